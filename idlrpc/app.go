@@ -0,0 +1,273 @@
+package idlrpc
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+)
+
+// AppState lifecycle state of App
+type AppState int32
+
+const (
+	AppInit     AppState = iota // not run yet
+	AppStarting                 // initializing framework, registering sdk and services, running start hooks
+	AppReady                    // ticking, ready for calls
+	AppStopping                 // running stop hooks, shutting down framework
+	AppStopped                  // stopped, can not run again
+)
+
+const defaultTickRate = 10 * time.Millisecond
+
+type (
+	// AppHook startup or shutdown hook, error of startup hook stops app from starting
+	AppHook func(app *App) error
+
+	AppOption func(*App)
+
+	// App runner of rpc framework, owns framework lifecycle, tick loop and os signals
+	App struct {
+		rpc      *rpcImpl
+		rpcOpts  []Option
+		tickRate time.Duration
+		sdks     []ISDK
+		host     []string // packages hosted, service name
+		proxy    []string // packages only called, service name
+		services []IService
+		onStart  []AppHook
+		onStop   []AppHook
+		signals  bool          // handle SIGINT, SIGTERM to stop, SIGHUP to reload config
+		state    int32         // AppState
+		stopCh   chan struct{} // closed by Stop
+		stopOnce sync.Once
+	}
+)
+
+// WithRpcOptions options of rpc framework owned by app
+func WithRpcOptions(opts ...Option) AppOption {
+	return func(app *App) {
+		app.rpcOpts = append(app.rpcOpts, opts...)
+	}
+}
+
+// WithTickRate interval of framework Tick, default 10ms
+func WithTickRate(rate time.Duration) AppOption {
+	return func(app *App) {
+		if rate > 0 {
+			app.tickRate = rate
+		}
+	}
+}
+
+// WithSDK sdk registered to framework on start, before services
+func WithSDK(sdks ...ISDK) AppOption {
+	return func(app *App) {
+		app.sdks = append(app.sdks, sdks...)
+	}
+}
+
+// WithPackages registered packages loaded on start, besides host and proxy lists of config.
+// once any package is listed, services not hosted are skipped on start
+func WithPackages(host, proxy []string) AppOption {
+	return func(app *App) {
+		app.host = append(app.host, host...)
+		app.proxy = append(app.proxy, proxy...)
+	}
+}
+
+// WithService service registered to framework on start
+func WithService(services ...IService) AppOption {
+	return func(app *App) {
+		app.services = append(app.services, services...)
+	}
+}
+
+// OnStart hook called after services registered, before app is ready
+func OnStart(hook AppHook) AppOption {
+	return func(app *App) {
+		app.onStart = append(app.onStart, hook)
+	}
+}
+
+// OnStop hook called before framework shut down, in reverse order of adding
+func OnStop(hook AppHook) AppOption {
+	return func(app *App) {
+		app.onStop = append(app.onStop, hook)
+	}
+}
+
+// WithSignal handle os signal or not, default true
+func WithSignal(handle bool) AppOption {
+	return func(app *App) {
+		app.signals = handle
+	}
+}
+
+// NewApp create app with its own rpc framework
+func NewApp(opts ...AppOption) *App {
+	app := &App{
+		rpc:      CreateRpcFramework().(*rpcImpl),
+		tickRate: defaultTickRate,
+		signals:  true,
+		stopCh:   make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(app)
+	}
+	return app
+}
+
+// Rpc rpc framework of app
+func (app *App) Rpc() IRpc {
+	return app.rpc
+}
+
+// State lifecycle state of app
+func (app *App) State() AppState {
+	return AppState(atomic.LoadInt32(&app.state))
+}
+
+// Ready app is ticking and ready for calls
+func (app *App) Ready() bool {
+	return app.State() == AppReady
+}
+
+// Stop ask Run to stop, can be called from any goroutine
+func (app *App) Stop() {
+	app.stopOnce.Do(func() {
+		close(app.stopCh)
+	})
+}
+
+// Run start framework and tick it in calling goroutine until Stop or os stop signal
+func (app *App) Run() error {
+	if !atomic.CompareAndSwapInt32(&app.state, int32(AppInit), int32(AppStarting)) {
+		return errors.NewRpcError(errors.CommErr, "app has been run")
+	}
+	if err := app.start(); err != nil {
+		app.shutdown()
+		return err
+	}
+
+	var sigCh chan os.Signal
+	if app.signals {
+		sigCh = make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		defer signal.Stop(sigCh)
+	}
+
+	atomic.StoreInt32(&app.state, int32(AppReady))
+	ticker := time.NewTicker(app.tickRate)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = app.rpc.Tick()
+		case sig := <-sigCh:
+			if sig == syscall.SIGHUP {
+				app.rpc.logger.Info("[App] reload config on signal %v", sig)
+				_ = app.rpc.ReloadConfig()
+				continue
+			}
+			app.rpc.logger.Info("[App] stop on signal %v", sig)
+			return app.shutdown()
+		case <-app.stopCh:
+			return app.shutdown()
+		}
+	}
+}
+
+// start init framework, register sdk and services, then call start hooks
+func (app *App) start() error {
+	if err := app.rpc.Init(app.rpcOpts...); err != nil {
+		return err
+	}
+	for _, sdk := range app.sdks {
+		if err := sdk.Register(app.rpc); err != nil {
+			return err
+		}
+	}
+	hosted, err := app.loadPackages()
+	if err != nil {
+		return err
+	}
+	if err = app.rpc.Start(); err != nil {
+		return err
+	}
+	for _, service := range app.services {
+		if hosted != nil && !hosted[service.GetUUID()] {
+			app.rpc.logger.Info("[App] skip service %s, not hosted", service.GetNickName())
+			continue
+		}
+		if err = app.rpc.RegisterService(service); err != nil {
+			return err
+		}
+	}
+	for _, hook := range app.onStart {
+		if err = hook(app); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPackages load packages of options and config, return uuid of hosted services, nil if no package listed
+func (app *App) loadPackages() (map[uint64]bool, error) {
+	conf := app.rpc.opt.Config()
+	host := append(append([]string{}, app.host...), conf.Host...)
+	proxy := append(append([]string{}, app.proxy...), conf.Proxy...)
+	if len(host) == 0 && len(proxy) == 0 {
+		return nil, nil
+	}
+
+	hosted := make(map[uint64]bool)
+	loaded := make(map[string]bool)
+	for _, name := range host {
+		if loaded[name] {
+			continue
+		}
+		sdk, err := LoadPackage(app.rpc, name, SdkHost)
+		if err != nil {
+			return nil, err
+		}
+		loaded[name] = true
+		hosted[sdk.GetUuid()] = true
+	}
+	for _, name := range proxy {
+		if loaded[name] {
+			continue
+		}
+		if _, err := LoadPackage(app.rpc, name, SdkProxy); err != nil {
+			return nil, err
+		}
+		loaded[name] = true
+	}
+	// explicit sdk still host its service
+	for _, sdk := range app.sdks {
+		if !sdk.IsProxy() {
+			hosted[sdk.GetUuid()] = true
+		}
+	}
+	return hosted, nil
+}
+
+// shutdown call stop hooks in reverse order, then shut down framework
+func (app *App) shutdown() error {
+	atomic.StoreInt32(&app.state, int32(AppStopping))
+	defer atomic.StoreInt32(&app.state, int32(AppStopped))
+
+	var err error
+	for i := len(app.onStop) - 1; i >= 0; i-- {
+		if hookErr := app.onStop[i](app); hookErr != nil && err == nil {
+			err = hookErr
+		}
+	}
+	// framework may not be started
+	_ = app.rpc.ShutDown()
+	return err
+}
@@ -0,0 +1,98 @@
+package idlrpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/logger"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+)
+
+// testService service without stub creator
+type testService struct{}
+
+func (ts *testService) GetUUID() uint64                  { return 2 }
+func (ts *testService) GetNickName() string              { return "Unregistered" }
+func (ts *testService) OnAfterFork(context.Context) bool { return true }
+func (ts *testService) OnTick() bool                     { return true }
+func (ts *testService) OnBeforeDestroy() bool            { return true }
+
+func TestAppRunStop(t *testing.T) {
+	var steps []string
+	hook := func(step string, err error) AppHook {
+		return func(app *App) error {
+			steps = append(steps, step)
+			return err
+		}
+	}
+	app := NewApp(
+		WithRpcOptions(WithLogger(&logger.NullLogger{})),
+		WithSignal(false),
+		WithTickRate(time.Millisecond),
+		OnStart(hook("start1", nil)),
+		OnStart(hook("start2", nil)),
+		OnStop(hook("stop1", nil)),
+		OnStop(hook("stop2", errors.NewRpcError(errors.CommErr, "stop failed"))),
+	)
+	if app.State() != AppInit || app.Ready() {
+		t.Fatalf("new app state %d", app.State())
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run()
+	}()
+	for start := time.Now(); !app.Ready(); time.Sleep(time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("app not ready, state %d", app.State())
+		}
+	}
+	if app.State() != AppReady || app.Rpc() == nil {
+		t.Fatalf("ready app state %d", app.State())
+	}
+
+	// error of stop hook is returned, the rest hooks still run
+	app.Stop()
+	app.Stop()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "stop failed") {
+			t.Fatalf("run returns %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("app not stopped")
+	}
+	if app.State() != AppStopped || strings.Join(steps, ",") != "start1,start2,stop2,stop1" {
+		t.Fatalf("unexpected state %d steps %v", app.State(), steps)
+	}
+	if err := app.Run(); err == nil {
+		t.Fatal("stopped app run again")
+	}
+}
+
+func TestAppStartFailed(t *testing.T) {
+	var steps []string
+	app := NewApp(
+		WithRpcOptions(WithLogger(&logger.NullLogger{})),
+		WithSignal(false),
+		WithService(&testService{}),
+		OnStart(func(*App) error {
+			steps = append(steps, "start")
+			return nil
+		}),
+		OnStop(func(*App) error {
+			steps = append(steps, "stop")
+			return nil
+		}),
+	)
+	// service without stub creator stops app before start hooks
+	err := app.Run()
+	var rpcErr *errors.RpcError
+	if !errors.As(err, &rpcErr) || rpcErr.Code() != errors.ServiceNotExist {
+		t.Fatalf("run returns %v", err)
+	}
+	if app.State() != AppStopped || strings.Join(steps, ",") != "stop" {
+		t.Fatalf("unexpected state %d steps %v", app.State(), steps)
+	}
+}
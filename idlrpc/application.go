@@ -1,7 +1,9 @@
 package idlrpc
 
 import (
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/logger"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/proxy"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/log"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/transport"
 )
@@ -10,12 +12,12 @@ const (
 	InvalidGlobalIndex = 0
 )
 
-func notFound(trans transport.ITransport, req *protocol.RpcCallHeader) {
+func notFound(trans transport.ITransport, wire protocol.Protocol, req *protocol.RpcCallHeader) {
 	resp := protocol.BuildNotFound(req)
 	if resp == nil {
 		return
 	}
-	resppkg, pkglen := protocol.PackRespMsg(resp)
+	resppkg, pkglen := wire.PackRespMsg(resp)
 	if resppkg == nil || pkglen == 0 {
 		//TODO 添加序列化错误
 		return
@@ -26,9 +28,9 @@ func notFound(trans transport.ITransport, req *protocol.RpcCallHeader) {
 	}
 }
 
-func notFoundReturnProxy(trans transport.ITransport, proxyReq *protocol.RpcProxyCallHeader) {
+func notFoundReturnProxy(trans transport.ITransport, wire protocol.Protocol, proxyReq *protocol.RpcProxyCallHeader) {
 	resp := protocol.BuildProxyNotFound(proxyReq)
-	respPkg, pkgLen := protocol.PackProxyRespMsg(resp)
+	respPkg, pkgLen := wire.PackProxyRespMsg(resp)
 	if respPkg == nil || pkgLen == 0 {
 		//TODO 添加序列化错误
 		return
@@ -41,11 +43,13 @@ func notFoundReturnProxy(trans transport.ITransport, proxyReq *protocol.RpcProxy
 
 func CreateRpcFramework() IRpc {
 	return &rpcImpl{
+		opt:            defaultOptions(),
 		proxyMgr:       newProxyManager(),
 		proxyCallMgr:   proxy.NewCallManager(),
 		stubMgr:        newStubManager(),
 		serviceFactory: make(stubFactoryMap),
-		logger:         nil,
+		logger:         &logger.NullLogger{},
+		flog:           log.FromLogger(&logger.NullLogger{}),
 		status:         RpcNotInit,
 	}
 }
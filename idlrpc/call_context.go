@@ -0,0 +1,205 @@
+package idlrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/log"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+// accessors of the incoming call for handlers, ctx is the one passed to service method
+
+type (
+	// metakey outgoing metadata set by WithMetadata
+	metakey struct{}
+
+	// CallInfo incoming call of handler
+	CallInfo struct {
+		ServiceUUID uint64
+		MethodID    uint32
+		Signature   string                   // method name
+		CallID      uint32                   // call id of caller's proxy call
+		GlobalIndex protocol.GlobalIndexType // caller behind proxy, InvalidGlobalIndex while called directly
+		OneWay      bool
+		RemoteAddr  string    // remote address of transport the call arrived on
+		RecvTime    time.Time // time request arrived
+		Deadline    time.Time // caller's deadline, zero if caller not send timeout
+		TraceID     string
+	}
+
+	// DeferredReply response of call sent after handler returns, see ReplyLater
+	DeferredReply struct {
+		sc *StubCall
+	}
+)
+
+// stubCallFrom stub call of handler ctx, nil while ctx is not a call context
+func stubCallFrom(ctx context.Context) *StubCall {
+	if ctx == nil {
+		return nil
+	}
+	sc, _ := ctx.Value(callkey{}).(*StubCall)
+	return sc
+}
+
+// CallInfoFromContext information of the incoming call, ok is false while ctx is not a call context
+func CallInfoFromContext(ctx context.Context) (info CallInfo, ok bool) {
+	sc := stubCallFrom(ctx)
+	if sc == nil {
+		return info, false
+	}
+	info = CallInfo{
+		ServiceUUID: sc.srvUuid,
+		MethodID:    sc.methodID,
+		Signature:   sc.signature,
+		CallID:      sc.callID,
+		GlobalIndex: sc.globalID,
+		OneWay:      sc.oneWay != 0,
+		RecvTime:    sc.recvTime,
+		Deadline:    sc.deadline,
+		TraceID:     sc.traceID,
+	}
+	if sc.trans != nil {
+		info.RemoteAddr = sc.trans.RemoteAddr()
+	}
+	return info, true
+}
+
+// CallerAddr remote address of transport the call arrived on, empty while ctx is not a call context
+func CallerAddr(ctx context.Context) string {
+	sc := stubCallFrom(ctx)
+	if sc == nil || sc.trans == nil {
+		return ""
+	}
+	return sc.trans.RemoteAddr()
+}
+
+// CallerGlobalIndex global index of caller behind proxy, InvalidGlobalIndex while called directly
+func CallerGlobalIndex(ctx context.Context) protocol.GlobalIndexType {
+	sc := stubCallFrom(ctx)
+	if sc == nil {
+		return InvalidGlobalIndex
+	}
+	return sc.globalID
+}
+
+// CallID call id of caller's proxy call, ok is false while ctx is not a call context
+func CallID(ctx context.Context) (id uint32, ok bool) {
+	sc := stubCallFrom(ctx)
+	if sc == nil {
+		return 0, false
+	}
+	return sc.callID, true
+}
+
+// TraceID trace id of the call chain, empty while ctx is not a call context
+func TraceID(ctx context.Context) string {
+	sc := stubCallFrom(ctx)
+	if sc == nil {
+		return ""
+	}
+	return sc.traceID
+}
+
+// Metadata key values sent by caller with WithMetadata, framework keys are not included.
+// returned map is a copy
+func Metadata(ctx context.Context) map[string]string {
+	sc := stubCallFrom(ctx)
+	if sc == nil || len(sc.meta) == 0 {
+		return nil
+	}
+	md := make(map[string]string, len(sc.meta))
+	for k, v := range sc.meta {
+		md[k] = v
+	}
+	return md
+}
+
+// WithMetadata attach key values sent with calls made by CallContext, kv is key, value pairs.
+// later value replaces earlier one of the same key, framework keys are ignored
+func WithMetadata(ctx context.Context, kv ...string) context.Context {
+	parent, _ := ctx.Value(metakey{}).(map[string]string)
+	md := make(map[string]string, len(parent)+len(kv)/2)
+	for k, v := range parent {
+		md[k] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		if !isFrameworkKey(kv[i]) {
+			md[kv[i]] = kv[i+1]
+		}
+	}
+	return context.WithValue(ctx, metakey{}, md)
+}
+
+func isFrameworkKey(key string) bool {
+	return key == protocol.CtxTimeoutKey || key == protocol.CtxTraceKey
+}
+
+// outgoingInfo trace id of current call and metadata set by WithMetadata, sent in Context field of args
+func outgoingInfo(ctx context.Context) map[string]string {
+	md, _ := ctx.Value(metakey{}).(map[string]string)
+	traceID := TraceID(ctx)
+	if traceID == "" {
+		return md
+	}
+	info := make(map[string]string, len(md)+1)
+	for k, v := range md {
+		info[k] = v
+	}
+	info[protocol.CtxTraceKey] = traceID
+	return info
+}
+
+// ReplyLater handler answers the call after it returns, from any goroutine by DeferredReply.
+// return value of handler is discarded, request arguments must be copied before handler returns.
+// calling it again returns reply of the same call
+func ReplyLater(ctx context.Context) (*DeferredReply, error) {
+	sc := stubCallFrom(ctx)
+	if sc == nil {
+		return nil, errors.ErrStubCallInvalid
+	}
+	if sc.oneWay != 0 {
+		return nil, errors.NewRpcError(errors.StubcallInvalid, "one-way method %s has no reply", sc.signature)
+	}
+	if !sc.deferReply() {
+		return nil, errors.ErrCallReplied
+	}
+	return &DeferredReply{sc: sc}, nil
+}
+
+// Reply send ret as response, ret is the generated return message of method, nil for method without return
+func (d *DeferredReply) Reply(ret proto.Message) error {
+	var body []byte
+	if ret != nil {
+		var err error
+		if body, err = proto.Marshal(ret); err != nil {
+			return err
+		}
+	}
+	return d.send(body, nil)
+}
+
+// ReplyError send err to caller like error returned by handler
+func (d *DeferredReply) ReplyError(err error) error {
+	if err == nil {
+		return d.Reply(nil)
+	}
+	return d.send(nil, err)
+}
+
+func (d *DeferredReply) send(body []byte, callErr error) error {
+	if !d.sc.takeReply(replyDeferred) {
+		return errors.ErrCallReplied
+	}
+	if callErr != nil {
+		d.sc.callLogger().Warn("[Service] method deferred error", log.KV("error", callErr))
+	}
+	if err := d.sc.respond(body, callErr); err != nil {
+		d.sc.callLogger().Warn("[Service] method send deferred reply error!", log.KV("error", err))
+		return err
+	}
+	return nil
+}
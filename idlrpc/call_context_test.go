@@ -0,0 +1,150 @@
+package idlrpc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+)
+
+func TestWithMetadata(t *testing.T) {
+	parent := WithMetadata(context.Background(), "zone", "1", "user", "bob")
+	child := WithMetadata(parent, "zone", "2", protocol.CtxTraceKey, "forged", protocol.CtxTimeoutKey, "1", "odd")
+	cases := []struct {
+		name   string
+		ctx    context.Context
+		expect map[string]string
+	}{
+		{"without metadata", context.Background(), nil},
+		{"parent", parent, map[string]string{"zone": "1", "user": "bob"}},
+		{"child overrides parent, framework keys and odd key ignored", child, map[string]string{"zone": "2", "user": "bob"}},
+	}
+	for _, c := range cases {
+		if info := outgoingInfo(c.ctx); !reflect.DeepEqual(info, c.expect) {
+			t.Errorf("%s: outgoing info %v, expect %v", c.name, info, c.expect)
+		}
+	}
+}
+
+func TestCallContextAccessors(t *testing.T) {
+	if _, ok := CallInfoFromContext(context.Background()); ok {
+		t.Fatal("call info from background context")
+	}
+	if _, ok := CallID(context.Background()); ok || TraceID(context.Background()) != "" || CallerAddr(context.Background()) != "" ||
+		CallerGlobalIndex(context.Background()) != InvalidGlobalIndex || Metadata(context.Background()) != nil {
+		t.Fatal("accessors return values of background context")
+	}
+
+	var (
+		info     CallInfo
+		md       map[string]string
+		outgoing map[string]string
+		deadline time.Time
+	)
+	stub := &testStub{handler: func(ctx context.Context, _ uint32, _ []byte) ([]byte, error) {
+		info, _ = CallInfoFromContext(ctx)
+		if id, ok := CallID(ctx); !ok || id != info.CallID || TraceID(ctx) != info.TraceID ||
+			CallerAddr(ctx) != info.RemoteAddr || CallerGlobalIndex(ctx) != info.GlobalIndex {
+			t.Errorf("accessors differ from call info %+v", info)
+		}
+		md = Metadata(ctx)
+		md["user"] = "changed"
+		outgoing = outgoingInfo(WithMetadata(ctx, "zone", "1"))
+		deadline, _ = ctx.Deadline()
+		return nil, nil
+	}}
+	sw := newTestWrapper(stub, serviceOptions{})
+	trans := newTestTrans()
+	call := newTestCall(trans, 5)
+	call.srvUuid, call.globalID, call.traceID = 1, 9, "trace-5"
+	call.meta = map[string]string{"user": "bob"}
+	call.deadline = time.Now().Add(time.Minute)
+	if err := sw.doCallMethod(call); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := CallInfo{
+		ServiceUUID: 1,
+		MethodID:    1,
+		Signature:   "method",
+		CallID:      5,
+		GlobalIndex: 9,
+		RemoteAddr:  "remote",
+		Deadline:    call.deadline,
+		TraceID:     "trace-5",
+	}
+	if info != expect {
+		t.Fatalf("call info %+v, expect %+v", info, expect)
+	}
+	if !deadline.Equal(call.deadline) {
+		t.Fatalf("handler deadline %v, expect caller's %v", deadline, call.deadline)
+	}
+	if md["user"] != "changed" || call.meta["user"] != "bob" {
+		t.Fatal("metadata is not a copy")
+	}
+	// incoming metadata is not forwarded, trace id is
+	if !reflect.DeepEqual(outgoing, map[string]string{"zone": "1", protocol.CtxTraceKey: "trace-5"}) {
+		t.Fatalf("outgoing info %v", outgoing)
+	}
+}
+
+func TestReplyLater(t *testing.T) {
+	if _, err := ReplyLater(context.Background()); !errors.Is(err, errors.ErrStubCallInvalid) {
+		t.Fatalf("reply later without call returns %v", err)
+	}
+
+	var (
+		first, second *DeferredReply
+		returned      context.Context
+	)
+	stub := &testStub{handler: func(ctx context.Context, _ uint32, req []byte) ([]byte, error) {
+		if string(req) == "direct" {
+			returned = ctx
+			return nil, nil
+		}
+		var err error
+		if first, err = ReplyLater(ctx); err != nil {
+			return nil, err
+		}
+		second, err = ReplyLater(ctx)
+		return nil, err
+	}}
+	sw := newTestWrapper(stub, serviceOptions{})
+	trans := newTestTrans()
+
+	if err := sw.doCallMethod(newTestCall(trans, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if first == nil || second == nil || first.sc != second.sc {
+		t.Fatal("reply later again returns reply of other call")
+	}
+	if err := first.ReplyError(errors.NewException(errors.ExceptionCodeMin, "late", "answered later")); err != nil {
+		t.Fatal(err)
+	}
+	header, body := trans.response(t)
+	code, name, _, ok := protocol.ReadExceptionInfo(body)
+	if header.CallID != 1 || header.ErrorCode != protocol.IDL_SERVICE_ERROR || !ok || code != uint32(errors.ExceptionCodeMin) || name != "late" {
+		t.Fatalf("deferred error response %+v code %d name %s", header, code, name)
+	}
+	if err := second.Reply(nil); !errors.Is(err, errors.ErrCallReplied) {
+		t.Fatalf("second reply returns %v", err)
+	}
+
+	// response of returned handler has been sent
+	direct := newTestCall(trans, 2)
+	direct.buffer = []byte("direct")
+	_ = sw.doCallMethod(direct)
+	trans.response(t)
+	if _, err := ReplyLater(returned); !errors.Is(err, errors.ErrCallReplied) {
+		t.Fatalf("reply later after response returns %v", err)
+	}
+
+	oneWay := newTestCall(trans, 3)
+	oneWay.oneWay = 1
+	if _, err := ReplyLater(context.WithValue(context.Background(), callkey{}, oneWay)); err == nil || errors.Is(err, errors.ErrCallReplied) {
+		t.Fatalf("reply later of one-way call returns %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package idlrpc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+)
+
+type (
+	// Config settings of framework, services and methods, loaded from json or toml file.
+	// zero value of field is not set, it keeps setting of options and idl. settings of a service
+	// are applied in order, later one overrides former one:
+	//	defaults and idl
+	//	WithServiceWorker
+	//	service_worker and queue_size of all services in file
+	//	WithDispatchMode and WithTickBudget of the service
+	//	workers and queue_size of the service in file
+	Config struct {
+		CallTimeout   uint32                   `json:"call_timeout"`   // timeout of proxy call without idl timeout, millisecond
+		MaxRetry      *int32                   `json:"max_retry"`      // max retry time of proxy call
+		ServiceWorker uint32                   `json:"service_worker"` // workers of service not declaring multiple number
+		QueueSize     uint32                   `json:"queue_size"`     // call queue size of service
+		Services      map[string]ServiceConfig `json:"services"`       // key is service name
+		Host          []string                 `json:"host"`           // packages hosted by app, service name
+		Proxy         []string                 `json:"proxy"`          // packages only called by app, service name
+	}
+
+	// ServiceConfig settings of one service, used by both its stub and its proxies
+	ServiceConfig struct {
+		Workers   uint32                  `json:"workers"`    // worker number, override idl multiple number
+		QueueSize uint32                  `json:"queue_size"` // call queue size
+		Methods   map[string]MethodConfig `json:"methods"`    // key is method name
+	}
+
+	// MethodConfig settings of proxy call of one method, override idl
+	MethodConfig struct {
+		Timeout uint32 `json:"timeout"` // millisecond
+		Retry   *int32 `json:"retry"`   // retry time after timeout
+	}
+
+	// configHolder config of rpc instance, replaced as a whole on reload
+	configHolder struct {
+		v atomic.Value // *Config
+	}
+)
+
+// LoadConfig read config file, format is decided by extension, .toml or .json
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewRpcError(errors.CommErr, "read config %s error %v", path, err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		var table map[string]interface{}
+		if table, err = parseToml(data); err == nil {
+			// toml table has the same shape as json object
+			data, err = json.Marshal(table)
+		}
+	case ".json":
+	default:
+		return nil, errors.NewRpcError(errors.CommErr, "unknown config format %s", path)
+	}
+	conf := &Config{}
+	if err == nil {
+		err = json.Unmarshal(data, conf)
+	}
+	if err != nil {
+		return nil, errors.NewRpcError(errors.CommErr, "parse config %s error %v", path, err)
+	}
+	return conf, nil
+}
+
+// method settings of method of service, timeout and retry are kept if not set
+func (c *Config) method(service, method string, timeout uint32, retry int32) (uint32, int32) {
+	mc, ok := c.Services[service].Methods[method]
+	if !ok {
+		return timeout, retry
+	}
+	if mc.Timeout > 0 {
+		timeout = mc.Timeout
+	}
+	if mc.Retry != nil {
+		retry = *mc.Retry
+	}
+	return timeout, retry
+}
+
+// global override execution settings of all services by settings set in file
+func (c *Config) global(setting *serviceOptions) {
+	if c.ServiceWorker > 0 {
+		setting.workers = c.ServiceWorker
+	}
+	if c.QueueSize > 0 {
+		setting.queueSize = c.QueueSize
+	}
+}
+
+// service override execution settings of service by its settings set in file
+func (c *Config) service(name string, setting *serviceOptions) {
+	sc, ok := c.Services[name]
+	if !ok {
+		return
+	}
+	if sc.Workers > 0 {
+		setting.workers = sc.Workers
+		setting.fixedWorkers = true
+	}
+	if sc.QueueSize > 0 {
+		setting.queueSize = sc.QueueSize
+	}
+}
+
+func (h *configHolder) load() *Config {
+	if conf, ok := h.v.Load().(*Config); ok {
+		return conf
+	}
+	return &Config{}
+}
+
+func (h *configHolder) store(conf *Config) {
+	h.v.Store(conf)
+}
@@ -0,0 +1,303 @@
+package idlrpc
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parseToml parse toml subset used by config: tables, dotted and quoted keys,
+// strings, decimal integers, floats, booleans and single line arrays
+func parseToml(data []byte) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	current := root
+	var (
+		path    []string                // keys of current table
+		headers = make(map[string]bool) // tables defined by [table], defined only once
+		dotted  = make(map[string]bool) // tables defined by dotted keys, not defined by [table] again
+	)
+	for i, line := range strings.Split(string(data), "\n") {
+		if pos := indexUnquoted(line, '#'); pos >= 0 {
+			line = line[:pos]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// [table]
+		if strings.HasPrefix(line, "[") {
+			if strings.HasPrefix(line, "[[") || !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unsupported table %q", i+1, line)
+			}
+			keys, err := splitKey(line[1 : len(line)-1])
+			if err == nil {
+				current, err = subTable(root, keys)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			name := tableName(keys)
+			if headers[name] || dotted[name] {
+				return nil, fmt.Errorf("line %d: table %q defined twice", i+1, strings.Join(keys, "."))
+			}
+			headers[name] = true
+			path = keys
+			continue
+		}
+
+		// key = value
+		eq := indexUnquoted(line, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expect key = value, got %q", i+1, line)
+		}
+		keys, err := splitKey(line[:eq])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		value, err := parseTomlValue(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		table, err := subTable(current, keys[:len(keys)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		for n := 1; n < len(keys); n++ {
+			sub := append(path[:len(path):len(path)], keys[:n]...)
+			name := tableName(sub)
+			if headers[name] {
+				return nil, fmt.Errorf("line %d: table %q defined twice", i+1, strings.Join(sub, "."))
+			}
+			dotted[name] = true
+		}
+		last := keys[len(keys)-1]
+		if _, ok := table[last]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %q", i+1, last)
+		}
+		table[last] = value
+	}
+	return root, nil
+}
+
+// tableName key of table path, parts may contain dots
+func tableName(keys []string) string {
+	return fmt.Sprintf("%q", keys)
+}
+
+// indexUnquoted index of first c not inside string, -1 if not found
+func indexUnquoted(s string, c byte) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == c:
+			return i
+		}
+	}
+	return -1
+}
+
+// indexItemEnd index of comma ending first array item, commas of nested arrays and strings are skipped
+func indexItemEnd(s string) int {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '[':
+			depth++
+		case s[i] == ']':
+			depth--
+		case s[i] == ',' && depth == 0:
+			return i
+		}
+	}
+	return -1
+}
+
+// splitKey split dotted key, parts may be quoted
+func splitKey(s string) ([]string, error) {
+	var keys []string
+	for {
+		dot := indexUnquoted(s, '.')
+		part := s
+		if dot >= 0 {
+			part = s[:dot]
+		}
+		key, err := parseKey(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		if dot < 0 {
+			return keys, nil
+		}
+		s = s[dot+1:]
+	}
+}
+
+func parseKey(s string) (string, error) {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') {
+		v, err := parseTomlString(s)
+		if err != nil {
+			return "", err
+		}
+		return v.(string), nil
+	}
+	if s == "" {
+		return "", fmt.Errorf("empty key")
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return "", fmt.Errorf("invalid key %q", s)
+		}
+	}
+	return s, nil
+}
+
+// subTable get or create table under t by keys
+func subTable(t map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, key := range keys {
+		v, ok := t[key]
+		if !ok {
+			sub := make(map[string]interface{})
+			t[key] = sub
+			t = sub
+			continue
+		}
+		sub, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("key %q is not a table", key)
+		}
+		t = sub
+	}
+	return t, nil
+}
+
+func parseTomlValue(s string) (interface{}, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("missing value")
+	case s[0] == '"' || s[0] == '\'':
+		return parseTomlString(s)
+	case s == "true" || s == "false":
+		return s == "true", nil
+	case s[0] == '[':
+		if s[len(s)-1] != ']' {
+			return nil, fmt.Errorf("unsupported multi line array %q", s)
+		}
+		values := make([]interface{}, 0)
+		s = s[1 : len(s)-1]
+		for strings.TrimSpace(s) != "" {
+			comma := indexItemEnd(s)
+			item := s
+			if comma >= 0 {
+				item, s = s[:comma], s[comma+1:]
+			} else {
+				s = ""
+			}
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			v, err := parseTomlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+	return parseTomlNumber(s)
+}
+
+// parseTomlNumber decimal integer or float, underscore only between digits,
+// leading zero, hex, octal and binary integers are rejected
+func parseTomlNumber(s string) (interface{}, error) {
+	digits := s
+	if digits[0] == '+' || digits[0] == '-' {
+		digits = digits[1:]
+	}
+	switch digits {
+	case "inf":
+		return strconv.ParseFloat(s, 64)
+	case "nan":
+		return math.NaN(), nil
+	}
+	isFloat := false
+	for i := 0; i < len(digits); i++ {
+		c := digits[i]
+		digitBefore := i > 0 && isDigit(digits[i-1])
+		digitAfter := i+1 < len(digits) && isDigit(digits[i+1])
+		switch {
+		case isDigit(c):
+		case c == '_' || c == '.':
+			if !digitBefore || !digitAfter {
+				return nil, fmt.Errorf("unsupported value %q", s)
+			}
+			isFloat = isFloat || c == '.'
+		case c == 'e' || c == 'E':
+			if !digitBefore {
+				return nil, fmt.Errorf("unsupported value %q", s)
+			}
+			isFloat = true
+		case c == '+' || c == '-':
+			if i == 0 || digits[i-1] != 'e' && digits[i-1] != 'E' {
+				return nil, fmt.Errorf("unsupported value %q", s)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported value %q", s)
+		}
+	}
+	if len(digits) > 1 && digits[0] == '0' && isDigit(digits[1]) {
+		return nil, fmt.Errorf("leading zero in number %q", s)
+	}
+	num := strings.Replace(s, "_", "", -1)
+	var (
+		v   interface{}
+		err error
+	)
+	if isFloat {
+		v, err = strconv.ParseFloat(num, 64)
+	} else {
+		v, err = strconv.ParseInt(num, 10, 64)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unsupported value %q", s)
+	}
+	return v, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parseTomlString basic string with escapes, or literal string
+func parseTomlString(s string) (interface{}, error) {
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return nil, fmt.Errorf("unterminated string %s", s)
+	}
+	if s[0] == '\'' {
+		return s[1 : len(s)-1], nil
+	}
+	v, err := strconv.Unquote(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid string %s", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,112 @@
+package idlrpc
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseToml(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		expect map[string]interface{}
+	}{
+		{"integers", "a = 0\nb = -17\nc = +1_000\nd = 9223372036854775807",
+			map[string]interface{}{"a": int64(0), "b": int64(-17), "c": int64(1000), "d": int64(math.MaxInt64)}},
+		{"floats", "a = 0.5\nb = -1e3\nc = 6.02E+23\nd = 1_0.2_5\ne = -inf\nf = 1e05",
+			map[string]interface{}{"a": 0.5, "b": -1000.0, "c": 6.02e23, "d": 10.25, "e": math.Inf(-1), "f": 1e5}},
+		{"booleans", "on = true\noff = false",
+			map[string]interface{}{"on": true, "off": false}},
+		{"strings with escapes", `a = "tab\there \"quoted\" \u00e9"` + "\n" + `b = 'C:\path\no escape'` + "\n" + `c = "has # and = inside"`,
+			map[string]interface{}{"a": "tab\there \"quoted\" é", "b": `C:\path\no escape`, "c": "has # and = inside"}},
+		{"arrays", `a = [1, 2, 3]` + "\n" + `b = ["x,y", 'z', ]` + "\n" + `c = []` + "\n" + `d = [[1, 2], ["a"]]` + "\n" + `e = ["a],[b", ['c\'], "\"]"]`,
+			map[string]interface{}{
+				"a": []interface{}{int64(1), int64(2), int64(3)},
+				"b": []interface{}{"x,y", "z"},
+				"c": []interface{}{},
+				"d": []interface{}{[]interface{}{int64(1), int64(2)}, []interface{}{"a"}},
+				"e": []interface{}{"a],[b", []interface{}{`c\`}, `"]`},
+			}},
+		{"nested tables", "top = 1\n[service.Player]\ntimeout = 10\n[service.Player.method.login]\nretry = 2\n[service.\"Bag.v2\"]\nworkers = 4\nlimits.max = 8",
+			map[string]interface{}{
+				"top": int64(1),
+				"service": map[string]interface{}{
+					"Player": map[string]interface{}{
+						"timeout": int64(10),
+						"method":  map[string]interface{}{"login": map[string]interface{}{"retry": int64(2)}},
+					},
+					"Bag.v2": map[string]interface{}{"workers": int64(4), "limits": map[string]interface{}{"max": int64(8)}},
+				},
+			}},
+		{"super table after sub table, dotted keys sharing table", "[a.b]\nx = 1\n[a]\nc.y = 2\nc.z = 3",
+			map[string]interface{}{"a": map[string]interface{}{
+				"b": map[string]interface{}{"x": int64(1)},
+				"c": map[string]interface{}{"y": int64(2), "z": int64(3)},
+			}}},
+		{"comments", "# header\n\n  # indented\na = 1 # tail\nb = \"#not comment\" # tail",
+			map[string]interface{}{"a": int64(1), "b": "#not comment"}},
+	}
+	for _, c := range cases {
+		got, err := parseToml([]byte(c.input))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.expect) {
+			t.Errorf("%s: expect %v, got %v", c.name, c.expect, got)
+		}
+	}
+
+	got, err := parseToml([]byte("a = nan"))
+	if v, ok := got["a"].(float64); err != nil || !ok || !math.IsNaN(v) {
+		t.Errorf("nan: got %v %v", got, err)
+	}
+}
+
+func TestParseTomlRejected(t *testing.T) {
+	cases := []struct {
+		input string
+		error string
+	}{
+		{"a = 0x10", "unsupported value"},
+		{"a = 0o17", "unsupported value"},
+		{"a = 0b11", "unsupported value"},
+		{"a = 017", "leading zero"},
+		{"a = -007", "leading zero"},
+		{"a = 01.5", "leading zero"},
+		{"a = 1__0", "unsupported value"},
+		{"a = _1", "unsupported value"},
+		{"a = 1_", "unsupported value"},
+		{"a = 1.", "unsupported value"},
+		{"a = .5", "unsupported value"},
+		{"a = 1e", "unsupported value"},
+		{"a = 1+2", "unsupported value"},
+		{"a = +-1", "unsupported value"},
+		{"a = Inf", "unsupported value"},
+		{"a = 99999999999999999999", "unsupported value"},
+		{"a = yes", "unsupported value"},
+		{"a =", "missing value"},
+		{`a = "open`, "unterminated string"},
+		{`a = "bad \q"`, "invalid string"},
+		{"a = [1, 2", "unsupported multi line array"},
+		{"a = 1\na = 2", "duplicate key"},
+		{"a = 1\n[a]", "is not a table"},
+		{"[a]\nx = 1\n[b]\n[a]\ny = 2", "table \"a\" defined twice"},
+		{"[a.b]\n[a]\n[a.b]", "table \"a.b\" defined twice"},
+		{"[a]\nb.c = 1\n[a.b]", "table \"a.b\" defined twice"},
+		{"[a.b]\n[a]\nb.c = 1", "table \"a.b\" defined twice"},
+		{"[[servers]]", "unsupported table"},
+		{"[table", "unsupported table"},
+		{"just text", "expect key = value"},
+		{"bad key = 1", "invalid key"},
+		{". = 1", "empty key"},
+	}
+	for _, c := range cases {
+		_, err := parseToml([]byte(c.input))
+		if err == nil || !strings.Contains(err.Error(), c.error) {
+			t.Errorf("%q: expect error %q, got %v", c.input, c.error, err)
+		}
+	}
+}
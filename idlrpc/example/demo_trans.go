@@ -51,6 +51,17 @@ func (trans *TransportRing) Read(pkg []byte, length int) (int, error) {
 	trans.recvbuffer = trans.recvbuffer[length:]
 	return length, nil
 }
+
+// ReadFrame hand over frame without copying, written data is always appended after it
+func (trans *TransportRing) ReadFrame(length int) ([]byte, error) {
+	if len(trans.recvbuffer) < length {
+		return nil, errors.New("frame not arrived")
+	}
+	frame := trans.recvbuffer[:length:length]
+	trans.recvbuffer = trans.recvbuffer[length:]
+	return frame, nil
+}
+
 func (trans *TransportRing) Peek(length int) ([]byte, int, error) {
 	if trans == nil {
 		return nil, 0, errors.New("Messages Trans Error !")
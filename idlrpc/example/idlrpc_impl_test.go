@@ -2,100 +2,75 @@ package example
 
 import (
 	"context"
+	gerrors "errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/CloudGuan/rpc-backend-go/idlrpc"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/example/pbdata"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/logger"
+	rpcerrors "github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/log"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/transport"
 	"google.golang.org/protobuf/proto"
 )
 
-type (
-	testApp struct {
-		rpc    idlrpc.IRpc
-		cancel chan bool
-		wg     sync.WaitGroup
-	}
-)
-
-func (t *testApp) init() error {
-	t.cancel = make(chan bool)
-	t.wg = sync.WaitGroup{}
-
-	t.rpc = idlrpc.CreateRpcFramework()
-	err := t.rpc.Init(idlrpc.WithLogger(&logger.DefaultLogger{}), idlrpc.WithStackTrace(true))
-	return err
+// testApp app running in background with TestCaller sdk
+type testApp struct {
+	*idlrpc.App
+	done chan error
 }
 
-func (t *testApp) start() {
-	_ = t.rpc.Start()
+// startApp run app until it is ready
+func startApp(t *testing.T, opts ...idlrpc.AppOption) *testApp {
+	opts = append([]idlrpc.AppOption{
+		idlrpc.WithRpcOptions(idlrpc.WithLogger(&logger.DefaultLogger{}), idlrpc.WithStackTrace(true)),
+		idlrpc.WithSDK(&TestCallerSDK{}),
+		idlrpc.WithSignal(false),
+	}, opts...)
+	app := &testApp{App: idlrpc.NewApp(opts...), done: make(chan error, 1)}
 	go func() {
-		t.wg.Add(1)
-		defer t.wg.Done()
-
-		timer := time.NewTimer(10 * time.Millisecond)
-		for {
-			select {
-			case <-timer.C:
-				t.rpc.Tick()
-				timer.Reset(10 * time.Millisecond)
-			case <-t.cancel:
-				return
-			}
-		}
+		app.done <- app.Run()
 	}()
+	for !app.Ready() {
+		if app.State() == idlrpc.AppStopped {
+			t.Fatalf("app start error %v", <-app.done)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return app
 }
 
-func (t *testApp) stop() {
-	t.cancel <- true
-	t.wg.Wait()
-	_ = t.rpc.ShutDown()
+func (t *testApp) stop() error {
+	t.Stop()
+	return <-t.done
 }
 
 func TestRpcInit(t *testing.T) {
-	app := testApp{}
-	if err := app.init(); err != nil {
-		t.Fatal(err)
-	}
-	app.start()
+	app := startApp(t)
 	time.Sleep(1 * time.Second)
-	app.stop()
+	_ = app.stop()
 }
 
 func TestAddService(t *testing.T) {
-	app := testApp{}
-	if err := app.init(); err != nil {
-		t.Fatal(err)
-	}
-	if err := app.rpc.AddStubCreator(SrvUUID, TestCallerStubCreator); err != nil {
-		t.Fatal(err)
-	}
-	app.start()
-	if err := app.rpc.RegisterService(NewTestCaller()); err != nil {
-		t.Fatal(err)
-	}
+	app := startApp(t, idlrpc.WithService(NewTestCaller()))
 	time.Sleep(1 * time.Second)
-	app.stop()
+	_ = app.stop()
 }
 
 func TestSendMessage(t *testing.T) {
-	app := testApp{}
 	trans := NewTransportRing()
 	caller := NewTestCaller()
-
-	if err := app.init(); err != nil {
-		t.Fatal(err)
-	}
-	if err := app.rpc.AddStubCreator(SrvUUID, TestCallerStubCreator); err != nil {
-		t.Fatal(err)
-	}
-	app.start()
-	if err := app.rpc.RegisterService(caller); err != nil {
-		t.Fatal(err)
-	}
+	app := startApp(t, idlrpc.WithService(caller))
 
 	pbarg := &pbdata.TestCaller_SetInfoArgs{}
 	pbarg.Arg1 = "hello"
@@ -123,29 +98,18 @@ func TestSendMessage(t *testing.T) {
 	reqData, _ := protocol.PackReqMsg(reqPb)
 	_, _ = trans.Write(reqData, len(reqData))
 
-	_ = app.rpc.OnMessage(trans, context.Background())
+	_ = app.Rpc().OnMessage(trans, context.Background())
 	time.Sleep(2 * time.Second)
 	if caller.name != "hello" {
 		t.Error("call method1 failed! ")
 	}
-	app.stop()
+	_ = app.stop()
 }
 
 func TestPeerPanic(t *testing.T) {
-	app := testApp{}
 	trans := NewTransportRing()
 	caller := NewTestCaller()
-
-	if err := app.init(); err != nil {
-		t.Fatal(err)
-	}
-	if err := app.rpc.AddStubCreator(SrvUUID, TestCallerStubCreator); err != nil {
-		t.Fatal(err)
-	}
-	app.start()
-	if err := app.rpc.RegisterService(caller); err != nil {
-		t.Fatal(err)
-	}
+	app := startApp(t, idlrpc.WithService(caller))
 
 	pbarg := &pbdata.TestCaller_GetInfoArgs{}
 
@@ -171,26 +135,17 @@ func TestPeerPanic(t *testing.T) {
 
 	reqData, _ := protocol.PackReqMsg(reqPb)
 	_, _ = trans.Write(reqData, len(reqData))
-	_ = app.rpc.OnMessage(trans, context.Background())
+	_ = app.Rpc().OnMessage(trans, context.Background())
 	time.Sleep(2 * time.Second)
-	app.stop()
+	_ = app.stop()
 }
 
 func TestRetryRpcCall(t *testing.T) {
-	app := testApp{}
 	trans := NewTransportRing()
-	//caller := NewTestCaller()
-
-	if err := app.init(); err != nil {
-		t.Fatal(err)
-	}
-	if err := app.rpc.AddProxyCreator(SrvUUID, TestCallerProxyCreator); err != nil {
-		t.Fatal(err)
-	}
-	app.start()
+	app := startApp(t)
 
 	// get proxy
-	pInterface, err := app.rpc.GetServiceProxy(SrvUUID, trans)
+	pInterface, err := app.Rpc().GetServiceProxy(SrvUUID, trans)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -204,5 +159,1233 @@ func TestRetryRpcCall(t *testing.T) {
 		t.Fatalf("unexception error return")
 	}
 
-	app.stop()
+	_ = app.stop()
+}
+
+type (
+	keyedStub struct {
+		*TestCallerStub
+	}
+
+	orderCaller struct {
+		TestCallerImpl
+		mu   sync.Mutex
+		seqs map[string][]int
+	}
+)
+
+func (ks *keyedStub) GetMutipleNum() uint32 {
+	return 4
+}
+
+// keyedSDK register keyed TestCaller stub
+type keyedSDK struct {
+	TestCallerSDK
+}
+
+func (*keyedSDK) Register(rpc idlrpc.IRpc) error {
+	return rpc.AddStubCreator(SrvUUID, func(v interface{}) idlrpc.IStub {
+		return &keyedStub{TestCallerStubCreator(v).(*TestCallerStub)}
+	})
+}
+
+func (oc *orderCaller) SetInfo(ctx context.Context, info string) error {
+	var key string
+	var seq int
+	_, _ = fmt.Sscanf(info, "%s %d", &key, &seq)
+	// give other workers a chance to overtake
+	time.Sleep(time.Duration(seq%3) * time.Millisecond)
+	oc.mu.Lock()
+	oc.seqs[key] = append(oc.seqs[key], seq)
+	oc.mu.Unlock()
+	return nil
+}
+
+func TestKeyedDispatch(t *testing.T) {
+	trans := NewTransportRing()
+	caller := &orderCaller{seqs: make(map[string][]int)}
+	app := startApp(t,
+		idlrpc.WithRpcOptions(idlrpc.WithDispatchMode(SrvUUID, idlrpc.DispatchKeyed)),
+		idlrpc.WithSDK(&keyedSDK{}),
+		idlrpc.WithService(caller),
+	)
+
+	const keys, calls = 8, 20
+	for seq := 0; seq < calls; seq++ {
+		for key := 1; key <= keys; key++ {
+			pkg, _ := proto.Marshal(&pbdata.TestCaller_SetInfoArgs{Arg1: fmt.Sprintf("%d %d", key, seq)})
+			reqPb := &protocol.ProxyRequestPackage{
+				Header: &protocol.RpcProxyCallHeader{
+					RpcMsgHeader: protocol.RpcMsgHeader{
+						Length: uint32(protocol.ProxyCallHeadSize + len(pkg)),
+						Type:   protocol.ProxyRequestMsg,
+					},
+					ServiceUUID: SrvUUID,
+					CallID:      uint32(seq*keys + key),
+					MethodID:    1,
+					GlobalIndex: protocol.GlobalIndexType(key),
+				},
+				Buffer: pkg,
+			}
+			reqData, _ := protocol.PackProxyReqMsg(reqPb)
+			_, _ = trans.Write(reqData, len(reqData))
+		}
+	}
+	_ = app.Rpc().OnMessage(trans, context.Background())
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		caller.mu.Lock()
+		done := 0
+		for _, seqs := range caller.seqs {
+			done += len(seqs)
+		}
+		caller.mu.Unlock()
+		if done == keys*calls || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	_ = app.stop()
+
+	if len(caller.seqs) != keys {
+		t.Fatalf("expect %d keys, got %d", keys, len(caller.seqs))
+	}
+	for key, seqs := range caller.seqs {
+		if len(seqs) != calls {
+			t.Fatalf("key %s expect %d calls, got %d", key, calls, len(seqs))
+		}
+		for i, seq := range seqs {
+			if seq != i {
+				t.Fatalf("key %s call out of order: %v", key, seqs)
+			}
+		}
+	}
+}
+
+type countCaller struct {
+	TestCallerImpl
+	calls    int
+	ticks    int
+	deadline time.Time
+}
+
+func (cc *countCaller) SetInfo(ctx context.Context, info string) error {
+	cc.calls++
+	cc.deadline, _ = ctx.Deadline()
+	return nil
+}
+
+func (cc *countCaller) OnTick() bool {
+	cc.ticks++
+	return true
+}
+
+func TestTickDispatch(t *testing.T) {
+	trans := NewTransportRing()
+	caller := &countCaller{}
+
+	rpc := idlrpc.CreateRpcFramework()
+	err := rpc.Init(
+		idlrpc.WithLogger(&logger.DefaultLogger{}),
+		idlrpc.WithDispatchMode(SrvUUID, idlrpc.DispatchTick),
+		idlrpc.WithTickBudget(SrvUUID, 2, 0),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rpc.AddStubCreator(SrvUUID, TestCallerStubCreator); err != nil {
+		t.Fatal(err)
+	}
+	_ = rpc.Start()
+	if err = rpc.RegisterService(caller); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		pkg, _ := proto.Marshal(&pbdata.TestCaller_SetInfoArgs{Arg1: "tick"})
+		reqData, _ := protocol.PackReqMsg(&protocol.RequestPackage{
+			Header: &protocol.RpcCallHeader{
+				RpcMsgHeader: protocol.RpcMsgHeader{
+					Length: uint32(protocol.CallHeadSize + len(pkg)),
+					Type:   protocol.RequestMsg,
+				},
+				ServiceUUID: SrvUUID,
+				CallID:      uint32(i + 1),
+				MethodID:    1,
+			},
+			Buffer: pkg,
+		})
+		_, _ = trans.Write(reqData, len(reqData))
+	}
+	_ = rpc.OnMessage(trans, context.Background())
+
+	// nothing runs outside of tick
+	time.Sleep(50 * time.Millisecond)
+	if caller.calls != 0 {
+		t.Fatalf("tick mode call executed outside of tick, calls %d", caller.calls)
+	}
+
+	expects := []int{2, 4, 5, 5}
+	for i, expect := range expects {
+		_ = rpc.Tick()
+		if caller.calls != expect || caller.ticks != i+1 {
+			t.Fatalf("tick %d expect %d calls, got %d calls %d ticks", i+1, expect, caller.calls, caller.ticks)
+		}
+	}
+	// every non one-way call has been answered
+	for i := 0; i < 5; i++ {
+		resp := trans.PopSend()
+		header := protocol.ReadRetHeader(resp)
+		if header == nil || header.ErrorCode != protocol.IDL_SUCCESS {
+			t.Fatalf("unexpected response %v", header)
+		}
+	}
+	_ = rpc.ShutDown()
+}
+
+// packSetInfo pack SetInfo request, with Context key values like proxy of other language does
+func packSetInfo(callID uint32, kvs ...string) []byte {
+	args := &pbdata.TestCaller_SetInfoArgs{Arg1: "deadline", Ctx: &pbdata.Context{}}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		args.Ctx.Info = append(args.Ctx.Info, &pbdata.KeyValue{Key: kvs[i], Value: kvs[i+1]})
+	}
+	pkg, _ := proto.Marshal(args)
+	reqData, _ := protocol.PackReqMsg(&protocol.RequestPackage{
+		Header: &protocol.RpcCallHeader{
+			RpcMsgHeader: protocol.RpcMsgHeader{
+				Length: uint32(protocol.CallHeadSize + len(pkg)),
+				Type:   protocol.RequestMsg,
+			},
+			ServiceUUID: SrvUUID,
+			CallID:      callID,
+			MethodID:    1,
+		},
+		Buffer: pkg,
+	})
+	return reqData
+}
+
+func TestTickQueueBusy(t *testing.T) {
+	trans := NewTransportRing()
+	rpc := idlrpc.CreateRpcFramework()
+	err := rpc.Init(
+		idlrpc.WithLogger(&logger.NullLogger{}),
+		idlrpc.WithServiceWorker(0, 1),
+		idlrpc.WithDispatchMode(SrvUUID, idlrpc.DispatchTick),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rpc.AddStubCreator(SrvUUID, TestCallerStubCreator)
+	_ = rpc.AddProxyCreator(SrvUUID, TestCallerProxyCreator)
+	_ = rpc.Start()
+	if err = rpc.RegisterService(&countCaller{}); err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	go pumpLoopback(rpc, trans, stop)
+	defer func() {
+		close(stop)
+		_ = rpc.ShutDown()
+	}()
+	p, _ := rpc.GetServiceProxy(SrvUUID, trans)
+	sp := p.(*TestCallerProxy)
+
+	// first call fills the queue, it waits for tick
+	first := make(chan error, 1)
+	go func() {
+		first <- sp.SetInfo("queued")
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	err = sp.SetInfo("rejected")
+	var rpcErr *rpcerrors.RpcError
+	if !gerrors.As(err, &rpcErr) || rpcErr.Code() != rpcerrors.ServiceBusy {
+		t.Fatalf("expect busy error, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("busy error arrived after call timeout")
+	}
+
+	_ = rpc.Tick()
+	if err = <-first; err != nil {
+		t.Fatalf("queued call error %v", err)
+	}
+}
+
+func TestDropExpiredCall(t *testing.T) {
+	trans := NewTransportRing()
+	caller := &countCaller{}
+
+	rpc := idlrpc.CreateRpcFramework()
+	err := rpc.Init(idlrpc.WithLogger(&logger.DefaultLogger{}), idlrpc.WithDispatchMode(SrvUUID, idlrpc.DispatchTick))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rpc.AddStubCreator(SrvUUID, TestCallerStubCreator); err != nil {
+		t.Fatal(err)
+	}
+	_ = rpc.Start()
+	if err = rpc.RegisterService(caller); err != nil {
+		t.Fatal(err)
+	}
+
+	// caller waits 20ms, call queued longer than that
+	reqData := packSetInfo(1, protocol.CtxTimeoutKey, "20")
+	_, _ = trans.Write(reqData, len(reqData))
+	_ = rpc.OnMessage(trans, context.Background())
+	time.Sleep(50 * time.Millisecond)
+	_ = rpc.Tick()
+	if caller.calls != 0 {
+		t.Fatal("expired call has been executed")
+	}
+	stats, err := idlrpc.GetServiceStats(rpc, SrvUUID)
+	if err != nil || stats.Expired != 1 {
+		t.Fatalf("expect 1 expired call, got %v %v", stats, err)
+	}
+
+	// call in time, handler see caller's deadline
+	start := time.Now()
+	reqData = packSetInfo(2, protocol.CtxTimeoutKey, "5000")
+	_, _ = trans.Write(reqData, len(reqData))
+	_ = rpc.OnMessage(trans, context.Background())
+	_ = rpc.Tick()
+	if caller.calls != 1 {
+		t.Fatal("call in time not executed")
+	}
+	if caller.deadline.Before(start.Add(4*time.Second)) || caller.deadline.After(time.Now().Add(5*time.Second)) {
+		t.Fatalf("unexpected handler deadline %v", caller.deadline)
+	}
+	header := protocol.ReadRetHeader(trans.PopSend())
+	if header == nil || header.CallID != 2 {
+		t.Fatalf("unexpected response %v", header)
+	}
+
+	// call without timeout has no deadline
+	reqData = packSetInfo(3)
+	_, _ = trans.Write(reqData, len(reqData))
+	_ = rpc.OnMessage(trans, context.Background())
+	_ = rpc.Tick()
+	if caller.calls != 2 || !caller.deadline.IsZero() {
+		t.Fatalf("unexpected call without timeout, calls %d deadline %v", caller.calls, caller.deadline)
+	}
+	_ = rpc.ShutDown()
+}
+
+type errCaller struct {
+	TestCallerImpl
+}
+
+type notEnoughGold struct {
+	detail string
+}
+
+func (e *notEnoughGold) Error() string         { return "NotEnoughGold: " + e.detail }
+func (e *notEnoughGold) ExceptionCode() uint32 { return 1001 }
+func (e *notEnoughGold) ExceptionName() string { return "NotEnoughGold" }
+
+func (ec *errCaller) SetInfo(ctx context.Context, info string) error {
+	switch info {
+	case "exception":
+		return &notEnoughGold{detail: "need 100 gold"}
+	case "rpc":
+		return rpcerrors.NewRpcError(2001, "custom code %d", 2001)
+	case "plain":
+		return gerrors.New("plain error")
+	}
+	return nil
+}
+
+// pumpLoopback deliver everything sent on trans back to rpc, rpc is both caller and callee
+func pumpLoopback(rpc idlrpc.IRpc, trans *TransportRing, stop chan struct{}) {
+	for {
+		select {
+		case pkg := <-trans.sendchan:
+			_, _ = trans.Write(pkg, len(pkg))
+			_ = rpc.OnMessage(trans, context.Background())
+		case <-stop:
+			return
+		}
+	}
+}
+
+func TestRemoteError(t *testing.T) {
+	trans := NewTransportRing()
+	rpc := idlrpc.CreateRpcFramework()
+	if err := rpc.Init(idlrpc.WithLogger(&logger.NullLogger{})); err != nil {
+		t.Fatal(err)
+	}
+	_ = rpc.AddStubCreator(SrvUUID, TestCallerStubCreator)
+	_ = rpc.AddProxyCreator(SrvUUID, TestCallerProxyCreator)
+	_ = rpc.Start()
+	if err := rpc.RegisterService(&errCaller{}); err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	go pumpLoopback(rpc, trans, stop)
+	defer func() {
+		close(stop)
+		_ = rpc.ShutDown()
+	}()
+
+	p, err := rpc.GetServiceProxy(SrvUUID, trans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := p.(*TestCallerProxy)
+
+	if err = sp.SetInfo("ok"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	err = sp.SetInfo("exception")
+	var rpcErr *rpcerrors.RpcError
+	if !gerrors.As(err, &rpcErr) {
+		t.Fatalf("expect RpcError, got %T %v", err, err)
+	}
+	if rpcErr.Code() != 1001 || rpcErr.Name() != "NotEnoughGold" || rpcErr.Detail() != "need 100 gold" {
+		t.Fatalf("unexpected remote error %d %q %q", rpcErr.Code(), rpcErr.Name(), rpcErr.Detail())
+	}
+	if !gerrors.Is(err, &notEnoughGold{}) || !gerrors.Is(err, rpcerrors.ErrRpcRet) {
+		t.Fatalf("remote error not match exception %v", err)
+	}
+
+	err = sp.SetInfo("rpc")
+	if !gerrors.As(err, &rpcErr) || rpcErr.Code() != 2001 || rpcErr.Error() != "custom code 2001" {
+		t.Fatalf("unexpected remote error %v", err)
+	}
+	if gerrors.Is(err, &notEnoughGold{}) {
+		t.Fatal("remote error match exception with different code")
+	}
+
+	err = sp.SetInfo("plain")
+	if !gerrors.As(err, &rpcErr) || rpcErr.Code() != rpcerrors.MethodException || rpcErr.Error() != "plain error" {
+		t.Fatalf("unexpected remote error %v", err)
+	}
+}
+
+// callSeen what handler got from call accessors
+type callSeen struct {
+	info     string
+	trace    string
+	meta     map[string]string
+	hasID    bool
+	global   protocol.GlobalIndexType
+	deadline time.Time
+}
+
+// metaCaller records call accessors, forward calls itself through peer proxy with ctx
+type metaCaller struct {
+	TestCallerImpl
+	rpc  idlrpc.IRpc
+	seen chan callSeen
+}
+
+func (mc *metaCaller) SetInfo(ctx context.Context, info string) error {
+	_, hasID := idlrpc.CallID(ctx)
+	deadline, _ := ctx.Deadline()
+	mc.seen <- callSeen{info, idlrpc.TraceID(ctx), idlrpc.Metadata(ctx), hasID, idlrpc.CallerGlobalIndex(ctx), deadline}
+	if info != "forward" {
+		return nil
+	}
+	p, err := mc.rpc.GetProxyFromPeer(ctx, SrvUUID)
+	if err != nil {
+		return err
+	}
+	_, err = idlrpc.CallContext(idlrpc.WithMetadata(ctx, "hop", "2"), mc.rpc, p, 1, 1000, 0, &pbdata.TestCaller_SetInfoArgs{Arg1: "leaf"})
+	return err
+}
+
+func TestCallContext(t *testing.T) {
+	trans := NewTransportRing()
+	rpc := idlrpc.CreateRpcFramework()
+	if err := rpc.Init(idlrpc.WithLogger(&logger.NullLogger{}), idlrpc.WithServiceWorker(4, 64)); err != nil {
+		t.Fatal(err)
+	}
+	_ = rpc.AddStubCreator(SrvUUID, TestCallerStubCreator)
+	_ = rpc.AddProxyCreator(SrvUUID, TestCallerProxyCreator)
+	_ = rpc.Start()
+	caller := &metaCaller{rpc: rpc, seen: make(chan callSeen, 2)}
+	if err := rpc.RegisterService(caller); err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	go pumpLoopback(rpc, trans, stop)
+	defer func() {
+		close(stop)
+		_ = rpc.ShutDown()
+	}()
+	p, err := rpc.GetServiceProxy(SrvUUID, trans)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(idlrpc.WithMetadata(context.Background(), "user", "42", protocol.CtxTraceKey, "forged"), 500*time.Millisecond)
+	defer cancel()
+	args := &pbdata.TestCaller_SetInfoArgs{Arg1: "forward"}
+	if _, err = idlrpc.CallContext(ctx, rpc, p, 1, 1000, 0, args); err != nil {
+		t.Fatal(err)
+	}
+	// context is added while encoding, caller's message is kept as it is
+	if !proto.Equal(args, &pbdata.TestCaller_SetInfoArgs{Arg1: "forward"}) || len(args.ProtoReflect().GetUnknown()) != 0 {
+		t.Fatalf("caller's args modified %v", args)
+	}
+	outer, leaf := <-caller.seen, <-caller.seen
+	if !reflect.DeepEqual(outer.meta, map[string]string{"user": "42"}) || outer.trace == "" || outer.trace == "forged" {
+		t.Fatalf("outer call metadata %v trace %q", outer.meta, outer.trace)
+	}
+	if !outer.hasID || outer.global != idlrpc.InvalidGlobalIndex {
+		t.Fatalf("outer call id %t global index %d", outer.hasID, outer.global)
+	}
+	// deadline of ctx is sent instead of method timeout
+	if outer.deadline.IsZero() || time.Until(outer.deadline) > 500*time.Millisecond {
+		t.Fatalf("outer deadline %v", outer.deadline)
+	}
+	// trace and deadline follow the chain, metadata is set per call
+	if leaf.info != "leaf" || leaf.trace != outer.trace || !reflect.DeepEqual(leaf.meta, map[string]string{"hop": "2"}) {
+		t.Fatalf("leaf call %+v", leaf)
+	}
+	// remaining time is sent in millisecond and counted from arrival, allow a little drift
+	if leaf.deadline.IsZero() || leaf.deadline.After(outer.deadline.Add(10*time.Millisecond)) {
+		t.Fatalf("leaf deadline %v after %v", leaf.deadline, outer.deadline)
+	}
+
+	// generated proxy without ctx still sends method timeout, handler gets a new trace id
+	start := time.Now()
+	if err = p.(*TestCallerProxy).SetInfo("plain"); err != nil {
+		t.Fatal(err)
+	}
+	plain := <-caller.seen
+	if plain.deadline.Before(start) || plain.deadline.After(start.Add(1010*time.Millisecond)) || plain.trace == "" || plain.trace == outer.trace {
+		t.Fatalf("plain call %+v", plain)
+	}
+
+	if idlrpc.Metadata(ctx) != nil || idlrpc.TraceID(ctx) != "" || idlrpc.CallerAddr(ctx) != "" {
+		t.Fatal("accessors return data out of call")
+	}
+	if _, ok := idlrpc.CallID(ctx); ok {
+		t.Fatal("call id out of call")
+	}
+	expired, cancel2 := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel2()
+	if _, err = idlrpc.CallContext(expired, rpc, p, 1, 1000, 0, &pbdata.TestCaller_SetInfoArgs{}); err != context.DeadlineExceeded {
+		t.Fatalf("expired ctx call returns %v", err)
+	}
+}
+
+// deferCaller answers GetInfo from another goroutine after handler returns
+type deferCaller struct {
+	TestCallerImpl
+	infos   chan idlrpc.CallInfo
+	replies chan error
+}
+
+func (dc *deferCaller) GetInfo(ctx context.Context) (string, error) {
+	info, _ := idlrpc.CallInfoFromContext(ctx)
+	dc.infos <- info
+	reply, err := idlrpc.ReplyLater(ctx)
+	if err != nil {
+		return "", err
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		dc.replies <- reply.Reply(&pbdata.TestCaller_GetInfoRet{Ret1: info.Signature})
+		// one response only
+		dc.replies <- reply.ReplyError(gerrors.New("twice"))
+	}()
+	return "discarded", nil
+}
+
+func (dc *deferCaller) SetInfo(ctx context.Context, info string) error {
+	reply, err := idlrpc.ReplyLater(ctx)
+	if err != nil {
+		return err
+	}
+	go func() {
+		dc.replies <- reply.ReplyError(&notEnoughGold{detail: info})
+	}()
+	return nil
+}
+
+// TestCtxWireField Context is written to field ctx numbered after arguments, as every language generator declares
+func TestCtxWireField(t *testing.T) {
+	trans := NewTransportRing()
+	rpc := idlrpc.CreateRpcFramework()
+	if err := rpc.Init(idlrpc.WithLogger(&logger.NullLogger{})); err != nil {
+		t.Fatal(err)
+	}
+	_ = rpc.AddProxyCreator(SrvUUID, TestCallerProxyCreator)
+	_ = rpc.Start()
+	defer rpc.ShutDown()
+	p, err := rpc.GetServiceProxy(SrvUUID, trans)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nobody answers, request is read from transport
+	done := make(chan error, 1)
+	go func() {
+		_, err := idlrpc.CallContext(idlrpc.WithMetadata(context.Background(), "user", "42"), rpc, p, 1, 50, 0, &pbdata.TestCaller_SetInfoArgs{Arg1: "wire"})
+		done <- err
+	}()
+	pkg := <-trans.sendchan
+	args := &pbdata.TestCaller_SetInfoArgs{}
+	if err = proto.Unmarshal(pkg[protocol.CallHeadSize:], args); err != nil {
+		t.Fatal(err)
+	}
+	info := make(map[string]string)
+	for _, kv := range args.GetCtx().GetInfo() {
+		info[kv.Key] = kv.Value
+	}
+	if args.Arg1 != "wire" || info["user"] != "42" || info[protocol.CtxTimeoutKey] != "50" || len(args.ProtoReflect().GetUnknown()) != 0 {
+		t.Fatalf("args on wire %v", args)
+	}
+	if err = <-done; !gerrors.Is(err, rpcerrors.ErrRpcTimeOut) {
+		t.Fatalf("expect timeout, got %v", err)
+	}
+}
+
+func TestReplyLater(t *testing.T) {
+	trans := NewTransportRing()
+	rpc := idlrpc.CreateRpcFramework()
+	if err := rpc.Init(idlrpc.WithLogger(&logger.NullLogger{})); err != nil {
+		t.Fatal(err)
+	}
+	_ = rpc.AddStubCreator(SrvUUID, TestCallerStubCreator)
+	_ = rpc.AddProxyCreator(SrvUUID, TestCallerProxyCreator)
+	_ = rpc.Start()
+	caller := &deferCaller{infos: make(chan idlrpc.CallInfo, 2), replies: make(chan error, 3)}
+	if err := rpc.RegisterService(caller); err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	go pumpLoopback(rpc, trans, stop)
+	defer func() {
+		close(stop)
+		_ = rpc.ShutDown()
+	}()
+	p, err := rpc.GetServiceProxy(SrvUUID, trans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := p.(*TestCallerProxy)
+
+	start := time.Now()
+	ret, err := sp.GetInfo()
+	if err != nil || ret != "GetInfo" {
+		t.Fatalf("deferred reply %q %v", ret, err)
+	}
+	if err = <-caller.replies; err != nil {
+		t.Fatalf("reply error %v", err)
+	}
+	if err = <-caller.replies; err != rpcerrors.ErrCallReplied {
+		t.Fatalf("second reply returns %v", err)
+	}
+	info := <-caller.infos
+	if info.ServiceUUID != SrvUUID || info.MethodID != 2 || info.Signature != "GetInfo" || info.CallID == 0 || info.OneWay {
+		t.Fatalf("call info %+v", info)
+	}
+	if info.GlobalIndex != idlrpc.InvalidGlobalIndex || info.RecvTime.Before(start) || info.TraceID == "" {
+		t.Fatalf("call info %+v", info)
+	}
+
+	err = sp.SetInfo("need 1 gold")
+	if !gerrors.Is(err, &notEnoughGold{}) {
+		t.Fatalf("deferred error %v", err)
+	}
+	if err = <-caller.replies; err != nil {
+		t.Fatalf("reply error error %v", err)
+	}
+
+	if _, ok := idlrpc.CallInfoFromContext(context.Background()); ok {
+		t.Fatal("call info out of call")
+	}
+	if _, err = idlrpc.ReplyLater(context.Background()); err == nil {
+		t.Fatal("reply later out of call")
+	}
+}
+
+// recordLogger record structured log lines with all attached fields
+type recordLogger struct {
+	mu     *sync.Mutex
+	lines  *[]map[string]interface{}
+	fields []log.Field
+}
+
+func newRecordLogger() *recordLogger {
+	return &recordLogger{mu: &sync.Mutex{}, lines: &[]map[string]interface{}{}}
+}
+
+func (rl *recordLogger) record(msg string, fields []log.Field) {
+	line := map[string]interface{}{"msg": msg}
+	for _, f := range append(append([]log.Field{}, rl.fields...), fields...) {
+		line[f.Key] = f.Value
+	}
+	rl.mu.Lock()
+	*rl.lines = append(*rl.lines, line)
+	rl.mu.Unlock()
+}
+
+func (rl *recordLogger) Debug(msg string, fields ...log.Field) { rl.record(msg, fields) }
+func (rl *recordLogger) Info(msg string, fields ...log.Field)  { rl.record(msg, fields) }
+func (rl *recordLogger) Warn(msg string, fields ...log.Field)  { rl.record(msg, fields) }
+func (rl *recordLogger) Error(msg string, fields ...log.Field) { rl.record(msg, fields) }
+func (rl *recordLogger) With(fields ...log.Field) log.IFieldLogger {
+	return &recordLogger{mu: rl.mu, lines: rl.lines, fields: append(append([]log.Field{}, rl.fields...), fields...)}
+}
+
+type logCaller struct {
+	TestCallerImpl
+	fields []log.Field
+}
+
+func (lc *logCaller) SetInfo(ctx context.Context, info string) error {
+	lc.fields = log.FieldsFromContext(ctx)
+	log.FromContext(ctx).Info("handler log")
+	return fmt.Errorf("handler error")
+}
+
+func TestCallLogFields(t *testing.T) {
+	trans := NewTransportRing()
+	caller := &logCaller{}
+	rl := newRecordLogger()
+
+	rpc := idlrpc.CreateRpcFramework()
+	err := rpc.Init(idlrpc.WithFieldLogger(rl), idlrpc.WithServiceWorker(0, 1), idlrpc.WithDispatchMode(SrvUUID, idlrpc.DispatchTick))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rpc.AddStubCreator(SrvUUID, TestCallerStubCreator)
+	_ = rpc.Start()
+	if err = rpc.RegisterService(caller); err != nil {
+		t.Fatal(err)
+	}
+
+	// second call is rejected by full queue before execution
+	reqData := packSetInfo(7, protocol.CtxTraceKey, "trace-1")
+	for i := 0; i < 2; i++ {
+		_, _ = trans.Write(reqData, len(reqData))
+		_ = rpc.OnMessage(trans, context.Background())
+	}
+	_ = rpc.Tick()
+	_ = rpc.ShutDown()
+
+	expect := map[string]interface{}{
+		log.KeyService:     "TestCaller",
+		log.KeyMethod:      "SetInfo",
+		log.KeyCallID:      uint32(7),
+		log.KeyGlobalIndex: protocol.GlobalIndexType(idlrpc.InvalidGlobalIndex),
+		log.KeyTraceID:     "trace-1",
+	}
+	check := func(where string, line map[string]interface{}) {
+		for k, v := range expect {
+			if line[k] != v {
+				t.Fatalf("%s: field %s expect %v, got %v", where, k, v, line[k])
+			}
+		}
+	}
+
+	ctxFields := map[string]interface{}{}
+	for _, f := range caller.fields {
+		ctxFields[f.Key] = f.Value
+	}
+	check("ctx fields", ctxFields)
+
+	found := map[string]bool{}
+	for _, line := range *rl.lines {
+		if _, ok := line[log.KeyCallID]; ok {
+			check(line["msg"].(string), line)
+			found[line["msg"].(string)] = true
+		}
+	}
+	if !found["handler log"] || !found["[Service] method call error"] || !found["[Service] service call queue is full"] {
+		t.Fatalf("call logs missing fields, got %v", *rl.lines)
+	}
+}
+
+// frameBenchTrans hand over frame without copying, response is dropped on send
+type frameBenchTrans struct {
+	*TransportRing
+}
+
+func (ft *frameBenchTrans) Send([]byte) error { return nil }
+func (ft *frameBenchTrans) CopyOnSend() bool  { return true }
+
+// readBenchTrans transport without ReadFrame, frame is read into pooled buffer
+type readBenchTrans struct {
+	transport.ITransport
+}
+
+func (rt *readBenchTrans) Send([]byte) error { return nil }
+func (rt *readBenchTrans) CopyOnSend() bool  { return true }
+
+func benchmarkOnMessage(b *testing.B, trans transport.ITransport) {
+	rpc := idlrpc.CreateRpcFramework()
+	_ = rpc.Init(idlrpc.WithLogger(&logger.NullLogger{}), idlrpc.WithDispatchMode(SrvUUID, idlrpc.DispatchTick))
+	_ = rpc.AddStubCreator(SrvUUID, TestCallerStubCreator)
+	_ = rpc.Start()
+	_ = rpc.RegisterService(&countCaller{})
+	defer func() {
+		_ = rpc.ShutDown()
+	}()
+	reqData := packSetInfo(1)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = trans.Write(reqData, len(reqData))
+		_ = rpc.OnMessage(trans, context.Background())
+		_ = rpc.Tick()
+	}
+}
+
+func BenchmarkOnMessageFrame(b *testing.B) {
+	benchmarkOnMessage(b, &frameBenchTrans{NewTransportRing()})
+}
+
+func BenchmarkOnMessageRead(b *testing.B) {
+	benchmarkOnMessage(b, &readBenchTrans{NewTransportRing()})
+}
+
+// leProtocol little endian variant of default protocol, only call and return are supported
+type leProtocol struct {
+	protocol.Protocol
+}
+
+var (
+	msgFields  = []int{4, 4}
+	callFields = []int{4, 4, 8, 4, 4, 4}
+	retFields  = []int{4, 4, 4, 4, 4}
+)
+
+// swapFields reverse bytes of each header field in place
+func swapFields(pkg []byte, fields []int) []byte {
+	off := 0
+	for _, n := range fields {
+		f := pkg[off : off+n]
+		for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+			f[i], f[j] = f[j], f[i]
+		}
+		off += n
+	}
+	return pkg
+}
+
+func (lp leProtocol) ReadHeader(pkg []byte, header *protocol.RpcMsgHeader) bool {
+	return len(pkg) >= protocol.RpcHeadSize &&
+		lp.Protocol.ReadHeader(swapFields(append([]byte(nil), pkg[:protocol.RpcHeadSize]...), msgFields), header)
+}
+
+func (lp leProtocol) ParseReqMsg(pkg []byte, header *protocol.RpcCallHeader) bool {
+	return len(pkg) >= protocol.CallHeadSize &&
+		lp.Protocol.ParseReqMsg(swapFields(append([]byte(nil), pkg[:protocol.CallHeadSize]...), callFields), header)
+}
+
+func (lp leProtocol) ParseRespMsg(pkg []byte, header *protocol.RpcCallRetHeader) bool {
+	return len(pkg) >= protocol.RespHeadSize &&
+		lp.Protocol.ParseRespMsg(swapFields(append([]byte(nil), pkg[:protocol.RespHeadSize]...), retFields), header)
+}
+
+func (lp leProtocol) PackReqMsg(req *protocol.RequestPackage) ([]byte, int) {
+	pkg, length := lp.Protocol.PackReqMsg(req)
+	swapFields(pkg[:protocol.CallHeadSize], callFields)
+	return pkg, length
+}
+
+func (lp leProtocol) PackRespMsg(resp *protocol.ResponsePackage) ([]byte, int) {
+	pkg, length := lp.Protocol.PackRespMsg(resp)
+	swapFields(pkg[:protocol.RespHeadSize], retFields)
+	return pkg, length
+}
+
+// protoTrans transport speaks its own protocol
+type protoTrans struct {
+	*TransportRing
+	wire protocol.Protocol
+}
+
+func (pt *protoTrans) Protocol() protocol.Protocol {
+	return pt.wire
+}
+
+func TestTransportProtocol(t *testing.T) {
+	le := leProtocol{protocol.Default()}
+	newRpc := func(opts ...idlrpc.Option) idlrpc.IRpc {
+		rpc := idlrpc.CreateRpcFramework()
+		opts = append(opts, idlrpc.WithLogger(&logger.NullLogger{}), idlrpc.WithDispatchMode(SrvUUID, idlrpc.DispatchTick))
+		if err := rpc.Init(opts...); err != nil {
+			t.Fatal(err)
+		}
+		_ = rpc.AddStubCreator(SrvUUID, TestCallerStubCreator)
+		_ = rpc.Start()
+		if err := rpc.RegisterService(&countCaller{}); err != nil {
+			t.Fatal(err)
+		}
+		return rpc
+	}
+	call := func(rpc idlrpc.IRpc, trans transport.ITransport, ring *TransportRing, wire protocol.Protocol, callID uint32) {
+		reqData := packSetInfo(callID)
+		if wire == le {
+			swapFields(reqData[:protocol.CallHeadSize], callFields)
+		}
+		_, _ = ring.Write(reqData, len(reqData))
+		if err := rpc.OnMessage(trans, context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		_ = rpc.Tick()
+		var resp []byte
+		select {
+		case resp = <-ring.sendchan:
+		case <-time.After(time.Second):
+			t.Fatalf("call %d no response", callID)
+		}
+		var header protocol.RpcCallRetHeader
+		if !wire.ParseRespMsg(resp, &header) || int(header.Length) != len(resp) || header.CallID != callID || header.ErrorCode != protocol.IDL_SUCCESS {
+			t.Fatalf("call %d unexpected response %+v", callID, header)
+		}
+	}
+
+	// gateway, legacy clients speak little endian, backends speak default protocol
+	gateway := newRpc()
+	defer gateway.ShutDown()
+	legacy := &protoTrans{TransportRing: NewTransportRing(), wire: le}
+	backend := NewTransportRing()
+	call(gateway, legacy, legacy.TransportRing, le, 1)
+	call(gateway, backend, backend, protocol.Default(), 2)
+	if idlrpc.Protocol(gateway, legacy) != le || idlrpc.Protocol(gateway, backend) != protocol.Default() {
+		t.Fatal("unexpected protocol of transport")
+	}
+
+	// whole instance speaks little endian
+	client := newRpc(idlrpc.WithProtocol(le))
+	defer client.ShutDown()
+	trans := NewTransportRing()
+	call(client, trans, trans, le, 3)
+}
+
+// loggedMsg count lines of rl containing msg
+func loggedMsg(rl *recordLogger, msg string) (n int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for _, line := range *rl.lines {
+		if strings.Contains(line["msg"].(string), msg) {
+			n++
+		}
+	}
+	return
+}
+
+// TestSideBySide gateway half and backend half in one process, settings of one do not leak to the other
+func TestSideBySide(t *testing.T) {
+	gwLog, backLog := newRecordLogger(), newRecordLogger()
+	gateway := idlrpc.CreateRpcFramework()
+	_ = gateway.Init(idlrpc.WithFieldLogger(gwLog), idlrpc.WithProtocol(leProtocol{protocol.Default()}), idlrpc.WithCallTimeout(30, 1))
+	backend := idlrpc.CreateRpcFramework()
+	_ = backend.Init(idlrpc.WithFieldLogger(backLog), idlrpc.WithStackTrace(true))
+
+	stop := make(chan struct{})
+	proxies := make([]*TestCallerProxy, 0, 2)
+	for _, rpc := range []idlrpc.IRpc{gateway, backend} {
+		_ = rpc.AddStubCreator(SrvUUID, TestCallerStubCreator)
+		_ = rpc.AddProxyCreator(SrvUUID, TestCallerProxyCreator)
+		_ = rpc.Start()
+		if err := rpc.RegisterService(&errCaller{}); err != nil {
+			t.Fatal(err)
+		}
+		trans := NewTransportRing()
+		go pumpLoopback(rpc, trans, stop)
+		p, err := rpc.GetServiceProxy(SrvUUID, trans)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proxies = append(proxies, p.(*TestCallerProxy))
+	}
+	defer func() {
+		close(stop)
+		_ = gateway.ShutDown()
+		_ = backend.ShutDown()
+	}()
+
+	// both speak their own protocol at the same time
+	var wg sync.WaitGroup
+	errs := make([]error, len(proxies))
+	for i, sp := range proxies {
+		wg.Add(1)
+		go func(i int, sp *TestCallerProxy) {
+			defer wg.Done()
+			errs[i] = sp.SetInfo("ok")
+		}(i, sp)
+	}
+	wg.Wait()
+	if errs[0] != nil || errs[1] != nil {
+		t.Fatalf("side by side call error %v", errs)
+	}
+
+	// only backend traces panic stack
+	for _, sp := range proxies {
+		if _, err := sp.GetInfo(); err == nil {
+			t.Fatal("panic call return no error")
+		}
+	}
+	if loggedMsg(gwLog, "trace back") != 0 || loggedMsg(backLog, "trace back") != 1 {
+		t.Fatal("stack trace setting leaks between instances")
+	}
+
+	// call without idl timeout uses gateway's default, retry is capped and logged by its own logger
+	lost := NewTransportRing()
+	lost.SetID(1)
+	silent, _ := gateway.GetServiceProxy(SrvUUID, lost)
+	start := time.Now()
+	if _, err := gateway.Call(silent, 1, 0, 5, &pbdata.TestCaller_SetInfoArgs{}); !gerrors.Is(err, rpcerrors.ErrRpcTimeOut) {
+		t.Fatalf("expect timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("gateway call timeout %v, expect 60ms", elapsed)
+	}
+	if loggedMsg(gwLog, "time out, retry") != 1 || loggedMsg(backLog, "time out, retry") != 0 {
+		t.Fatal("proxy call log leaks between instances")
+	}
+	if loggedMsg(gwLog, "start working") != 1 || loggedMsg(backLog, "start working") != 1 {
+		t.Fatal("framework log leaks between instances")
+	}
+}
+
+const tomlConfig = `# framework defaults
+call_timeout = 3_000
+max_retry = 2
+queue_size = 64
+
+[services.TestCaller]
+workers = 3
+queue_size = 16
+
+[services.TestCaller.methods."SetInfo"]
+timeout = %d # override idl timeout
+retry = 0
+`
+
+const jsonConfig = `{
+	"call_timeout": 3000, "max_retry": 2, "queue_size": 64,
+	"services": {"TestCaller": {"workers": 3, "queue_size": 16, "methods": {"SetInfo": {"timeout": 30, "retry": 0}}}}
+}`
+
+func TestConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idlrpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tomlPath, jsonPath := filepath.Join(dir, "rpc.toml"), filepath.Join(dir, "rpc.json")
+	_ = ioutil.WriteFile(tomlPath, []byte(fmt.Sprintf(tomlConfig, 30)), 0644)
+	_ = ioutil.WriteFile(jsonPath, []byte(jsonConfig), 0644)
+
+	tomlConf, err := idlrpc.LoadConfig(tomlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonConf, err := idlrpc.LoadConfig(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tomlConf, jsonConf) {
+		t.Fatalf("toml config %+v differs from json config %+v", tomlConf, jsonConf)
+	}
+
+	rpc := idlrpc.CreateRpcFramework()
+	if err = rpc.Init(idlrpc.WithLogger(&logger.NullLogger{}), idlrpc.WithConfigFile(tomlPath)); err != nil {
+		t.Fatal(err)
+	}
+	_ = rpc.AddStubCreator(SrvUUID, TestCallerStubCreator)
+	_ = rpc.AddProxyCreator(SrvUUID, TestCallerProxyCreator)
+	_ = rpc.Start()
+	defer rpc.ShutDown()
+	if err = rpc.RegisterService(&errCaller{}); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := idlrpc.GetServiceStats(rpc, SrvUUID); stats.Workers != 3 || stats.QueueSize != 16 {
+		t.Fatalf("service setting not applied %+v", stats)
+	}
+
+	// nobody answers, method timeout of config overrides idl
+	p, _ := rpc.GetServiceProxy(SrvUUID, NewTransportRing())
+	sp := p.(*TestCallerProxy)
+	start := time.Now()
+	if err = sp.SetInfo("lost"); !gerrors.Is(err, rpcerrors.ErrRpcTimeOut) || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("expect timeout after 30ms, got %v after %v", err, time.Since(start))
+	}
+
+	// reload at runtime
+	_ = ioutil.WriteFile(tomlPath, []byte(fmt.Sprintf(tomlConfig, 300)), 0644)
+	if err = idlrpc.ReloadConfig(rpc); err != nil {
+		t.Fatal(err)
+	}
+	start = time.Now()
+	if err = sp.SetInfo("lost"); !gerrors.Is(err, rpcerrors.ErrRpcTimeOut) || time.Since(start) < 300*time.Millisecond {
+		t.Fatalf("expect timeout after 300ms, got %v after %v", err, time.Since(start))
+	}
+
+	// broken file keeps previous config
+	_ = ioutil.WriteFile(tomlPath, []byte("[services\n"), 0644)
+	if err = idlrpc.ReloadConfig(rpc); err == nil {
+		t.Fatal("broken config loaded")
+	}
+	if rpc.Options().Config().Services[SrvName].Methods["SetInfo"].Timeout != 300 {
+		t.Fatal("config lost after failed reload")
+	}
+}
+
+func TestAppLifecycle(t *testing.T) {
+	var steps []string
+	hook := func(step string, ready bool) idlrpc.AppHook {
+		return func(app *idlrpc.App) error {
+			if app.Ready() != ready {
+				t.Errorf("%s ready %v", step, app.Ready())
+			}
+			steps = append(steps, step)
+			return nil
+		}
+	}
+	dir, _ := ioutil.TempDir("", "idlrpc")
+	defer os.RemoveAll(dir)
+	confPath := filepath.Join(dir, "rpc.toml")
+	_ = ioutil.WriteFile(confPath, []byte("call_timeout = 100\n"), 0644)
+
+	app := idlrpc.NewApp(
+		idlrpc.WithRpcOptions(idlrpc.WithLogger(&logger.NullLogger{}), idlrpc.WithConfigFile(confPath)),
+		idlrpc.WithSDK(&TestCallerSDK{}),
+		idlrpc.WithService(NewTestCaller()),
+		idlrpc.WithTickRate(time.Millisecond),
+		idlrpc.OnStart(hook("start1", false)),
+		idlrpc.OnStart(hook("start2", false)),
+		idlrpc.OnStop(hook("stop1", false)),
+		idlrpc.OnStop(hook("stop2", false)),
+	)
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run()
+	}()
+	for !app.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := idlrpc.GetServiceStats(app.Rpc(), SrvUUID); err != nil {
+		t.Fatalf("service not registered %v", err)
+	}
+
+	// reload on SIGHUP, stop on SIGTERM
+	self, _ := os.FindProcess(os.Getpid())
+	_ = ioutil.WriteFile(confPath, []byte("call_timeout = 200\n"), 0644)
+	if err := self.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("signal not supported %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for app.Rpc().Options().Config().CallTimeout != 200 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if app.Rpc().Options().Config().CallTimeout != 200 {
+		t.Fatal("config not reloaded on SIGHUP")
+	}
+	_ = self.Signal(syscall.SIGTERM)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if app.State() != idlrpc.AppStopped || strings.Join(steps, ",") != "start1,start2,stop2,stop1" {
+		t.Fatalf("unexpected state %d steps %v", app.State(), steps)
+	}
+	if err := app.Run(); err == nil {
+		t.Fatal("stopped app run again")
+	}
+
+	// failed start hook stops app
+	failed := idlrpc.NewApp(
+		idlrpc.WithRpcOptions(idlrpc.WithLogger(&logger.NullLogger{})),
+		idlrpc.WithSignal(false),
+		idlrpc.OnStart(func(*idlrpc.App) error { return gerrors.New("not ready") }),
+		idlrpc.OnStop(hook("failed stop", false)),
+	)
+	if err := failed.Run(); err == nil || err.Error() != "not ready" || failed.State() != idlrpc.AppStopped {
+		t.Fatalf("unexpected start result %v state %d", err, failed.State())
+	}
+}
+
+func TestPackageRegistry(t *testing.T) {
+	if info, ok := idlrpc.GetPackage(SrvName); !ok || info.ServiceUUID != SrvUUID {
+		t.Fatalf("package %s not registered", SrvName)
+	}
+	newApp := func(opts ...idlrpc.AppOption) *testApp {
+		opts = append([]idlrpc.AppOption{
+			idlrpc.WithService(NewTestCaller()),
+			idlrpc.WithSignal(false),
+		}, opts...)
+		return &testApp{App: idlrpc.NewApp(opts...), done: make(chan error, 1)}
+	}
+	run := func(app *testApp) {
+		go func() {
+			app.done <- app.Run()
+		}()
+		for !app.Ready() && app.State() != idlrpc.AppStopped {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// proxy only package from config, service is not hosted
+	dir, _ := ioutil.TempDir("", "idlrpc")
+	defer os.RemoveAll(dir)
+	confPath := filepath.Join(dir, "rpc.toml")
+	_ = ioutil.WriteFile(confPath, []byte("proxy = [\"TestCaller\"]\n"), 0644)
+	app := newApp(idlrpc.WithRpcOptions(idlrpc.WithLogger(&logger.NullLogger{}), idlrpc.WithConfigFile(confPath)))
+	run(app)
+	if _, err := idlrpc.GetServiceStats(app.Rpc(), SrvUUID); err == nil {
+		t.Fatal("proxy package hosts service")
+	}
+	_ = app.stop()
+
+	// hosted package
+	app = newApp(idlrpc.WithRpcOptions(idlrpc.WithLogger(&logger.NullLogger{})), idlrpc.WithPackages([]string{SrvName}, nil))
+	run(app)
+	if _, err := idlrpc.GetServiceStats(app.Rpc(), SrvUUID); err != nil {
+		t.Fatalf("hosted service not registered %v", err)
+	}
+	_ = app.stop()
+
+	// unknown package stops app from starting
+	app = newApp(idlrpc.WithRpcOptions(idlrpc.WithLogger(&logger.NullLogger{})), idlrpc.WithPackages(nil, []string{"Unknown"}))
+	if err := app.Run(); err == nil {
+		t.Fatal("unknown package loaded")
+	}
+}
+
+func TestGeneratedMock(t *testing.T) {
+	mock := NewMockTestCaller().ExpectSetInfo(1).ExpectGetInfo(0)
+	var got string
+	mock.SetInfoFunc = func(_ context.Context, info string) error {
+		got = info
+		return nil
+	}
+	var service ITestCaller = mock
+	if err := service.SetInfo(context.Background(), "hello"); err != nil || got != "hello" {
+		t.Fatalf("stub hook not called, err %v info %q", err, got)
+	}
+	if err := mock.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = service.GetInfo(context.Background())
+	if err := mock.Verify(); err == nil || !strings.Contains(err.Error(), "GetInfo called 1 times, expect 0") {
+		t.Fatalf("unexpected verify result %v", err)
+	}
+
+	fake := NewFakeTestCallerProxy()
+	fake.GetInfoRet = "canned"
+	var caller TestCallerCaller = fake
+	if err := caller.SetInfo("a"); err != nil {
+		t.Fatal(err)
+	}
+	if ret, err := caller.GetInfo(); ret != "canned" || err != nil {
+		t.Fatalf("unexpected result %q %v", ret, err)
+	}
+	calls := fake.CallsOf("SetInfo")
+	if len(fake.Calls()) != 2 || len(calls) != 1 || !reflect.DeepEqual(calls[0].Args, []interface{}{"a"}) {
+		t.Fatalf("unexpected calls %+v", fake.Calls())
+	}
 }
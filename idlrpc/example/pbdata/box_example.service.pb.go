@@ -2,13 +2,14 @@
 
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.27.1
-// 	protoc        v3.10.1
+// 	protoc-gen-go v1.23.0
+// 	protoc        (unknown)
 // source: box_example.service.proto
 
 package pbdata
 
 import (
+	proto "github.com/golang/protobuf/proto"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -22,6 +23,246 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type ServiceBoxTraceInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TracerId     string `protobuf:"bytes,1,opt,name=tracer_id,json=tracerId,proto3" json:"tracer_id,omitempty"`
+	SpanId       uint64 `protobuf:"varint,2,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`
+	ParentSpanId uint64 `protobuf:"varint,3,opt,name=parent_span_id,json=parentSpanId,proto3" json:"parent_span_id,omitempty"`
+	UserData     string `protobuf:"bytes,4,opt,name=user_data,json=userData,proto3" json:"user_data,omitempty"`
+}
+
+func (x *ServiceBoxTraceInfo) Reset() {
+	*x = ServiceBoxTraceInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_box_example_service_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ServiceBoxTraceInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceBoxTraceInfo) ProtoMessage() {}
+
+func (x *ServiceBoxTraceInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_box_example_service_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceBoxTraceInfo.ProtoReflect.Descriptor instead.
+func (*ServiceBoxTraceInfo) Descriptor() ([]byte, []int) {
+	return file_box_example_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ServiceBoxTraceInfo) GetTracerId() string {
+	if x != nil {
+		return x.TracerId
+	}
+	return ""
+}
+
+func (x *ServiceBoxTraceInfo) GetSpanId() uint64 {
+	if x != nil {
+		return x.SpanId
+	}
+	return 0
+}
+
+func (x *ServiceBoxTraceInfo) GetParentSpanId() uint64 {
+	if x != nil {
+		return x.ParentSpanId
+	}
+	return 0
+}
+
+func (x *ServiceBoxTraceInfo) GetUserData() string {
+	if x != nil {
+		return x.UserData
+	}
+	return ""
+}
+
+type ExceptionInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name   string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Detail string `protobuf:"bytes,2,opt,name=detail,proto3" json:"detail,omitempty"`
+	Code   uint32 `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"`
+}
+
+func (x *ExceptionInfo) Reset() {
+	*x = ExceptionInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_box_example_service_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExceptionInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExceptionInfo) ProtoMessage() {}
+
+func (x *ExceptionInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_box_example_service_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExceptionInfo.ProtoReflect.Descriptor instead.
+func (*ExceptionInfo) Descriptor() ([]byte, []int) {
+	return file_box_example_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ExceptionInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ExceptionInfo) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+func (x *ExceptionInfo) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+type KeyValue struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *KeyValue) Reset() {
+	*x = KeyValue{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_box_example_service_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeyValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValue) ProtoMessage() {}
+
+func (x *KeyValue) ProtoReflect() protoreflect.Message {
+	mi := &file_box_example_service_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValue.ProtoReflect.Descriptor instead.
+func (*KeyValue) Descriptor() ([]byte, []int) {
+	return file_box_example_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KeyValue) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KeyValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type Context struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Info []*KeyValue `protobuf:"bytes,1,rep,name=info,proto3" json:"info,omitempty"`
+}
+
+func (x *Context) Reset() {
+	*x = Context{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_box_example_service_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Context) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Context) ProtoMessage() {}
+
+func (x *Context) ProtoReflect() protoreflect.Message {
+	mi := &file_box_example_service_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Context.ProtoReflect.Descriptor instead.
+func (*Context) Descriptor() ([]byte, []int) {
+	return file_box_example_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Context) GetInfo() []*KeyValue {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
 type Empty struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -31,7 +272,7 @@ type Empty struct {
 func (x *Empty) Reset() {
 	*x = Empty{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_box_example_service_proto_msgTypes[0]
+		mi := &file_box_example_service_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -44,7 +285,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_box_example_service_proto_msgTypes[0]
+	mi := &file_box_example_service_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -57,7 +298,7 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_box_example_service_proto_rawDescGZIP(), []int{0}
+	return file_box_example_service_proto_rawDescGZIP(), []int{4}
 }
 
 type TestCaller_SetInfoArgs struct {
@@ -65,13 +306,15 @@ type TestCaller_SetInfoArgs struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Arg1 string `protobuf:"bytes,1,opt,name=arg1,proto3" json:"arg1,omitempty"`
+	Arg1      string               `protobuf:"bytes,1,opt,name=arg1,proto3" json:"arg1,omitempty"`
+	TraceInfo *ServiceBoxTraceInfo `protobuf:"bytes,2,opt,name=trace_info,json=traceInfo,proto3" json:"trace_info,omitempty"`
+	Ctx       *Context             `protobuf:"bytes,3,opt,name=ctx,proto3" json:"ctx,omitempty"`
 }
 
 func (x *TestCaller_SetInfoArgs) Reset() {
 	*x = TestCaller_SetInfoArgs{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_box_example_service_proto_msgTypes[1]
+		mi := &file_box_example_service_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -84,7 +327,7 @@ func (x *TestCaller_SetInfoArgs) String() string {
 func (*TestCaller_SetInfoArgs) ProtoMessage() {}
 
 func (x *TestCaller_SetInfoArgs) ProtoReflect() protoreflect.Message {
-	mi := &file_box_example_service_proto_msgTypes[1]
+	mi := &file_box_example_service_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -97,7 +340,7 @@ func (x *TestCaller_SetInfoArgs) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestCaller_SetInfoArgs.ProtoReflect.Descriptor instead.
 func (*TestCaller_SetInfoArgs) Descriptor() ([]byte, []int) {
-	return file_box_example_service_proto_rawDescGZIP(), []int{1}
+	return file_box_example_service_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *TestCaller_SetInfoArgs) GetArg1() string {
@@ -107,16 +350,33 @@ func (x *TestCaller_SetInfoArgs) GetArg1() string {
 	return ""
 }
 
+func (x *TestCaller_SetInfoArgs) GetTraceInfo() *ServiceBoxTraceInfo {
+	if x != nil {
+		return x.TraceInfo
+	}
+	return nil
+}
+
+func (x *TestCaller_SetInfoArgs) GetCtx() *Context {
+	if x != nil {
+		return x.Ctx
+	}
+	return nil
+}
+
 type TestCaller_GetInfoArgs struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	TraceInfo *ServiceBoxTraceInfo `protobuf:"bytes,1,opt,name=trace_info,json=traceInfo,proto3" json:"trace_info,omitempty"`
+	Ctx       *Context             `protobuf:"bytes,2,opt,name=ctx,proto3" json:"ctx,omitempty"`
 }
 
 func (x *TestCaller_GetInfoArgs) Reset() {
 	*x = TestCaller_GetInfoArgs{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_box_example_service_proto_msgTypes[2]
+		mi := &file_box_example_service_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -129,7 +389,7 @@ func (x *TestCaller_GetInfoArgs) String() string {
 func (*TestCaller_GetInfoArgs) ProtoMessage() {}
 
 func (x *TestCaller_GetInfoArgs) ProtoReflect() protoreflect.Message {
-	mi := &file_box_example_service_proto_msgTypes[2]
+	mi := &file_box_example_service_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -142,7 +402,21 @@ func (x *TestCaller_GetInfoArgs) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestCaller_GetInfoArgs.ProtoReflect.Descriptor instead.
 func (*TestCaller_GetInfoArgs) Descriptor() ([]byte, []int) {
-	return file_box_example_service_proto_rawDescGZIP(), []int{2}
+	return file_box_example_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TestCaller_GetInfoArgs) GetTraceInfo() *ServiceBoxTraceInfo {
+	if x != nil {
+		return x.TraceInfo
+	}
+	return nil
+}
+
+func (x *TestCaller_GetInfoArgs) GetCtx() *Context {
+	if x != nil {
+		return x.Ctx
+	}
+	return nil
 }
 
 type TestCallee_AddArgs struct {
@@ -150,14 +424,16 @@ type TestCallee_AddArgs struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Arg1 int32 `protobuf:"varint,1,opt,name=arg1,proto3" json:"arg1,omitempty"`
-	Arg2 int32 `protobuf:"varint,2,opt,name=arg2,proto3" json:"arg2,omitempty"`
+	Arg1      int32                `protobuf:"varint,1,opt,name=arg1,proto3" json:"arg1,omitempty"`
+	Arg2      int32                `protobuf:"varint,2,opt,name=arg2,proto3" json:"arg2,omitempty"`
+	TraceInfo *ServiceBoxTraceInfo `protobuf:"bytes,3,opt,name=trace_info,json=traceInfo,proto3" json:"trace_info,omitempty"`
+	Ctx       *Context             `protobuf:"bytes,4,opt,name=ctx,proto3" json:"ctx,omitempty"`
 }
 
 func (x *TestCallee_AddArgs) Reset() {
 	*x = TestCallee_AddArgs{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_box_example_service_proto_msgTypes[3]
+		mi := &file_box_example_service_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -170,7 +446,7 @@ func (x *TestCallee_AddArgs) String() string {
 func (*TestCallee_AddArgs) ProtoMessage() {}
 
 func (x *TestCallee_AddArgs) ProtoReflect() protoreflect.Message {
-	mi := &file_box_example_service_proto_msgTypes[3]
+	mi := &file_box_example_service_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -183,7 +459,7 @@ func (x *TestCallee_AddArgs) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestCallee_AddArgs.ProtoReflect.Descriptor instead.
 func (*TestCallee_AddArgs) Descriptor() ([]byte, []int) {
-	return file_box_example_service_proto_rawDescGZIP(), []int{3}
+	return file_box_example_service_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *TestCallee_AddArgs) GetArg1() int32 {
@@ -200,19 +476,35 @@ func (x *TestCallee_AddArgs) GetArg2() int32 {
 	return 0
 }
 
+func (x *TestCallee_AddArgs) GetTraceInfo() *ServiceBoxTraceInfo {
+	if x != nil {
+		return x.TraceInfo
+	}
+	return nil
+}
+
+func (x *TestCallee_AddArgs) GetCtx() *Context {
+	if x != nil {
+		return x.Ctx
+	}
+	return nil
+}
+
 type TestCallee_SubArgs struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Arg1 int32 `protobuf:"varint,1,opt,name=arg1,proto3" json:"arg1,omitempty"`
-	Arg2 int32 `protobuf:"varint,2,opt,name=arg2,proto3" json:"arg2,omitempty"`
+	Arg1      int32                `protobuf:"varint,1,opt,name=arg1,proto3" json:"arg1,omitempty"`
+	Arg2      int32                `protobuf:"varint,2,opt,name=arg2,proto3" json:"arg2,omitempty"`
+	TraceInfo *ServiceBoxTraceInfo `protobuf:"bytes,3,opt,name=trace_info,json=traceInfo,proto3" json:"trace_info,omitempty"`
+	Ctx       *Context             `protobuf:"bytes,4,opt,name=ctx,proto3" json:"ctx,omitempty"`
 }
 
 func (x *TestCallee_SubArgs) Reset() {
 	*x = TestCallee_SubArgs{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_box_example_service_proto_msgTypes[4]
+		mi := &file_box_example_service_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -225,7 +517,7 @@ func (x *TestCallee_SubArgs) String() string {
 func (*TestCallee_SubArgs) ProtoMessage() {}
 
 func (x *TestCallee_SubArgs) ProtoReflect() protoreflect.Message {
-	mi := &file_box_example_service_proto_msgTypes[4]
+	mi := &file_box_example_service_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -238,7 +530,7 @@ func (x *TestCallee_SubArgs) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestCallee_SubArgs.ProtoReflect.Descriptor instead.
 func (*TestCallee_SubArgs) Descriptor() ([]byte, []int) {
-	return file_box_example_service_proto_rawDescGZIP(), []int{4}
+	return file_box_example_service_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *TestCallee_SubArgs) GetArg1() int32 {
@@ -255,16 +547,34 @@ func (x *TestCallee_SubArgs) GetArg2() int32 {
 	return 0
 }
 
+func (x *TestCallee_SubArgs) GetTraceInfo() *ServiceBoxTraceInfo {
+	if x != nil {
+		return x.TraceInfo
+	}
+	return nil
+}
+
+func (x *TestCallee_SubArgs) GetCtx() *Context {
+	if x != nil {
+		return x.Ctx
+	}
+	return nil
+}
+
 type TestCaller_SetInfoRet struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	TraceInfo *ServiceBoxTraceInfo `protobuf:"bytes,2,opt,name=trace_info,json=traceInfo,proto3" json:"trace_info,omitempty"`
+	ExecInfo  *ExceptionInfo       `protobuf:"bytes,3,opt,name=exec_info,json=execInfo,proto3" json:"exec_info,omitempty"`
+	Ctx       *Context             `protobuf:"bytes,4,opt,name=ctx,proto3" json:"ctx,omitempty"`
 }
 
 func (x *TestCaller_SetInfoRet) Reset() {
 	*x = TestCaller_SetInfoRet{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_box_example_service_proto_msgTypes[5]
+		mi := &file_box_example_service_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -277,7 +587,7 @@ func (x *TestCaller_SetInfoRet) String() string {
 func (*TestCaller_SetInfoRet) ProtoMessage() {}
 
 func (x *TestCaller_SetInfoRet) ProtoReflect() protoreflect.Message {
-	mi := &file_box_example_service_proto_msgTypes[5]
+	mi := &file_box_example_service_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -290,7 +600,28 @@ func (x *TestCaller_SetInfoRet) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestCaller_SetInfoRet.ProtoReflect.Descriptor instead.
 func (*TestCaller_SetInfoRet) Descriptor() ([]byte, []int) {
-	return file_box_example_service_proto_rawDescGZIP(), []int{5}
+	return file_box_example_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TestCaller_SetInfoRet) GetTraceInfo() *ServiceBoxTraceInfo {
+	if x != nil {
+		return x.TraceInfo
+	}
+	return nil
+}
+
+func (x *TestCaller_SetInfoRet) GetExecInfo() *ExceptionInfo {
+	if x != nil {
+		return x.ExecInfo
+	}
+	return nil
+}
+
+func (x *TestCaller_SetInfoRet) GetCtx() *Context {
+	if x != nil {
+		return x.Ctx
+	}
+	return nil
 }
 
 type TestCaller_GetInfoRet struct {
@@ -298,13 +629,16 @@ type TestCaller_GetInfoRet struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Ret1 string `protobuf:"bytes,1,opt,name=ret1,proto3" json:"ret1,omitempty"`
+	Ret1      string               `protobuf:"bytes,1,opt,name=ret1,proto3" json:"ret1,omitempty"`
+	TraceInfo *ServiceBoxTraceInfo `protobuf:"bytes,2,opt,name=trace_info,json=traceInfo,proto3" json:"trace_info,omitempty"`
+	ExecInfo  *ExceptionInfo       `protobuf:"bytes,3,opt,name=exec_info,json=execInfo,proto3" json:"exec_info,omitempty"`
+	Ctx       *Context             `protobuf:"bytes,4,opt,name=ctx,proto3" json:"ctx,omitempty"`
 }
 
 func (x *TestCaller_GetInfoRet) Reset() {
 	*x = TestCaller_GetInfoRet{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_box_example_service_proto_msgTypes[6]
+		mi := &file_box_example_service_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -317,7 +651,7 @@ func (x *TestCaller_GetInfoRet) String() string {
 func (*TestCaller_GetInfoRet) ProtoMessage() {}
 
 func (x *TestCaller_GetInfoRet) ProtoReflect() protoreflect.Message {
-	mi := &file_box_example_service_proto_msgTypes[6]
+	mi := &file_box_example_service_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -330,7 +664,7 @@ func (x *TestCaller_GetInfoRet) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestCaller_GetInfoRet.ProtoReflect.Descriptor instead.
 func (*TestCaller_GetInfoRet) Descriptor() ([]byte, []int) {
-	return file_box_example_service_proto_rawDescGZIP(), []int{6}
+	return file_box_example_service_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *TestCaller_GetInfoRet) GetRet1() string {
@@ -340,18 +674,42 @@ func (x *TestCaller_GetInfoRet) GetRet1() string {
 	return ""
 }
 
+func (x *TestCaller_GetInfoRet) GetTraceInfo() *ServiceBoxTraceInfo {
+	if x != nil {
+		return x.TraceInfo
+	}
+	return nil
+}
+
+func (x *TestCaller_GetInfoRet) GetExecInfo() *ExceptionInfo {
+	if x != nil {
+		return x.ExecInfo
+	}
+	return nil
+}
+
+func (x *TestCaller_GetInfoRet) GetCtx() *Context {
+	if x != nil {
+		return x.Ctx
+	}
+	return nil
+}
+
 type TestCallee_AddRet struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Ret1 int32 `protobuf:"varint,1,opt,name=ret1,proto3" json:"ret1,omitempty"`
+	Ret1      int32                `protobuf:"varint,1,opt,name=ret1,proto3" json:"ret1,omitempty"`
+	TraceInfo *ServiceBoxTraceInfo `protobuf:"bytes,2,opt,name=trace_info,json=traceInfo,proto3" json:"trace_info,omitempty"`
+	ExecInfo  *ExceptionInfo       `protobuf:"bytes,3,opt,name=exec_info,json=execInfo,proto3" json:"exec_info,omitempty"`
+	Ctx       *Context             `protobuf:"bytes,4,opt,name=ctx,proto3" json:"ctx,omitempty"`
 }
 
 func (x *TestCallee_AddRet) Reset() {
 	*x = TestCallee_AddRet{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_box_example_service_proto_msgTypes[7]
+		mi := &file_box_example_service_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -364,7 +722,7 @@ func (x *TestCallee_AddRet) String() string {
 func (*TestCallee_AddRet) ProtoMessage() {}
 
 func (x *TestCallee_AddRet) ProtoReflect() protoreflect.Message {
-	mi := &file_box_example_service_proto_msgTypes[7]
+	mi := &file_box_example_service_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -377,7 +735,7 @@ func (x *TestCallee_AddRet) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestCallee_AddRet.ProtoReflect.Descriptor instead.
 func (*TestCallee_AddRet) Descriptor() ([]byte, []int) {
-	return file_box_example_service_proto_rawDescGZIP(), []int{7}
+	return file_box_example_service_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *TestCallee_AddRet) GetRet1() int32 {
@@ -387,18 +745,42 @@ func (x *TestCallee_AddRet) GetRet1() int32 {
 	return 0
 }
 
+func (x *TestCallee_AddRet) GetTraceInfo() *ServiceBoxTraceInfo {
+	if x != nil {
+		return x.TraceInfo
+	}
+	return nil
+}
+
+func (x *TestCallee_AddRet) GetExecInfo() *ExceptionInfo {
+	if x != nil {
+		return x.ExecInfo
+	}
+	return nil
+}
+
+func (x *TestCallee_AddRet) GetCtx() *Context {
+	if x != nil {
+		return x.Ctx
+	}
+	return nil
+}
+
 type TestCallee_SubRet struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Ret1 int32 `protobuf:"varint,1,opt,name=ret1,proto3" json:"ret1,omitempty"`
+	Ret1      int32                `protobuf:"varint,1,opt,name=ret1,proto3" json:"ret1,omitempty"`
+	TraceInfo *ServiceBoxTraceInfo `protobuf:"bytes,2,opt,name=trace_info,json=traceInfo,proto3" json:"trace_info,omitempty"`
+	ExecInfo  *ExceptionInfo       `protobuf:"bytes,3,opt,name=exec_info,json=execInfo,proto3" json:"exec_info,omitempty"`
+	Ctx       *Context             `protobuf:"bytes,4,opt,name=ctx,proto3" json:"ctx,omitempty"`
 }
 
 func (x *TestCallee_SubRet) Reset() {
 	*x = TestCallee_SubRet{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_box_example_service_proto_msgTypes[8]
+		mi := &file_box_example_service_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -411,7 +793,7 @@ func (x *TestCallee_SubRet) String() string {
 func (*TestCallee_SubRet) ProtoMessage() {}
 
 func (x *TestCallee_SubRet) ProtoReflect() protoreflect.Message {
-	mi := &file_box_example_service_proto_msgTypes[8]
+	mi := &file_box_example_service_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -424,7 +806,7 @@ func (x *TestCallee_SubRet) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestCallee_SubRet.ProtoReflect.Descriptor instead.
 func (*TestCallee_SubRet) Descriptor() ([]byte, []int) {
-	return file_box_example_service_proto_rawDescGZIP(), []int{8}
+	return file_box_example_service_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *TestCallee_SubRet) GetRet1() int32 {
@@ -434,35 +816,144 @@ func (x *TestCallee_SubRet) GetRet1() int32 {
 	return 0
 }
 
+func (x *TestCallee_SubRet) GetTraceInfo() *ServiceBoxTraceInfo {
+	if x != nil {
+		return x.TraceInfo
+	}
+	return nil
+}
+
+func (x *TestCallee_SubRet) GetExecInfo() *ExceptionInfo {
+	if x != nil {
+		return x.ExecInfo
+	}
+	return nil
+}
+
+func (x *TestCallee_SubRet) GetCtx() *Context {
+	if x != nil {
+		return x.Ctx
+	}
+	return nil
+}
+
 var File_box_example_service_proto protoreflect.FileDescriptor
 
 var file_box_example_service_proto_rawDesc = []byte{
 	0x0a, 0x19, 0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x73, 0x65,
 	0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x62, 0x6f, 0x78,
-	0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x22, 0x07, 0x0a, 0x05, 0x45, 0x6d, 0x70, 0x74,
-	0x79, 0x22, 0x2d, 0x0a, 0x17, 0x54, 0x65, 0x73, 0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x5f,
-	0x53, 0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x5f, 0x61, 0x72, 0x67, 0x73, 0x12, 0x12, 0x0a, 0x04,
-	0x61, 0x72, 0x67, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x31,
-	0x22, 0x19, 0x0a, 0x17, 0x54, 0x65, 0x73, 0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x5f, 0x47,
-	0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x5f, 0x61, 0x72, 0x67, 0x73, 0x22, 0x3d, 0x0a, 0x13, 0x54,
-	0x65, 0x73, 0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x65, 0x5f, 0x41, 0x64, 0x64, 0x5f, 0x61, 0x72,
-	0x67, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
-	0x52, 0x04, 0x61, 0x72, 0x67, 0x31, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x32, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x61, 0x72, 0x67, 0x32, 0x22, 0x3d, 0x0a, 0x13, 0x54, 0x65,
-	0x73, 0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x65, 0x5f, 0x53, 0x75, 0x62, 0x5f, 0x61, 0x72, 0x67,
-	0x73, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x04, 0x61, 0x72, 0x67, 0x31, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x32, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x05, 0x52, 0x04, 0x61, 0x72, 0x67, 0x32, 0x22, 0x18, 0x0a, 0x16, 0x54, 0x65, 0x73,
-	0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x5f, 0x53, 0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x5f,
-	0x72, 0x65, 0x74, 0x22, 0x2c, 0x0a, 0x16, 0x54, 0x65, 0x73, 0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65,
-	0x72, 0x5f, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x5f, 0x72, 0x65, 0x74, 0x12, 0x12, 0x0a,
-	0x04, 0x72, 0x65, 0x74, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x72, 0x65, 0x74,
-	0x31, 0x22, 0x28, 0x0a, 0x12, 0x54, 0x65, 0x73, 0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x65, 0x5f,
-	0x41, 0x64, 0x64, 0x5f, 0x72, 0x65, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x65, 0x74, 0x31, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x72, 0x65, 0x74, 0x31, 0x22, 0x28, 0x0a, 0x12, 0x54,
-	0x65, 0x73, 0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x65, 0x5f, 0x53, 0x75, 0x62, 0x5f, 0x72, 0x65,
-	0x74, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x65, 0x74, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x04, 0x72, 0x65, 0x74, 0x31, 0x42, 0x10, 0x5a, 0x0e, 0x69, 0x64, 0x6c, 0x64, 0x61, 0x74, 0x61,
+	0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x22, 0x8e, 0x01, 0x0a, 0x13, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x42, 0x6f, 0x78, 0x54, 0x72, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f,
+	0x12, 0x1b, 0x0a, 0x09, 0x74, 0x72, 0x61, 0x63, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x74, 0x72, 0x61, 0x63, 0x65, 0x72, 0x49, 0x64, 0x12, 0x17, 0x0a,
+	0x07, 0x73, 0x70, 0x61, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06,
+	0x73, 0x70, 0x61, 0x6e, 0x49, 0x64, 0x12, 0x24, 0x0a, 0x0e, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74,
+	0x5f, 0x73, 0x70, 0x61, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c,
+	0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x53, 0x70, 0x61, 0x6e, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09,
+	0x75, 0x73, 0x65, 0x72, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x75, 0x73, 0x65, 0x72, 0x44, 0x61, 0x74, 0x61, 0x22, 0x4f, 0x0a, 0x0d, 0x45, 0x78, 0x63,
+	0x65, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x22, 0x32, 0x0a, 0x08, 0x4b, 0x65,
+	0x79, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x34,
+	0x0a, 0x07, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x12, 0x29, 0x0a, 0x04, 0x69, 0x6e, 0x66,
+	0x6f, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78,
+	0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x4b, 0x65, 0x79, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x04,
+	0x69, 0x6e, 0x66, 0x6f, 0x22, 0x07, 0x0a, 0x05, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x96, 0x01,
+	0x0a, 0x17, 0x54, 0x65, 0x73, 0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x5f, 0x53, 0x65, 0x74,
+	0x49, 0x6e, 0x66, 0x6f, 0x5f, 0x61, 0x72, 0x67, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67,
+	0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x31, 0x12, 0x3f, 0x0a,
+	0x0a, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x20, 0x2e, 0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x42, 0x6f, 0x78, 0x54, 0x72, 0x61, 0x63, 0x65, 0x49,
+	0x6e, 0x66, 0x6f, 0x52, 0x09, 0x74, 0x72, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x26,
+	0x0a, 0x03, 0x63, 0x74, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x62, 0x6f,
+	0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78,
+	0x74, 0x52, 0x03, 0x63, 0x74, 0x78, 0x22, 0x82, 0x01, 0x0a, 0x17, 0x54, 0x65, 0x73, 0x74, 0x43,
+	0x61, 0x6c, 0x6c, 0x65, 0x72, 0x5f, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x5f, 0x61, 0x72,
+	0x67, 0x73, 0x12, 0x3f, 0x0a, 0x0a, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x6e, 0x66, 0x6f,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61,
+	0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x42, 0x6f, 0x78, 0x54,
+	0x72, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09, 0x74, 0x72, 0x61, 0x63, 0x65, 0x49,
+	0x6e, 0x66, 0x6f, 0x12, 0x26, 0x0a, 0x03, 0x63, 0x74, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x14, 0x2e, 0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x43,
+	0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x52, 0x03, 0x63, 0x74, 0x78, 0x22, 0xa6, 0x01, 0x0a, 0x13,
+	0x54, 0x65, 0x73, 0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x65, 0x5f, 0x41, 0x64, 0x64, 0x5f, 0x61,
+	0x72, 0x67, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x04, 0x61, 0x72, 0x67, 0x31, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x32, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x61, 0x72, 0x67, 0x32, 0x12, 0x3f, 0x0a, 0x0a, 0x74,
+	0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x20, 0x2e, 0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x42, 0x6f, 0x78, 0x54, 0x72, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x66,
+	0x6f, 0x52, 0x09, 0x74, 0x72, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x26, 0x0a, 0x03,
+	0x63, 0x74, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x62, 0x6f, 0x78, 0x5f,
+	0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x52,
+	0x03, 0x63, 0x74, 0x78, 0x22, 0xa6, 0x01, 0x0a, 0x13, 0x54, 0x65, 0x73, 0x74, 0x43, 0x61, 0x6c,
+	0x6c, 0x65, 0x65, 0x5f, 0x53, 0x75, 0x62, 0x5f, 0x61, 0x72, 0x67, 0x73, 0x12, 0x12, 0x0a, 0x04,
+	0x61, 0x72, 0x67, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x61, 0x72, 0x67, 0x31,
+	0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x32, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04,
+	0x61, 0x72, 0x67, 0x32, 0x12, 0x3f, 0x0a, 0x0a, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x6e,
+	0x66, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x62, 0x6f, 0x78, 0x5f, 0x65,
+	0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x42, 0x6f,
+	0x78, 0x54, 0x72, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09, 0x74, 0x72, 0x61, 0x63,
+	0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x26, 0x0a, 0x03, 0x63, 0x74, 0x78, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x14, 0x2e, 0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65,
+	0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x52, 0x03, 0x63, 0x74, 0x78, 0x22, 0xba, 0x01,
+	0x0a, 0x16, 0x54, 0x65, 0x73, 0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x5f, 0x53, 0x65, 0x74,
+	0x49, 0x6e, 0x66, 0x6f, 0x5f, 0x72, 0x65, 0x74, 0x12, 0x3f, 0x0a, 0x0a, 0x74, 0x72, 0x61, 0x63,
+	0x65, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x62,
+	0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x42, 0x6f, 0x78, 0x54, 0x72, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09,
+	0x74, 0x72, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x37, 0x0a, 0x09, 0x65, 0x78, 0x65,
+	0x63, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x62,
+	0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x45, 0x78, 0x63, 0x65, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x65, 0x78, 0x65, 0x63, 0x49, 0x6e,
+	0x66, 0x6f, 0x12, 0x26, 0x0a, 0x03, 0x63, 0x74, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x14, 0x2e, 0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x43, 0x6f,
+	0x6e, 0x74, 0x65, 0x78, 0x74, 0x52, 0x03, 0x63, 0x74, 0x78, 0x22, 0xce, 0x01, 0x0a, 0x16, 0x54,
+	0x65, 0x73, 0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x5f, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x66,
+	0x6f, 0x5f, 0x72, 0x65, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x65, 0x74, 0x31, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x72, 0x65, 0x74, 0x31, 0x12, 0x3f, 0x0a, 0x0a, 0x74, 0x72, 0x61,
+	0x63, 0x65, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e,
+	0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x53, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x42, 0x6f, 0x78, 0x54, 0x72, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52,
+	0x09, 0x74, 0x72, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x37, 0x0a, 0x09, 0x65, 0x78,
+	0x65, 0x63, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x45, 0x78, 0x63, 0x65,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x65, 0x78, 0x65, 0x63, 0x49,
+	0x6e, 0x66, 0x6f, 0x12, 0x26, 0x0a, 0x03, 0x63, 0x74, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x14, 0x2e, 0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x43,
+	0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x52, 0x03, 0x63, 0x74, 0x78, 0x22, 0xca, 0x01, 0x0a, 0x12,
+	0x54, 0x65, 0x73, 0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x65, 0x5f, 0x41, 0x64, 0x64, 0x5f, 0x72,
+	0x65, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x65, 0x74, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x04, 0x72, 0x65, 0x74, 0x31, 0x12, 0x3f, 0x0a, 0x0a, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f,
+	0x69, 0x6e, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x62, 0x6f, 0x78,
+	0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x42, 0x6f, 0x78, 0x54, 0x72, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09, 0x74, 0x72,
+	0x61, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x37, 0x0a, 0x09, 0x65, 0x78, 0x65, 0x63, 0x5f,
+	0x69, 0x6e, 0x66, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x62, 0x6f, 0x78,
+	0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x45, 0x78, 0x63, 0x65, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x65, 0x78, 0x65, 0x63, 0x49, 0x6e, 0x66, 0x6f,
+	0x12, 0x26, 0x0a, 0x03, 0x63, 0x74, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e,
+	0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x43, 0x6f, 0x6e, 0x74,
+	0x65, 0x78, 0x74, 0x52, 0x03, 0x63, 0x74, 0x78, 0x22, 0xca, 0x01, 0x0a, 0x12, 0x54, 0x65, 0x73,
+	0x74, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x65, 0x5f, 0x53, 0x75, 0x62, 0x5f, 0x72, 0x65, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x72, 0x65, 0x74, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x72,
+	0x65, 0x74, 0x31, 0x12, 0x3f, 0x0a, 0x0a, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x6e, 0x66,
+	0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78,
+	0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x42, 0x6f, 0x78,
+	0x54, 0x72, 0x61, 0x63, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09, 0x74, 0x72, 0x61, 0x63, 0x65,
+	0x49, 0x6e, 0x66, 0x6f, 0x12, 0x37, 0x0a, 0x09, 0x65, 0x78, 0x65, 0x63, 0x5f, 0x69, 0x6e, 0x66,
+	0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x62, 0x6f, 0x78, 0x5f, 0x65, 0x78,
+	0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x45, 0x78, 0x63, 0x65, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x49,
+	0x6e, 0x66, 0x6f, 0x52, 0x08, 0x65, 0x78, 0x65, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x26, 0x0a,
+	0x03, 0x63, 0x74, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x62, 0x6f, 0x78,
+	0x5f, 0x65, 0x78, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74,
+	0x52, 0x03, 0x63, 0x74, 0x78, 0x42, 0x10, 0x5a, 0x0e, 0x69, 0x64, 0x6c, 0x64, 0x61, 0x74, 0x61,
 	0x2f, 0x70, 0x62, 0x64, 0x61, 0x74, 0x61, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
@@ -478,24 +969,49 @@ func file_box_example_service_proto_rawDescGZIP() []byte {
 	return file_box_example_service_proto_rawDescData
 }
 
-var file_box_example_service_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_box_example_service_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_box_example_service_proto_goTypes = []interface{}{
-	(*Empty)(nil),                  // 0: box_example.Empty
-	(*TestCaller_SetInfoArgs)(nil), // 1: box_example.TestCaller_SetInfo_args
-	(*TestCaller_GetInfoArgs)(nil), // 2: box_example.TestCaller_GetInfo_args
-	(*TestCallee_AddArgs)(nil),     // 3: box_example.TestCallee_Add_args
-	(*TestCallee_SubArgs)(nil),     // 4: box_example.TestCallee_Sub_args
-	(*TestCaller_SetInfoRet)(nil),  // 5: box_example.TestCaller_SetInfo_ret
-	(*TestCaller_GetInfoRet)(nil),  // 6: box_example.TestCaller_GetInfo_ret
-	(*TestCallee_AddRet)(nil),      // 7: box_example.TestCallee_Add_ret
-	(*TestCallee_SubRet)(nil),      // 8: box_example.TestCallee_Sub_ret
+	(*ServiceBoxTraceInfo)(nil),    // 0: box_example.ServiceBoxTraceInfo
+	(*ExceptionInfo)(nil),          // 1: box_example.ExceptionInfo
+	(*KeyValue)(nil),               // 2: box_example.KeyValue
+	(*Context)(nil),                // 3: box_example.Context
+	(*Empty)(nil),                  // 4: box_example.Empty
+	(*TestCaller_SetInfoArgs)(nil), // 5: box_example.TestCaller_SetInfo_args
+	(*TestCaller_GetInfoArgs)(nil), // 6: box_example.TestCaller_GetInfo_args
+	(*TestCallee_AddArgs)(nil),     // 7: box_example.TestCallee_Add_args
+	(*TestCallee_SubArgs)(nil),     // 8: box_example.TestCallee_Sub_args
+	(*TestCaller_SetInfoRet)(nil),  // 9: box_example.TestCaller_SetInfo_ret
+	(*TestCaller_GetInfoRet)(nil),  // 10: box_example.TestCaller_GetInfo_ret
+	(*TestCallee_AddRet)(nil),      // 11: box_example.TestCallee_Add_ret
+	(*TestCallee_SubRet)(nil),      // 12: box_example.TestCallee_Sub_ret
 }
 var file_box_example_service_proto_depIdxs = []int32{
-	0, // [0:0] is the sub-list for method output_type
-	0, // [0:0] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	2,  // 0: box_example.Context.info:type_name -> box_example.KeyValue
+	0,  // 1: box_example.TestCaller_SetInfo_args.trace_info:type_name -> box_example.ServiceBoxTraceInfo
+	3,  // 2: box_example.TestCaller_SetInfo_args.ctx:type_name -> box_example.Context
+	0,  // 3: box_example.TestCaller_GetInfo_args.trace_info:type_name -> box_example.ServiceBoxTraceInfo
+	3,  // 4: box_example.TestCaller_GetInfo_args.ctx:type_name -> box_example.Context
+	0,  // 5: box_example.TestCallee_Add_args.trace_info:type_name -> box_example.ServiceBoxTraceInfo
+	3,  // 6: box_example.TestCallee_Add_args.ctx:type_name -> box_example.Context
+	0,  // 7: box_example.TestCallee_Sub_args.trace_info:type_name -> box_example.ServiceBoxTraceInfo
+	3,  // 8: box_example.TestCallee_Sub_args.ctx:type_name -> box_example.Context
+	0,  // 9: box_example.TestCaller_SetInfo_ret.trace_info:type_name -> box_example.ServiceBoxTraceInfo
+	1,  // 10: box_example.TestCaller_SetInfo_ret.exec_info:type_name -> box_example.ExceptionInfo
+	3,  // 11: box_example.TestCaller_SetInfo_ret.ctx:type_name -> box_example.Context
+	0,  // 12: box_example.TestCaller_GetInfo_ret.trace_info:type_name -> box_example.ServiceBoxTraceInfo
+	1,  // 13: box_example.TestCaller_GetInfo_ret.exec_info:type_name -> box_example.ExceptionInfo
+	3,  // 14: box_example.TestCaller_GetInfo_ret.ctx:type_name -> box_example.Context
+	0,  // 15: box_example.TestCallee_Add_ret.trace_info:type_name -> box_example.ServiceBoxTraceInfo
+	1,  // 16: box_example.TestCallee_Add_ret.exec_info:type_name -> box_example.ExceptionInfo
+	3,  // 17: box_example.TestCallee_Add_ret.ctx:type_name -> box_example.Context
+	0,  // 18: box_example.TestCallee_Sub_ret.trace_info:type_name -> box_example.ServiceBoxTraceInfo
+	1,  // 19: box_example.TestCallee_Sub_ret.exec_info:type_name -> box_example.ExceptionInfo
+	3,  // 20: box_example.TestCallee_Sub_ret.ctx:type_name -> box_example.Context
+	21, // [21:21] is the sub-list for method output_type
+	21, // [21:21] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_box_example_service_proto_init() }
@@ -505,7 +1021,7 @@ func file_box_example_service_proto_init() {
 	}
 	if !protoimpl.UnsafeEnabled {
 		file_box_example_service_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Empty); i {
+			switch v := v.(*ServiceBoxTraceInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -517,7 +1033,7 @@ func file_box_example_service_proto_init() {
 			}
 		}
 		file_box_example_service_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TestCaller_SetInfoArgs); i {
+			switch v := v.(*ExceptionInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -529,7 +1045,7 @@ func file_box_example_service_proto_init() {
 			}
 		}
 		file_box_example_service_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TestCaller_GetInfoArgs); i {
+			switch v := v.(*KeyValue); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -541,7 +1057,7 @@ func file_box_example_service_proto_init() {
 			}
 		}
 		file_box_example_service_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TestCallee_AddArgs); i {
+			switch v := v.(*Context); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -553,7 +1069,7 @@ func file_box_example_service_proto_init() {
 			}
 		}
 		file_box_example_service_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TestCallee_SubArgs); i {
+			switch v := v.(*Empty); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -565,7 +1081,7 @@ func file_box_example_service_proto_init() {
 			}
 		}
 		file_box_example_service_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TestCaller_SetInfoRet); i {
+			switch v := v.(*TestCaller_SetInfoArgs); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -577,7 +1093,7 @@ func file_box_example_service_proto_init() {
 			}
 		}
 		file_box_example_service_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TestCaller_GetInfoRet); i {
+			switch v := v.(*TestCaller_GetInfoArgs); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -589,7 +1105,7 @@ func file_box_example_service_proto_init() {
 			}
 		}
 		file_box_example_service_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TestCallee_AddRet); i {
+			switch v := v.(*TestCallee_AddArgs); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -601,6 +1117,54 @@ func file_box_example_service_proto_init() {
 			}
 		}
 		file_box_example_service_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TestCallee_SubArgs); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_box_example_service_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TestCaller_SetInfoRet); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_box_example_service_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TestCaller_GetInfoRet); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_box_example_service_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TestCallee_AddRet); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_box_example_service_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*TestCallee_SubRet); i {
 			case 0:
 				return &v.state
@@ -619,7 +1183,7 @@ func file_box_example_service_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_box_example_service_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,90 @@
+// Output of idl2go fake template for TestCaller, package and imports adapted to the example package
+// source: TestCaller
+
+package example
+
+import (
+	"sync"
+)
+
+// TestCallerCaller methods of TestCallerProxy, depend on it to swap in FakeTestCallerProxy in tests
+type TestCallerCaller interface {
+	GetUUID() uint64
+	GetSrvName() string
+	SetInfo(_1 string) (err error)
+	GetInfo() (ret1 string, err error)
+}
+
+var _ TestCallerCaller = (*TestCallerProxy)(nil)
+
+// FakeTestCallerCall call recorded by FakeTestCallerProxy
+type FakeTestCallerCall struct {
+	Method string
+	Args   []interface{}
+}
+
+// FakeTestCallerProxy fake TestCallerProxy, records calls and returns canned results without rpc framework
+type FakeTestCallerProxy struct {
+	mu         sync.Mutex
+	calls      []FakeTestCallerCall
+	SetInfoErr error
+	GetInfoRet string
+	GetInfoErr error
+}
+
+func NewFakeTestCallerProxy() *FakeTestCallerProxy {
+	return &FakeTestCallerProxy{}
+}
+
+func (f *FakeTestCallerProxy) GetUUID() uint64 {
+	return SrvUUID
+}
+
+func (f *FakeTestCallerProxy) GetSrvName() string {
+	return SrvName
+}
+
+// Calls recorded calls in order
+func (f *FakeTestCallerProxy) Calls() []FakeTestCallerCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeTestCallerCall(nil), f.calls...)
+}
+
+// CallsOf recorded calls of method in order
+func (f *FakeTestCallerProxy) CallsOf(method string) []FakeTestCallerCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []FakeTestCallerCall
+	for _, call := range f.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset clear recorded calls, canned results are kept
+func (f *FakeTestCallerProxy) Reset() {
+	f.mu.Lock()
+	f.calls = nil
+	f.mu.Unlock()
+}
+
+func (f *FakeTestCallerProxy) record(method string, args ...interface{}) {
+	f.calls = append(f.calls, FakeTestCallerCall{Method: method, Args: args})
+}
+
+func (f *FakeTestCallerProxy) SetInfo(_1 string) (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("SetInfo", _1)
+	return f.SetInfoErr
+}
+
+func (f *FakeTestCallerProxy) GetInfo() (ret1 string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("GetInfo")
+	return f.GetInfoRet, f.GetInfoErr
+}
@@ -0,0 +1,140 @@
+// Output of idl2go mock template for TestCaller, package and imports adapted to the example package
+// source: TestCaller
+
+package example
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MockTestCallerCall call recorded by MockTestCaller, Args are method arguments without ctx
+type MockTestCallerCall struct {
+	Method string
+	Args   []interface{}
+}
+
+// MockTestCaller mock of ITestCaller, set <Method>Func to stub method, Expect<Method> to expect calls,
+// CallsOf to check arguments of calls
+type MockTestCaller struct {
+	mu          sync.Mutex
+	calls       []MockTestCallerCall
+	expects     map[string]int
+	SetInfoFunc func(context.Context, string) error
+	GetInfoFunc func(context.Context) (string, error)
+}
+
+func NewMockTestCaller() *MockTestCaller {
+	return &MockTestCaller{
+		expects: make(map[string]int),
+	}
+}
+
+func (m *MockTestCaller) GetUUID() uint64 {
+	return SrvUUID
+}
+
+func (m *MockTestCaller) GetNickName() string {
+	return SrvName
+}
+
+func (m *MockTestCaller) OnAfterFork(ctx context.Context) bool {
+	return true
+}
+
+func (m *MockTestCaller) OnTick() bool {
+	return true
+}
+
+func (m *MockTestCaller) OnBeforeDestroy() bool {
+	return true
+}
+
+// Calls times method called
+func (m *MockTestCaller) Calls(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.count(method)
+}
+
+// CallsOf recorded calls of method in order
+func (m *MockTestCaller) CallsOf(method string) []MockTestCallerCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var calls []MockTestCallerCall
+	for _, call := range m.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (m *MockTestCaller) count(method string) int {
+	n := 0
+	for _, call := range m.calls {
+		if call.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+// Verify check expected calls, return error of all unmet expectations
+func (m *MockTestCaller) Verify() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var unmet []string
+	for method, times := range m.expects {
+		if called := m.count(method); called != times {
+			unmet = append(unmet, fmt.Sprintf("%s called %d times, expect %d", method, called, times))
+		}
+	}
+	if len(unmet) == 0 {
+		return nil
+	}
+	sort.Strings(unmet)
+	return fmt.Errorf("TestCaller mock: %s", strings.Join(unmet, "; "))
+}
+
+func (m *MockTestCaller) expect(method string, times int) *MockTestCaller {
+	m.mu.Lock()
+	m.expects[method] = times
+	m.mu.Unlock()
+	return m
+}
+
+func (m *MockTestCaller) called(method string, args ...interface{}) {
+	m.mu.Lock()
+	m.calls = append(m.calls, MockTestCallerCall{Method: method, Args: args})
+	m.mu.Unlock()
+}
+
+// ExpectSetInfo expect SetInfo called times
+func (m *MockTestCaller) ExpectSetInfo(times int) *MockTestCaller {
+	return m.expect("SetInfo", times)
+}
+
+func (m *MockTestCaller) SetInfo(ctx context.Context, _1 string) (err error) {
+	m.called("SetInfo", _1)
+	if m.SetInfoFunc != nil {
+		return m.SetInfoFunc(ctx, _1)
+	}
+	return
+}
+
+// ExpectGetInfo expect GetInfo called times
+func (m *MockTestCaller) ExpectGetInfo(times int) *MockTestCaller {
+	return m.expect("GetInfo", times)
+}
+
+func (m *MockTestCaller) GetInfo(ctx context.Context) (ret1 string, err error) {
+	m.called("GetInfo")
+	if m.GetInfoFunc != nil {
+		return m.GetInfoFunc(ctx)
+	}
+	return
+}
@@ -0,0 +1,46 @@
+// Output of idl2go sdk template for TestCaller, package and imports adapted to the example package
+// source: TestCaller
+package example
+
+import (
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+)
+
+// TestCallerSDK register TestCaller creators, proxy sdk does not register stub creator
+type TestCallerSDK struct {
+	proxy bool
+}
+
+// NewTestCallerSDK create sdk, args idlrpc.SdkProxy for proxy only sdk
+func NewTestCallerSDK(args ...string) (idlrpc.ISDK, error) {
+	return &TestCallerSDK{proxy: idlrpc.IsProxyArgs(args)}, nil
+}
+
+func (sdk *TestCallerSDK) GetUuid() uint64 {
+	return SrvUUID
+}
+
+func (sdk *TestCallerSDK) GetNickName() string {
+	return SrvName
+}
+
+func (sdk *TestCallerSDK) IsProxy() bool {
+	return sdk.proxy
+}
+
+func (sdk *TestCallerSDK) Register(rpc idlrpc.IRpc) error {
+	if !sdk.proxy {
+		if err := rpc.AddStubCreator(SrvUUID, TestCallerStubCreator); err != nil {
+			return err
+		}
+	}
+	return rpc.AddProxyCreator(SrvUUID, TestCallerProxyCreator)
+}
+
+func init() {
+	idlrpc.RegisterPackage(&idlrpc.PackageInfo{
+		ServiceUUID: SrvUUID,
+		ServiceName: SrvName,
+		Creator:     NewTestCallerSDK,
+	})
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"text/template"
+)
+
+// generated code is compiled and its tests are run in a temporary module named game,
+// idl structs live in game/idldata like the real layout
+
+const behaviorIdldata = `package idldata
+
+type Info struct {
+	Name string
+}
+
+type Item struct {
+	ID int32
+}
+`
+
+// goTestModule write files into a temporary module and run go test on it, skip while go is not available
+func goTestModule(t *testing.T, files map[string]string) {
+	goBin := filepath.Join(runtime.GOROOT(), "bin", "go")
+	if _, err := os.Stat(goBin); err != nil {
+		t.Skipf("go tool not found: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "idl2go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files["go.mod"] = "module game\n\ngo 1.13\n"
+	files["idldata/idldata.go"] = behaviorIdldata
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(goBin, "test", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GO111MODULE=on", "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generated code test failed: %v\n%s", err, out)
+	}
+}
+
+// execute render template with gen, generated code refers to idl structs by idldata package
+func execute(t *testing.T, tp *template.Template, gen *Gen) string {
+	idlpackagename = "idldata"
+	defer func() {
+		idlpackagename = ""
+	}()
+	buf := &bytes.Buffer{}
+	if err := tp.Execute(buf, gen); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// playerGen service with argument of idl struct, void method and method returning struct list
+func playerGen() *Gen {
+	return &Gen{Name: "Player", Idlname: "game", Service: &ServiceNode{Name: "Player", Uuid: "1001", Methods: []*MethodNode{
+		{
+			Name:  "login",
+			Index: 1,
+			Arguments: []*ArgNode{
+				{IdlType: "string", GoType: "string", Index: 1},
+				{IdlType: "Info", GoType: "Info", Index: 2, IsStruct: true},
+			},
+			RetType: &ArgNode{IdlType: "void", GoType: "void"},
+		},
+		{
+			Name:    "items",
+			Index:   2,
+			RetType: &ArgNode{IdlType: "seq", GoType: "[]", Key: &ComplexNode{GoType: "Item", IsStruct: true}},
+		},
+	}}}
+}
+
+// playerDef hand written part of service package which mock and fake depend on
+const playerDef = `package player
+
+import (
+	"context"
+
+	"game/idldata"
+)
+
+const (
+	SrvUUID uint64 = 1001
+	SrvName        = "Player"
+)
+
+type IPlayer interface {
+	GetUUID() uint64
+	GetNickName() string
+	OnAfterFork(ctx context.Context) bool
+	OnTick() bool
+	OnBeforeDestroy() bool
+	Login(ctx context.Context, _1 string, _2 *idldata.Info) error
+	Items(ctx context.Context) ([]*idldata.Item, error)
+}
+
+type PlayerProxy struct{}
+
+func (sp *PlayerProxy) GetUUID() uint64                        { return SrvUUID }
+func (sp *PlayerProxy) GetSrvName() string                     { return SrvName }
+func (sp *PlayerProxy) Login(_1 string, _2 *idldata.Info) error { return nil }
+func (sp *PlayerProxy) Items() ([]*idldata.Item, error)        { return nil, nil }
+`
+
+const playerMockTest = `package player
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"game/idldata"
+)
+
+var _ IPlayer = (*MockPlayer)(nil)
+
+func TestMock(t *testing.T) {
+	m := NewMockPlayer().ExpectLogin(2).ExpectItems(1)
+	m.ItemsFunc = func(context.Context) ([]*idldata.Item, error) {
+		return []*idldata.Item{{ID: 3}}, nil
+	}
+	bob, amy := &idldata.Info{Name: "bob"}, &idldata.Info{Name: "amy"}
+	if err := m.Login(context.Background(), "first", bob); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Verify(); err == nil || !strings.Contains(err.Error(), "Login called 1 times, expect 2") ||
+		!strings.Contains(err.Error(), "Items called 0 times, expect 1") {
+		t.Fatalf("unmet expectations not reported: %v", err)
+	}
+
+	m.LoginFunc = func(ctx context.Context, name string, info *idldata.Info) error {
+		return errors.New(name)
+	}
+	if err := m.Login(context.Background(), "second", amy); err == nil || err.Error() != "second" {
+		t.Fatalf("stub func not called: %v", err)
+	}
+	if items, err := m.Items(context.Background()); err != nil || len(items) != 1 || items[0].ID != 3 {
+		t.Fatalf("items %v %v", items, err)
+	}
+	if err := m.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := m.CallsOf("Login")
+	if m.Calls("Login") != 2 || len(calls) != 2 {
+		t.Fatalf("login calls %v", calls)
+	}
+	if !reflect.DeepEqual(calls[0].Args, []interface{}{"first", bob}) || calls[1].Args[1] != amy {
+		t.Fatalf("login arguments %v %v", calls[0].Args, calls[1].Args)
+	}
+	if items := m.CallsOf("Items"); len(items) != 1 || len(items[0].Args) != 0 {
+		t.Fatalf("items calls %v", items)
+	}
+}
+
+func TestFake(t *testing.T) {
+	var caller PlayerCaller = NewFakePlayerProxy()
+	f := caller.(*FakePlayerProxy)
+	f.LoginErr = errors.New("denied")
+	f.ItemsRet = []*idldata.Item{{ID: 1}}
+
+	info := &idldata.Info{Name: "bob"}
+	if err := caller.Login("bob", info); err != f.LoginErr {
+		t.Fatalf("login returns %v", err)
+	}
+	if items, err := caller.Items(); err != nil || !reflect.DeepEqual(items, f.ItemsRet) {
+		t.Fatalf("items %v %v", items, err)
+	}
+	expect := []FakePlayerCall{{Method: "Login", Args: []interface{}{"bob", info}}, {Method: "Items"}}
+	if calls := f.Calls(); !reflect.DeepEqual(calls, expect) {
+		t.Fatalf("calls %+v", calls)
+	}
+	f.Reset()
+	if len(f.Calls()) != 0 || f.ItemsRet == nil {
+		t.Fatal("reset drops canned results or keeps calls")
+	}
+}
+`
+
+func TestGenMockBehavior(t *testing.T) {
+	gen := playerGen()
+	goTestModule(t, map[string]string{
+		"player/player_def.go":  playerDef,
+		"player/player_mock.go": execute(t, mocktp, gen),
+		"player/player_fake.go": execute(t, faketp, gen),
+		"player/player_test.go": playerMockTest,
+	})
+}
+
+// playerCtxDef proxy of ctx first service which fake depends on
+const playerCtxDef = `package player
+
+import (
+	"context"
+
+	"game/idldata"
+)
+
+const (
+	SrvUUID uint64 = 1001
+	SrvName        = "Player"
+)
+
+type PlayerProxy struct{}
+
+func (sp *PlayerProxy) GetUUID() uint64    { return SrvUUID }
+func (sp *PlayerProxy) GetSrvName() string { return SrvName }
+func (sp *PlayerProxy) Login(ctx context.Context, _1 string, _2 *idldata.Info) error {
+	return nil
+}
+func (sp *PlayerProxy) Items(ctx context.Context) ([]*idldata.Item, error) { return nil, nil }
+`
+
+const playerCtxFakeTest = `package player
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"game/idldata"
+)
+
+func TestCtxFake(t *testing.T) {
+	var caller PlayerCaller = NewFakePlayerProxy()
+	f := caller.(*FakePlayerProxy)
+	f.ItemsRet = []*idldata.Item{{ID: 2}}
+
+	info := &idldata.Info{Name: "amy"}
+	if err := caller.Login(context.Background(), "amy", info); err != nil {
+		t.Fatal(err)
+	}
+	if items, err := caller.Items(context.TODO()); err != nil || !reflect.DeepEqual(items, f.ItemsRet) {
+		t.Fatalf("items %v %v", items, err)
+	}
+	// context is not recorded as argument
+	if calls := f.CallsOf("Login"); len(calls) != 1 || !reflect.DeepEqual(calls[0].Args, []interface{}{"amy", info}) {
+		t.Fatalf("login calls %+v", calls)
+	}
+}
+`
+
+func TestGenContextFirstFake(t *testing.T) {
+	gen := playerGen()
+	gen.CtxFirst = true
+	goTestModule(t, map[string]string{
+		"player/player_def.go":  playerCtxDef,
+		"player/player_fake.go": execute(t, faketp, gen),
+		"player/player_test.go": playerCtxFakeTest,
+	})
+}
+
+const exceptionTest = `package idldata
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// remoteException exception rebuilt from code and name on the caller side, like rpc RpcError
+type remoteException struct {
+	code uint32
+	name string
+}
+
+func (e *remoteException) Error() string         { return e.name }
+func (e *remoteException) ExceptionCode() uint32 { return e.code }
+func (e *remoteException) ExceptionName() string { return e.name }
+
+func TestException(t *testing.T) {
+	var ex IException = NewNotEnoughGold("need 10")
+	if ex.Error() != "NotEnoughGold: need 10" || ex.ExceptionCode() != 1001 || ex.ExceptionName() != "NotEnoughGold" {
+		t.Fatalf("unexpected exception %v %d %s", ex, ex.ExceptionCode(), ex.ExceptionName())
+	}
+	if ItemLockedCode != 1002 || NewItemLocked("").ExceptionName() != "item_locked" {
+		t.Fatal("unexpected item_locked code or name")
+	}
+
+	wrapped := fmt.Errorf("buy: %w", ex)
+	if !errors.Is(wrapped, &NotEnoughGold{}) || errors.Is(wrapped, &ItemLocked{}) {
+		t.Fatal("exception matched by wrong type")
+	}
+	if !errors.Is(wrapped, &remoteException{1001, "NotEnoughGold"}) {
+		t.Fatal("exception does not match remote one with same code and name")
+	}
+	if errors.Is(wrapped, &remoteException{1001, "item_locked"}) || errors.Is(wrapped, &remoteException{1002, "NotEnoughGold"}) {
+		t.Fatal("exception matches remote one with different code or name")
+	}
+	var gold *NotEnoughGold
+	if !errors.As(wrapped, &gold) || gold.Detail != "need 10" {
+		t.Fatalf("detail lost %v", gold)
+	}
+}
+`
+
+func TestGenExceptionBehavior(t *testing.T) {
+	buf := &bytes.Buffer{}
+	exceptions := []*ExceptionNode{{Name: "NotEnoughGold", Code: 1001}, {Name: "item_locked", Code: 1002}}
+	err := idlextp.Execute(buf, struct {
+		IdlName    string
+		Exceptions []*ExceptionNode
+	}{"game", exceptions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	goTestModule(t, map[string]string{
+		"idldata/game.exception.go":      buf.String(),
+		"idldata/game_exception_test.go": exceptionTest,
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -20,6 +21,7 @@ type Gen struct {
 	Service   *ServiceNode //解析出来的节点
 	HasStruct bool         //是否有公共文件结构体
 	Idlname   string       //idl 包名字用于管理公共的结构体
+	CtxFirst  bool         //proxy 方法第一个参数为 context.Context
 }
 
 func (g *Gen) GenHead() string {
@@ -78,6 +80,15 @@ func GenGoIdleService(jsonpath, idlfile string) error {
 		}
 		os.Chdir("..")
 	}
+	//生成idl 异常类型
+	if len(idldesc.Exceptions) != 0 {
+		os.Chdir("idldata")
+		err := GenGoException(idldesc.IdlName, idldesc.Exceptions)
+		os.Chdir("..")
+		if err != nil {
+			return err
+		}
+	}
 	//fmt.Printf("%v \n", idldesc)
 	GenService(&idldesc)
 
@@ -86,6 +97,54 @@ func GenGoIdleService(jsonpath, idlfile string) error {
 	return nil
 }
 
+// DryRunIdl print diff of usr impl files which regeneration would make, nothing is written
+func DryRunIdl(jsonpath, idlfile string) error {
+	idldesc, err := LoadIdlJson(fmt.Sprintf("%s/%s", jsonpath, idlfile))
+	if err != nil {
+		return err
+	}
+	if !idldesc.IsValid() {
+		return fmt.Errorf("service %v json is invalid", idldesc.ServiceNames)
+	}
+	idlpackagename = "idldata"
+	defer func() {
+		idlpackagename = ""
+	}()
+
+	for idx, srv := range idldesc.Services {
+		name := idldesc.ServiceNames[idx]
+		if name == "" || (len(updateService) != 0 && strings.ToLower(name) != updateService) {
+			continue
+		}
+		gen := &Gen{Name: name, Service: srv, Idlname: idldesc.IdlName, HasStruct: len(idldesc.Structs) != 0}
+		code, err := RenderClientImpl(gen)
+		if err != nil {
+			return err
+		}
+		srvpath := strings.ToLower(name)
+		rel := filepath.Join(strings.ToLower(idldesc.IdlName), srvpath, "usr", srvpath+"_impl.go")
+		existing, err := ioutil.ReadFile(filepath.Join(OutDir, rel))
+		if err == nil {
+			merge, err := MergeUsrFile(existing, code)
+			if err != nil {
+				return fmt.Errorf("merge %s error %v", rel, err)
+			}
+			if len(merge.Mismatched) != 0 {
+				fmt.Printf("%s methods %v differ from idl, fix their signature !!! \n", rel, merge.Mismatched)
+			}
+			code = merge.Code
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		if diff := UnifiedDiff(filepath.ToSlash(rel), existing, code); diff != "" {
+			fmt.Print(diff)
+		} else {
+			fmt.Printf("%s is up to date \n", rel)
+		}
+	}
+	return nil
+}
+
 func CheckIdlDir(idlname string) error {
 	var err error
 	if PathExits(idlname) == false {
@@ -185,9 +244,10 @@ func GenService(idljson *IdlJsonNode) {
 		}
 		//生成data 引用文件
 		gen := &Gen{
-			Name:    idljson.ServiceNames[idx],
-			Service: v,
-			Idlname: idljson.IdlName,
+			Name:     idljson.ServiceNames[idx],
+			Service:  v,
+			Idlname:  idljson.IdlName,
+			CtxFirst: ctxFirst,
 		}
 
 		if len(idljson.Structs) != 0 {
@@ -205,6 +265,10 @@ func GenService(idljson *IdlJsonNode) {
 		GenProxyFile(gen.Idlname, gen)
 		//生成stub文件
 		StubGenFile(gen.Idlname, gen)
+		//生成sdk文件
+		GenSdkFile(gen.Idlname, gen)
+		//生成mock和fake文件
+		GenMockFile(gen.Idlname, gen)
 		//生成client文件 生相对路径绑在不接入包管理的情况下需要绑定
 		GenClientImpl(gen.Idlname, gen)
 
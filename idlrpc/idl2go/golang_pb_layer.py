@@ -28,7 +28,7 @@ class FileGenerator:
         file.write('message ServiceBoxTraceInfo {\n')
         file.write('\tstring tracer_id = 1;\n\tuint64 span_id = 2;\n\tuint64 parent_span_id = 3;\n\tstring user_data = 4;\n}\n\n')
         file.write('message ExceptionInfo {\n')
-        file.write('\tstring name = 1;\n\tstring detail = 2;\n}\n\n')
+        file.write('\tstring name = 1;\n\tstring detail = 2;\n\tuint32 code = 3;\n}\n\n')
         file.write('')
         file.write('message KeyValue {\n')
         file.write('    string key = 1;\n')
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// compat 模式: idl2go compat old.json new.json
+// 对比两个版本的 idl json, 报告会导致老版本调用方失败的修改, 有破坏性修改时返回非零
+
+// LoadIdlJson read idl json file
+func LoadIdlJson(path string) (*IdlJsonNode, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	idldesc := &IdlJsonNode{}
+	if err = json.Unmarshal(data, idldesc); err != nil {
+		return nil, fmt.Errorf("parse idl json %s error %v", path, err)
+	}
+	return idldesc, nil
+}
+
+// runCompat compat sub command, exit code 1 while breaking changes are found
+func runCompat(args []string) int {
+	if len(args) != 2 {
+		fmt.Println("Usage idl2go compat old.idl.go.json new.idl.go.json")
+		return -1
+	}
+	oldIdl, err := LoadIdlJson(args[0])
+	if err != nil {
+		fmt.Println(err)
+		return -1
+	}
+	newIdl, err := LoadIdlJson(args[1])
+	if err != nil {
+		fmt.Println(err)
+		return -1
+	}
+	breaks := CompatCheck(oldIdl, newIdl)
+	if len(breaks) == 0 {
+		fmt.Printf("%s is compatible with %s \n", args[1], args[0])
+		return 0
+	}
+	for _, b := range breaks {
+		fmt.Println("BREAKING:", b)
+	}
+	fmt.Printf("%d breaking changes found \n", len(breaks))
+	return 1
+}
+
+// CompatCheck breaking changes from oldIdl to newIdl, callers built with old idl would fail
+func CompatCheck(oldIdl, newIdl *IdlJsonNode) []string {
+	var breaks []string
+	newSrvs := make(map[string]*ServiceNode, len(newIdl.Services))
+	for _, srv := range newIdl.Services {
+		newSrvs[srv.Name] = srv
+	}
+	for _, oldSrv := range oldIdl.Services {
+		newSrv, ok := newSrvs[oldSrv.Name]
+		if !ok {
+			breaks = append(breaks, fmt.Sprintf("service %s removed", oldSrv.Name))
+			continue
+		}
+		if oldSrv.Uuid != newSrv.Uuid {
+			breaks = append(breaks, fmt.Sprintf("service %s uuid changed %s -> %s", oldSrv.Name, oldSrv.Uuid, newSrv.Uuid))
+		}
+		breaks = append(breaks, compatMethods(oldSrv, newSrv)...)
+	}
+	return append(breaks, compatEnums(oldIdl.Enums, newIdl.Enums)...)
+}
+
+// compatMethods methods are matched by name, stub dispatches by method index
+func compatMethods(oldSrv, newSrv *ServiceNode) []string {
+	var breaks []string
+	newMethods := make(map[string]*MethodNode, len(newSrv.Methods))
+	for _, m := range newSrv.Methods {
+		newMethods[m.Name] = m
+	}
+	oldIndex := make(map[uint32]string, len(oldSrv.Methods))
+	for _, m := range oldSrv.Methods {
+		oldIndex[m.Index] = m.Name
+	}
+
+	for _, om := range oldSrv.Methods {
+		name := oldSrv.Name + "." + om.Name
+		nm, ok := newMethods[om.Name]
+		if !ok {
+			breaks = append(breaks, fmt.Sprintf("method %s removed", name))
+			continue
+		}
+		if om.Index != nm.Index {
+			breaks = append(breaks, fmt.Sprintf("method %s renumbered %d -> %d", name, om.Index, nm.Index))
+		}
+		if om.IsOneway != nm.IsOneway {
+			breaks = append(breaks, fmt.Sprintf("method %s oneway changed %t -> %t", name, om.IsOneway, nm.IsOneway))
+		}
+		oldArgs, newArgs := realArgs(om), realArgs(nm)
+		if len(oldArgs) != len(newArgs) {
+			breaks = append(breaks, fmt.Sprintf("method %s argument count changed %d -> %d", name, len(oldArgs), len(newArgs)))
+		}
+		for i := 0; i < len(oldArgs) && i < len(newArgs); i++ {
+			if ot, nt := argTypeName(oldArgs[i]), argTypeName(newArgs[i]); ot != nt {
+				breaks = append(breaks, fmt.Sprintf("method %s argument %d type changed %s -> %s", name, i+1, ot, nt))
+			}
+		}
+		if ot, nt := argTypeName(om.RetType), argTypeName(nm.RetType); ot != nt {
+			breaks = append(breaks, fmt.Sprintf("method %s return type changed %s -> %s", name, ot, nt))
+		}
+	}
+
+	// index of old method taken by another method
+	for _, nm := range newSrv.Methods {
+		if name, ok := oldIndex[nm.Index]; ok && name != nm.Name {
+			breaks = append(breaks, fmt.Sprintf("method %s.%s takes index %d of %s", newSrv.Name, nm.Name, nm.Index, name))
+		}
+	}
+	return breaks
+}
+
+// compatEnums removed enum or value, value number changed
+func compatEnums(oldEnums, newEnums []*EnumNode) []string {
+	var breaks []string
+	newByName := make(map[string]*EnumNode, len(newEnums))
+	for _, e := range newEnums {
+		newByName[e.Name] = e
+	}
+	for _, oe := range oldEnums {
+		ne, ok := newByName[oe.Name]
+		if !ok {
+			breaks = append(breaks, fmt.Sprintf("enum %s removed", oe.Name))
+			continue
+		}
+		values := make(map[string]int32, len(ne.Fields))
+		for _, f := range ne.Fields {
+			values[f.Name] = f.Value
+		}
+		for _, f := range oe.Fields {
+			v, ok := values[f.Name]
+			switch {
+			case !ok:
+				breaks = append(breaks, fmt.Sprintf("enum value %s.%s removed", oe.Name, f.Name))
+			case v != f.Value:
+				breaks = append(breaks, fmt.Sprintf("enum value %s.%s changed %d -> %d", oe.Name, f.Name, f.Value, v))
+			}
+		}
+	}
+	return breaks
+}
+
+// realArgs arguments without void placeholder
+func realArgs(m *MethodNode) []*ArgNode {
+	args := make([]*ArgNode, 0, len(m.Arguments))
+	for _, arg := range m.Arguments {
+		if arg.IdlType != "void" {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// argTypeName idl spelling of type, argument name is not part of it
+func argTypeName(v *ArgNode) string {
+	if v == nil {
+		return "void"
+	}
+	switch v.IdlType {
+	case "seq", "set":
+		return fmt.Sprintf("%s<%s>", v.IdlType, complexTypeName(v.Key))
+	case "dict", "map":
+		return fmt.Sprintf("dict<%s,%s>", complexTypeName(v.Key), complexTypeName(v.Value))
+	}
+	if v.IsStruct || v.IsEnum {
+		return v.GoType
+	}
+	return v.IdlType
+}
+
+func complexTypeName(c *ComplexNode) string {
+	if c == nil {
+		return "void"
+	}
+	if c.IsStruct {
+		return c.GoType
+	}
+	return c.IdlType
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+const (
+	//@title idl 异常文件生成模板, 异常类型通过 ExceptionCode ExceptionName 与框架 RpcError 互相匹配
+	exletter = `// Generated by the go idl tools. DO NOT EDIT {{idltime}}
+// source: {{.IdlName}}
+
+package idldata
+
+// IException exception carrying code across rpc call, same as rpc-go-backend errors.IException
+type IException interface {
+	error
+	ExceptionCode() uint32
+	ExceptionName() string
+}
+
+{{range .Exceptions}}
+{{- $en := stfieldup .Name}}
+// {{$en}}Code code of idl exception {{.Name}}
+const {{$en}}Code uint32 = {{.Code}}
+
+// {{$en}} idl exception {{.Name}}, return it from service method,
+// caller can check the returned error with errors.Is(err, &idldata.{{$en}}{})
+type {{$en}} struct {
+	Detail string
+}
+
+func New{{$en}}(detail string) *{{$en}} {
+	return &{{$en}}{Detail: detail}
+}
+
+func (e *{{$en}}) Error() string {
+	return "{{.Name}}: " + e.Detail
+}
+
+func (e *{{$en}}) ExceptionCode() uint32 {
+	return {{$en}}Code
+}
+
+func (e *{{$en}}) ExceptionName() string {
+	return "{{.Name}}"
+}
+
+func (e *{{$en}}) Is(target error) bool {
+	ex, ok := target.(IException)
+	return ok && ex.ExceptionCode() == {{$en}}Code && ex.ExceptionName() == "{{.Name}}"
+}
+{{end}}
+`
+)
+
+var (
+	idlextp *template.Template
+)
+
+func init() {
+	temp, err := template.New("exception").Funcs(funcsMap).Parse(exletter)
+	if err != nil {
+		panic(fmt.Sprintf("init exception letter error %v !!!!", err))
+	}
+	idlextp = temp
+}
+
+// GenGoException 生成idl 异常类型, 在 idldata 目录中调用
+func GenGoException(idlname string, exceptions []*ExceptionNode) error {
+	if idlextp == nil {
+		return errors.New("idl exception template is nil !!!!")
+	}
+
+	type ExGen struct {
+		IdlName    string
+		Exceptions []*ExceptionNode
+	}
+
+	filename := strings.ToLower(idlname) + ".exception.go"
+	if FileExits(filename) {
+		//存在的话删了重新生成
+		err := os.Remove(filename)
+		if err != nil {
+			fmt.Printf("delete %s idl exception file  error %v !!! \n", filename, err)
+			return err
+		}
+	}
+
+	filehandle, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE, 0765)
+	if err != nil {
+		fmt.Printf("open %s idl exception file error %v !!!!", filename, err)
+		return err
+	}
+	defer filehandle.Close()
+
+	return idlextp.Execute(filehandle, &ExGen{idlname, exceptions})
+}
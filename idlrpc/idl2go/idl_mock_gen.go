@@ -0,0 +1,329 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+//@title gen service mock
+//@desc 生成服务接口 mock 和 proxy fake, 单元测试不需要启动框架和传输层
+
+const (
+	//参数列表, 不带 context
+	mockargsletter = `{{- define "mockargs"}}
+{{- range $index,$elem := .Arguments}}
+{{- if ne $elem.GoType "void" }}
+{{- if $index }}{{print ", "}}{{end}}
+{{- "_"}}{{$elem.Index}}{{- block "typelet" $elem}}{{end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- define "mockvals"}}
+{{- range $index,$elem := .Arguments}}
+{{- if ne $elem.GoType "void" }}, _{{$elem.Index}}{{end}}
+{{- end}}
+{{- end}}
+{{- define "mockret"}}({{if hasret .}}ret1{{block "typelet" .RetType}}{{end}}, {{end}}err error){{end}}`
+
+	mockletter = `// Generated by the go idl tools. DO NOT EDIT {{idltime}}
+// source: {{.Service.Name}}
+
+{{- $idln := .Idlname}}
+{{- $sn := .Service.Name}}
+
+package {{tolower .Service.Name}}
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"{{$idln}}/idldata"
+)
+
+// Mock{{$sn}}Call call recorded by Mock{{$sn}}, Args are method arguments without ctx
+type Mock{{$sn}}Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Mock{{$sn}} mock of I{{$sn}}, set <Method>Func to stub method, Expect<Method> to expect calls,
+// CallsOf to check arguments of calls
+type Mock{{$sn}} struct {
+	mu      sync.Mutex
+	calls   []Mock{{$sn}}Call
+	expects map[string]int
+{{- range .Service.Methods}}
+	{{stfieldup .Name}}Func func(context.Context
+{{- range .Arguments}}{{if ne .GoType "void" }},{{block "typelet" .}}{{end}}{{end}}{{end}}) {{if hasret .}}({{block "typelet" .RetType}}{{end}}, error){{else}}error{{end}}
+{{- end}}
+}
+
+func NewMock{{$sn}}() *Mock{{$sn}} {
+	return &Mock{{$sn}}{
+		expects: make(map[string]int),
+	}
+}
+
+func (m *Mock{{$sn}}) GetUUID() uint64 {
+	return SrvUUID
+}
+
+func (m *Mock{{$sn}}) GetNickName() string {
+	return SrvName
+}
+
+func (m *Mock{{$sn}}) OnAfterFork(ctx context.Context) bool {
+	return true
+}
+
+func (m *Mock{{$sn}}) OnTick() bool {
+	return true
+}
+
+func (m *Mock{{$sn}}) OnBeforeDestroy() bool {
+	return true
+}
+
+// Calls times method called
+func (m *Mock{{$sn}}) Calls(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.count(method)
+}
+
+// CallsOf recorded calls of method in order
+func (m *Mock{{$sn}}) CallsOf(method string) []Mock{{$sn}}Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var calls []Mock{{$sn}}Call
+	for _, call := range m.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (m *Mock{{$sn}}) count(method string) int {
+	n := 0
+	for _, call := range m.calls {
+		if call.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+// Verify check expected calls, return error of all unmet expectations
+func (m *Mock{{$sn}}) Verify() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var unmet []string
+	for method, times := range m.expects {
+		if called := m.count(method); called != times {
+			unmet = append(unmet, fmt.Sprintf("%s called %d times, expect %d", method, called, times))
+		}
+	}
+	if len(unmet) == 0 {
+		return nil
+	}
+	sort.Strings(unmet)
+	return fmt.Errorf("{{$sn}} mock: %s", strings.Join(unmet, "; "))
+}
+
+func (m *Mock{{$sn}}) expect(method string, times int) *Mock{{$sn}} {
+	m.mu.Lock()
+	m.expects[method] = times
+	m.mu.Unlock()
+	return m
+}
+
+func (m *Mock{{$sn}}) called(method string, args ...interface{}) {
+	m.mu.Lock()
+	m.calls = append(m.calls, Mock{{$sn}}Call{Method: method, Args: args})
+	m.mu.Unlock()
+}
+{{range .Service.Methods}}
+{{- $mn := stfieldup .Name}}
+// Expect{{$mn}} expect {{$mn}} called times
+func (m *Mock{{$sn}}) Expect{{$mn}}(times int) *Mock{{$sn}} {
+	return m.expect("{{$mn}}", times)
+}
+
+func (m *Mock{{$sn}}) {{$mn}}(ctx context.Context{{range .Arguments}}{{if ne .GoType "void" }}, _{{.Index}}{{block "typelet" .}}{{end}}{{end}}{{end}}) {{template "mockret" .}} {
+	m.called("{{$mn}}"{{template "mockvals" .}})
+	if m.{{$mn}}Func != nil {
+		return m.{{$mn}}Func(ctx{{template "mockvals" .}})
+	}
+	return
+}
+{{end}}
+`
+
+	fakeletter = `// Generated by the go idl tools. DO NOT EDIT {{idltime}}
+// source: {{.Service.Name}}
+
+{{- $idln := .Idlname}}
+{{- $sn := .Service.Name}}
+
+package {{tolower .Service.Name}}
+
+import (
+{{- if .CtxFirst}}
+	"context"
+{{- end}}
+	"sync"
+
+	"{{$idln}}/idldata"
+)
+
+// {{$sn}}Caller methods of {{$sn}}Proxy, depend on it to swap in Fake{{$sn}}Proxy in tests
+type {{$sn}}Caller interface {
+	GetUUID() uint64
+	GetSrvName() string
+{{- range .Service.Methods}}
+	{{stfieldup .Name}}({{if $.CtxFirst}}ctx context.Context{{if hasargs .}}, {{end}}{{end}}{{template "mockargs" .}}) {{template "mockret" .}}
+{{- end}}
+}
+
+var _ {{$sn}}Caller = (*{{$sn}}Proxy)(nil)
+
+// Fake{{$sn}}Call call recorded by Fake{{$sn}}Proxy
+type Fake{{$sn}}Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Fake{{$sn}}Proxy fake {{$sn}}Proxy, records calls and returns canned results without rpc framework
+type Fake{{$sn}}Proxy struct {
+	mu    sync.Mutex
+	calls []Fake{{$sn}}Call
+{{- range .Service.Methods}}
+{{- $mn := stfieldup .Name}}
+{{- if hasret .}}
+	{{$mn}}Ret{{block "typelet" .RetType}}{{end}}
+{{- end}}
+	{{$mn}}Err error
+{{- end}}
+}
+
+func NewFake{{$sn}}Proxy() *Fake{{$sn}}Proxy {
+	return &Fake{{$sn}}Proxy{}
+}
+
+func (f *Fake{{$sn}}Proxy) GetUUID() uint64 {
+	return SrvUUID
+}
+
+func (f *Fake{{$sn}}Proxy) GetSrvName() string {
+	return SrvName
+}
+
+// Calls recorded calls in order
+func (f *Fake{{$sn}}Proxy) Calls() []Fake{{$sn}}Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Fake{{$sn}}Call(nil), f.calls...)
+}
+
+// CallsOf recorded calls of method in order
+func (f *Fake{{$sn}}Proxy) CallsOf(method string) []Fake{{$sn}}Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []Fake{{$sn}}Call
+	for _, call := range f.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset clear recorded calls, canned results are kept
+func (f *Fake{{$sn}}Proxy) Reset() {
+	f.mu.Lock()
+	f.calls = nil
+	f.mu.Unlock()
+}
+
+func (f *Fake{{$sn}}Proxy) record(method string, args ...interface{}) {
+	f.calls = append(f.calls, Fake{{$sn}}Call{Method: method, Args: args})
+}
+{{range .Service.Methods}}
+{{- $mn := stfieldup .Name}}
+func (f *Fake{{$sn}}Proxy) {{$mn}}({{if $.CtxFirst}}ctx context.Context{{if hasargs .}}, {{end}}{{end}}{{template "mockargs" .}}) {{template "mockret" .}} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("{{$mn}}"{{template "mockvals" .}})
+	return {{if hasret .}}f.{{$mn}}Ret, {{end}}f.{{$mn}}Err
+}
+{{end}}
+`
+)
+
+var (
+	mocktp *template.Template
+	faketp *template.Template
+)
+
+func init() {
+	mocktp = newMockTemplate("mock", mockletter)
+	faketp = newMockTemplate("fake", fakeletter)
+}
+
+func newMockTemplate(name, letter string) *template.Template {
+	tp := template.New(name).Funcs(funcsMap).Funcs(template.FuncMap{"hasret": hasRet})
+	tp = template.Must(tp.Parse(letter))
+	tp = template.Must(tp.Parse(mockargsletter))
+	return template.Must(tp.Parse(typeletter))
+}
+
+// hasRet method has return value
+func hasRet(method *MethodNode) bool {
+	return method.RetType != nil && method.RetType.GoType != "void"
+}
+
+// hasArgs method has arguments besides void placeholder
+func hasArgs(method *MethodNode) bool {
+	return len(realArgs(method)) > 0
+}
+
+// GenMockFile 生成服务接口 mock 文件和 proxy fake 文件
+func GenMockFile(idlname string, gen *Gen) error {
+	if gen == nil {
+		return errors.New("gen idl name info error !!!!")
+	}
+
+	idlpackagename = "idldata"
+	defer func() {
+		idlpackagename = ""
+	}()
+
+	for suffix, tp := range map[string]*template.Template{"_mock.go": mocktp, "_fake.go": faketp} {
+		//检测文件删了重写
+		ifile := strings.ToLower(gen.Name) + suffix
+		if FileExits(ifile) {
+			if err := os.Remove(ifile); err != nil {
+				fmt.Printf("delete %s mock file error %v !!! \n", gen.Name, err)
+				return err
+			}
+		}
+		filehanle, err := os.OpenFile(ifile, os.O_WRONLY|os.O_CREATE, 0765)
+		if err != nil {
+			return err
+		}
+		err = tp.Execute(filehanle, gen)
+		filehanle.Close()
+		if err != nil {
+			fmt.Printf("generate mock file error %v\n", err)
+			return err
+		}
+	}
+	return nil
+}
@@ -17,6 +17,9 @@ const (
 package {{tolower .Service.Name}}
 
 import (
+{{- if .CtxFirst}}
+	"context"
+{{- end}}
 	"fmt"
 
 	"{{$idln}}/idldata"
@@ -82,7 +85,7 @@ func (sp *{{.Service.Name}}Proxy) IsOneWay(methodid uint32) (isoneway bool){
 
 {{- $sn := .Service.Name}}
 {{- range .Service.Methods}}
-func(sp *{{$sn}}Proxy){{stfieldup .Name}}({{- range $index,$elem := .Arguments}}
+func(sp *{{$sn}}Proxy){{stfieldup .Name}}({{if $.CtxFirst}}ctx context.Context{{if hasargs .}}, {{end}}{{end}}{{- range $index,$elem := .Arguments}}
 {{- if $index }}{{print ", "}}{{end}} 
 {{- if ne $elem.GoType "void" }}
 {{- "_"}}{{$elem.Index}}{{- block "typelet" $elem}}{{end}}
@@ -149,11 +152,11 @@ func(sp *{{$sn}}Proxy){{stfieldup .Name}}({{- range $index,$elem := .Arguments}}
 	{{- end}}
 
 	{{- if eq .RetType.IdlType "void"}}
-		_, err = rpc.Call(sp, {{.Index}}, {{.TimeOut}}, {{.Retry}}, pbarg)
+		_, err = {{if $.CtxFirst}}idlrpc.CallContext(ctx, rpc, sp{{else}}rpc.Call(sp{{end}}, {{.Index}}, {{.TimeOut}}, {{.Retry}}, pbarg)
 	{{- else}}
-		respMsg, err := rpc.Call(sp, {{.Index}}, {{.TimeOut}}, {{.Retry}}, pbarg)
+		respMsg, err := {{if $.CtxFirst}}idlrpc.CallContext(ctx, rpc, sp{{else}}rpc.Call(sp{{end}}, {{.Index}}, {{.TimeOut}}, {{.Retry}}, pbarg)
 	{{- end}}
-	if err != nil && err != errors.ErrRpcRet {
+	if err != nil && !errors.Is(err, errors.ErrRpcRet) {
 		return
 	}
 	
@@ -173,13 +176,16 @@ func(sp *{{$sn}}Proxy){{stfieldup .Name}}({{- range $index,$elem := .Arguments}}
 	if pbret.Ctx != nil {
 		pbCtxInfo = pbCtxInfoToMap(pbret.Ctx.Info)
 	}
-	if err == errors.ErrRpcRet {
+	if errors.Is(err, errors.ErrRpcRet) {
 		trace, ok := pbCtxInfo["call_trace"]
 		errmsg := pbCtxInfo["error_info"]
 		if ok && sp.GetRpc().Options().CallTrace() {
 			panic(fmt.Sprintf("call trace =====> RPC CALL PANIC: %s\n%s\n<=====", errmsg, trace))
 		}
-		err = fmt.Errorf("%w: %s", err, errmsg)
+		// old version remote, error message only in context
+		if err == errors.ErrRpcRet {
+			err = fmt.Errorf("%w: %s", err, errmsg)
+		}
 	}
 		{{- if eq .RetType.IdlType "i8" "i16" "ui8" "ui16"}}	
 	ret1 = {{.RetType.GoType}}(pbret.Ret1)
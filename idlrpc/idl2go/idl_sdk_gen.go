@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+//@title gen service sdk
+//@desc 生成服务 sdk, init 时注册到 idlrpc 包注册表, app 按配置选择托管或者只调用服务
+
+const (
+	sdkletter = `
+// Generated by the go idl tools. DO NOT EDIT {{idltime}}
+// source: {{.Service.Name}}
+package {{tolower .Service.Name}}
+
+import (
+	"gitee.com/dennis-kk/rpc-go-backend/idlrpc"
+)
+
+// {{.Service.Name}}SDK register {{.Service.Name}} creators, proxy sdk does not register stub creator
+type {{.Service.Name}}SDK struct {
+	proxy bool
+}
+
+// New{{.Service.Name}}SDK create sdk, args idlrpc.SdkProxy for proxy only sdk
+func New{{.Service.Name}}SDK(args ...string) (idlrpc.ISDK, error) {
+	return &{{.Service.Name}}SDK{proxy: idlrpc.IsProxyArgs(args)}, nil
+}
+
+func (sdk *{{.Service.Name}}SDK) GetUuid() uint64 {
+	return SrvUUID
+}
+
+func (sdk *{{.Service.Name}}SDK) GetNickName() string {
+	return SrvName
+}
+
+func (sdk *{{.Service.Name}}SDK) IsProxy() bool {
+	return sdk.proxy
+}
+
+func (sdk *{{.Service.Name}}SDK) Register(rpc idlrpc.IRpc) error {
+	if !sdk.proxy {
+		if err := rpc.AddStubCreator(SrvUUID, {{.Service.Name}}StubCreator); err != nil {
+			return err
+		}
+	}
+	return rpc.AddProxyCreator(SrvUUID, {{.Service.Name}}ProxyCreator)
+}
+
+func init() {
+	idlrpc.RegisterPackage(&idlrpc.PackageInfo{
+		ServiceUUID: SrvUUID,
+		ServiceName: SrvName,
+		Creator:     New{{.Service.Name}}SDK,
+	})
+}
+`
+)
+
+var (
+	sdktp *template.Template
+)
+
+func init() {
+	var err error
+	sdktp, err = template.New("sdk").Funcs(funcsMap).Parse(sdkletter)
+	if err != nil {
+		panic(fmt.Sprintf("init sdk letter error %v !!!!", err))
+	}
+}
+
+// GenSdkFile 生成服务 sdk 文件
+func GenSdkFile(idlname string, gen *Gen) error {
+	if gen == nil {
+		return errors.New("gen idl name info error !!!!")
+	}
+
+	//检测文件删了重写
+	ifile := strings.ToLower(gen.Name) + "_sdk.go"
+	if FileExits(ifile) {
+		if err := os.Remove(ifile); err != nil {
+			fmt.Printf("delete %s sdk file error %v !!! \n", gen.Name, err)
+			return err
+		}
+	}
+
+	filehanle, err := os.OpenFile(ifile, os.O_WRONLY|os.O_CREATE, 0765)
+	if err != nil {
+		return err
+	}
+	defer filehanle.Close()
+
+	if err = sdktp.Execute(filehanle, gen); err != nil {
+		fmt.Printf("generate sdk file error %v\n", err)
+		return err
+	}
+	return nil
+}
@@ -120,6 +120,32 @@ func (sb *{{.Service.Name}}Stub)IsOneWay(methodid uint32)(isoneway bool) {
 }
 
 {{- $sn := .Service.Name}}
+{{- if .Service.IsKeyed}}
+
+func (sb *{{.Service.Name}}Stub) GetDispatchKey(methodId uint32, req []byte) (key uint64, ok bool) {
+	switch methodId {
+	{{- range $m := .Service.Methods}}
+	{{- with $m.DispatchArg}}
+	case {{$m.Index}}:
+		{{- if isupper $m.Name }}
+		pbreq := &pbdata.{{$sn}}_{{stfieldup $m.Name}}Args{}
+		{{- else}}
+		pbreq := &pbdata.{{$sn}}{{stfieldup $m.Name}}Args{}
+		{{- end}}
+		if proto.Unmarshal(req, pbreq) != nil {
+			return
+		}
+		{{- if eq .IdlType "string"}}
+		key, ok = idlrpc.StringDispatchKey(pbreq.Arg{{.Index}}), true
+		{{- else}}
+		key, ok = uint64(pbreq.Arg{{.Index}}), true
+		{{- end}}
+	{{- end}}
+	{{- end}}
+	}
+	return
+}
+{{- end}}
 {{range .Service.Methods}}
 {{- $fcn := stfieldup .Name}}
 func(sb *{{$sn}}Stub) {{$fcn}}(ctx context.Context, req []byte) (resp []byte, err error){
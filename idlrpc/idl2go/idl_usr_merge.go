@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 用户实现文件增量更新: 已存在的 usr/*_impl.go 不再删除重写,
+// 只追加新增方法, 被 idl 删除的方法标记为 Deprecated, 签名和 idl 不一致的方法标记出来由用户修改,
+// 用户代码和 import 保持不变
+
+// UsrMerge result of merging generated user impl into existing file
+type UsrMerge struct {
+	Code       []byte   // merged source
+	Added      []string // methods added from idl
+	Deprecated []string // methods removed from idl, marked deprecated
+	Mismatched []string // methods whose signature differs from idl, marked for user to fix
+}
+
+// usrEdit text inserted at offset of existing file
+type usrEdit struct {
+	offset int
+	text   string
+}
+
+// MergeUsrFile merge generated impl into existing user file. methods are matched by name on impl type,
+// then parameter and result types are compared, user bodies are never touched.
+// method of existing file is treated as idl method while it is exported,
+// takes context.Context first and returns error last
+func MergeUsrFile(existing, generated []byte) (*UsrMerge, error) {
+	fset := token.NewFileSet()
+	genFile, err := parser.ParseFile(fset, "generated.go", generated, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parse generated impl error %v", err)
+	}
+	oldFile, err := parser.ParseFile(fset, "existing.go", existing, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parse user impl error %v", err)
+	}
+
+	implType := ""
+	genMethods := make(map[string]*ast.FuncDecl)
+	for _, decl := range genFile.Decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv != nil {
+			implType = recvTypeName(fd)
+			genMethods[fd.Name.Name] = fd
+		}
+	}
+	oldMethods := make(map[string]bool)
+	for _, decl := range oldFile.Decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv != nil && recvTypeName(fd) == implType {
+			oldMethods[fd.Name.Name] = true
+		}
+	}
+
+	merge := &UsrMerge{}
+	var edits []usrEdit
+	// new methods are appended in idl order
+	var appended strings.Builder
+	used := make(map[string]bool)
+	for _, decl := range genFile.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Recv == nil || oldMethods[fd.Name.Name] {
+			continue
+		}
+		start := fd.Pos()
+		if fd.Doc != nil {
+			start = fd.Doc.Pos()
+		}
+		appended.WriteString("\n")
+		appended.Write(generated[fset.Position(start).Offset:fset.Position(fd.End()).Offset])
+		appended.WriteString("\n")
+		merge.Added = append(merge.Added, fd.Name.Name)
+		usedPackages(fd, used)
+	}
+	if appended.Len() > 0 {
+		edits = append(edits, usrEdit{len(existing), appended.String()})
+	}
+
+	// removed idl methods are kept for user code, marked deprecated
+	for _, decl := range oldFile.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Recv == nil || recvTypeName(fd) != implType || !isRpcMethod(fd) {
+			continue
+		}
+		if genFd, ok := genMethods[fd.Name.Name]; ok {
+			// same name, user must change signature by hand
+			want := funcTypeString(fset, genFd.Type)
+			if funcTypeString(fset, fd.Type) == want {
+				continue
+			}
+			note := fmt.Sprintf("// Mismatch: %s signature differs from idl, idl declares func%s\n", fd.Name.Name, want)
+			if fd.Doc != nil && strings.Contains(fd.Doc.Text(), strings.TrimPrefix(strings.TrimSpace(note), "// ")) {
+				merge.Mismatched = append(merge.Mismatched, fd.Name.Name)
+				continue
+			}
+			if fd.Doc != nil {
+				note = "//\n" + note
+			}
+			edits = append(edits, usrEdit{fset.Position(fd.Pos()).Offset, note})
+			merge.Mismatched = append(merge.Mismatched, fd.Name.Name)
+			continue
+		}
+		if fd.Doc != nil && strings.Contains(fd.Doc.Text(), "Deprecated:") {
+			continue
+		}
+		note := fmt.Sprintf("// Deprecated: %s is removed from idl, delete it after all callers are updated.\n", fd.Name.Name)
+		if fd.Doc != nil {
+			note = "//\n" + note
+		}
+		edits = append(edits, usrEdit{fset.Position(fd.Pos()).Offset, note})
+		merge.Deprecated = append(merge.Deprecated, fd.Name.Name)
+	}
+
+	// imports needed by new methods, put into import block of user file if it has one
+	if imports := missingImports(genFile, oldFile, used); len(imports) > 0 {
+		offset, block := fset.Position(oldFile.Name.End()).Offset, false
+		for _, decl := range oldFile.Decls {
+			if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+				if gd.Lparen.IsValid() {
+					offset, block = fset.Position(gd.Rparen).Offset, true
+					break
+				}
+				offset = fset.Position(gd.End()).Offset
+			}
+		}
+		text := "\n\t" + strings.Join(imports, "\n\t") + "\n"
+		if !block {
+			text = "\nimport (" + text + ")"
+		}
+		edits = append(edits, usrEdit{offset, text})
+	}
+
+	// apply from the end, offsets of earlier edits stay valid
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].offset > edits[j].offset
+	})
+	code := append([]byte(nil), existing...)
+	for _, e := range edits {
+		code = append(code[:e.offset], append([]byte(e.text), code[e.offset:]...)...)
+	}
+	if merge.Code, err = format.Source(code); err != nil {
+		return nil, fmt.Errorf("format merged impl error %v", err)
+	}
+	return merge, nil
+}
+
+// recvTypeName receiver type name without pointer
+func recvTypeName(fd *ast.FuncDecl) string {
+	if len(fd.Recv.List) == 0 {
+		return ""
+	}
+	expr := fd.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// isRpcMethod shape of generated idl method, func (sp *Impl) Name(ctx context.Context, ...) (..., err error)
+func isRpcMethod(fd *ast.FuncDecl) bool {
+	if !fd.Name.IsExported() || fd.Type.Params == nil || len(fd.Type.Params.List) == 0 || fd.Type.Results == nil {
+		return false
+	}
+	sel, ok := fd.Type.Params.List[0].Type.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Context" {
+		return false
+	}
+	results := fd.Type.Results.List
+	last, ok := results[len(results)-1].Type.(*ast.Ident)
+	return ok && last.Name == "error"
+}
+
+// funcTypeString parameter and result types of method, names are ignored
+func funcTypeString(fset *token.FileSet, ft *ast.FuncType) string {
+	fields := func(list *ast.FieldList) string {
+		if list == nil {
+			return "()"
+		}
+		var types []string
+		for _, field := range list.List {
+			var buf bytes.Buffer
+			_ = format.Node(&buf, fset, field.Type)
+			for n := 0; n == 0 || n < len(field.Names); n++ {
+				types = append(types, buf.String())
+			}
+		}
+		return "(" + strings.Join(types, ", ") + ")"
+	}
+	return fields(ft.Params) + " " + fields(ft.Results)
+}
+
+// usedPackages package names referred by fd
+func usedPackages(fd *ast.FuncDecl, used map[string]bool) {
+	ast.Inspect(fd, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+}
+
+// missingImports import specs of generated file used by new methods but not imported by user file
+func missingImports(genFile, oldFile *ast.File, used map[string]bool) []string {
+	have := make(map[string]bool)
+	for _, spec := range oldFile.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		have[p] = true
+	}
+	var specs []string
+	for _, spec := range genFile.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if have[p] || !used[name] {
+			continue
+		}
+		if spec.Name != nil {
+			specs = append(specs, spec.Name.Name+" "+spec.Path.Value)
+		} else {
+			specs = append(specs, spec.Path.Value)
+		}
+	}
+	return specs
+}
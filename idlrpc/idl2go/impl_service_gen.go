@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"go/format"
+	"io/ioutil"
 	"os"
 	"strings"
 	"sync"
@@ -116,30 +118,32 @@ func GenClientImpl(idlname string, gen *Gen) error {
 		idlpackagename = ""
 	}()
 
-	//检测文件删了重写
+	//已有用户文件增量合并, 不覆盖用户代码
 	ifile := strings.ToLower(gen.Name) + "_impl.go"
+	code, err := RenderClientImpl(gen)
+	if err != nil {
+		fmt.Printf("generate client impl file error %v\n", err)
+		return err
+	}
 	if FileExits(ifile) {
-		//存在的话删了重新生成
-		err := os.Remove(ifile)
+		existing, err := ioutil.ReadFile(ifile)
 		if err != nil {
-			fmt.Printf("delete %s impl file error %v !!! \n", gen.Name, err)
 			return err
 		}
+		merge, err := MergeUsrFile(existing, code)
+		if err != nil {
+			fmt.Printf("merge %s impl file error %v, file is kept !!! \n", gen.Name, err)
+			return err
+		}
+		if len(merge.Added) != 0 || len(merge.Deprecated) != 0 {
+			fmt.Printf("merge %s impl file, add %v deprecate %v \n", gen.Name, merge.Added, merge.Deprecated)
+		}
+		if len(merge.Mismatched) != 0 {
+			fmt.Printf("%s impl methods %v differ from idl, fix their signature !!! \n", gen.Name, merge.Mismatched)
+		}
+		code = merge.Code
 	}
-
-	//打开文件
-	filehanle, err := os.OpenFile(ifile, os.O_WRONLY|os.O_CREATE, 0765)
-	if err != nil {
-		return err
-	}
-
-	defer func() {
-		filehanle.Close()
-	}()
-
-	err = clienttp.Execute(filehanle, gen)
-	if err != nil {
-		fmt.Printf("generate client impl file error %v\n", err)
+	if err = ioutil.WriteFile(ifile, code, 0765); err != nil {
 		return err
 	}
 
@@ -150,6 +154,15 @@ func GenClientImpl(idlname string, gen *Gen) error {
 	return nil
 }
 
+// RenderClientImpl formatted user impl file of service
+func RenderClientImpl(gen *Gen) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := clienttp.Execute(buf, gen); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
 func GenClientImplMod(gen *Gen) error {
 	filename := "go.mod"
 	if FileExits(filename) {
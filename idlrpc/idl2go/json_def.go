@@ -3,6 +3,8 @@ package main
 import (
 	"errors"
 	"fmt"
+
+	rpcerrors "github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
 )
 
 //@title set dict 这种复合结构的key value
@@ -45,6 +47,8 @@ type MethodNode struct {
 	RetType   *ArgNode   `json:"retType"` //只有在oneway的時候有才没有返回值 不做类型检查
 	Retry     uint32     `json:"retry"`
 	TimeOut   uint32     `json:"timeout"`
+	//调度key注解, 参数名称, 相同key的调用在同一个worker上顺序执行
+	DispatchKey string `json:"dispatchKey"`
 }
 
 type ServiceNode struct {
@@ -68,20 +72,27 @@ type EnumNode struct {
 	Fields []*EnumFieldNode `json:"fields"`
 }
 
+// ExceptionNode idl 异常声明, 生成go error 类型
+type ExceptionNode struct {
+	Name string `json:"name"`
+	Code uint32 `json:"code"`
+}
+
 type StructNode struct {
 	Name   string       `json:"name"`
 	Fields []*FieldNode `json:"fields"`
 }
 
 type IdlJsonNode struct {
-	ServiceNames []string       `json:serviceNames`
-	Services     []*ServiceNode `json:"services"`
-	StructNames  []string       `json:"structNames"`
-	Structs      []*StructNode  `json:"structs"`
-	EnumNames    []string       `json:"enumNames"`
-	Enums        []*EnumNode    `json:"enums"`
-	MaxInst      uint32         `json:"maxInst"`
-	IdlName      string         `json:"idlname"`
+	ServiceNames []string         `json:"serviceNames"`
+	Services     []*ServiceNode   `json:"services"`
+	StructNames  []string         `json:"structNames"`
+	Structs      []*StructNode    `json:"structs"`
+	EnumNames    []string         `json:"enumNames"`
+	Enums        []*EnumNode      `json:"enums"`
+	Exceptions   []*ExceptionNode `json:"exceptions"`
+	MaxInst      uint32           `json:"maxInst"`
+	IdlName      string           `json:"idlname"`
 }
 
 func (ij *IdlJsonNode) IsValid() bool {
@@ -92,6 +103,28 @@ func (ij *IdlJsonNode) IsValid() bool {
 	if len(ij.ServiceNames) != len(ij.Services) {
 		return false
 	}
+
+	codes := make(map[uint32]string)
+	for _, ex := range ij.Exceptions {
+		if ex.Name == "" || ex.Code < uint32(rpcerrors.ExceptionCodeMin) {
+			fmt.Printf("exception %q must have name and code not less than %d \n", ex.Name, rpcerrors.ExceptionCodeMin)
+			return false
+		}
+		if other, ok := codes[ex.Code]; ok {
+			fmt.Printf("exception %s and %s have same code %d \n", ex.Name, other, ex.Code)
+			return false
+		}
+		codes[ex.Code] = ex.Name
+	}
+
+	for _, srv := range ij.Services {
+		for _, m := range srv.Methods {
+			if m.DispatchKey != "" && m.DispatchArg() == nil {
+				fmt.Printf("service %s method %s dispatch key %s is not a integer or string argument \n", srv.Name, m.Name, m.DispatchKey)
+				return false
+			}
+		}
+	}
 	//添加自己的检查代码
 	return true
 }
@@ -101,6 +134,16 @@ func (s *ServiceNode) String() string {
 	return fmt.Sprintf("Uuid %s type %s max %d Name %s srvType %s \n method %v \n", s.Uuid, s.LoadType, s.MaxInst, s.Name, s.SrvType, s.Methods)
 }
 
+// IsKeyed service has methods with dispatch key annotation
+func (s *ServiceNode) IsKeyed() bool {
+	for _, m := range s.Methods {
+		if m.DispatchKey != "" {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *MethodNode) String() string {
 	return fmt.Sprintf("Index %d Name %s excpet %t oneway %t args %v", m.Index, m.Name, m.Noexcept, m.IsOneway, m.Arguments)
 }
@@ -148,3 +191,24 @@ func (m *MethodNode) IsValid() error {
 
 	return nil
 }
+
+// DispatchArg argument named by dispatch key annotation, only integer, enum and string types can be key
+func (m *MethodNode) DispatchArg() *ArgNode {
+	if m.DispatchKey == "" {
+		return nil
+	}
+	for _, arg := range m.Arguments {
+		if arg.DeclName != m.DispatchKey {
+			continue
+		}
+		switch arg.IdlType {
+		case "i8", "i16", "i32", "i64", "ui8", "ui16", "ui32", "ui64", "string":
+			return arg
+		}
+		if arg.IsEnum {
+			return arg
+		}
+		return nil
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ var (
 		"tolower":    strings.ToLower,
 		"isupper":    StartWithUppercase,
 		"pbfiled":    DealPbStructField,
+		"hasargs":    hasArgs,
 	}
 )
 
@@ -20,6 +20,8 @@ var IdlDir string        //idl文件搜索根路径
 var usrDir string        //用户路径
 var updateService string //指定更新的服务名称
 var gVersion string      //版本号
+var dryRun bool          //只打印用户实现文件的改动, 不写文件
+var ctxFirst bool        //生成 context 优先的 proxy 方法
 var gInputFile ImputFiles
 
 //impl Value Interface
@@ -39,7 +41,7 @@ func printHelp() {
 		bin = bin[i+1:]
 	}
 
-	fmt.Printf("Usage %s -I example.idl -O impl/goservice", bin)
+	fmt.Printf("Usage %s -I example.idl -O impl/goservice\n      %s compat old.idl.go.json new.idl.go.json\n", bin, bin)
 	flag.PrintDefaults()
 }
 func main() {
@@ -53,10 +55,17 @@ func main() {
 	flag.StringVar(&updateService, "service", "", "Specify a service")
 	flag.StringVar(&ProtocExec, "proto_dir", "protoc", "set protoc exec dir")
 	flag.StringVar(&gVersion, "ver", "v0.3.3", "rpc-backend-go version")
+	flag.BoolVar(&dryRun, "dry-run", false, "print diff of usr impl files instead of generating, nothing is written")
+	flag.BoolVar(&ctxFirst, "ctx", false, "generate proxy and fake methods taking context.Context first, deadline, trace id and metadata are passed to service")
 
 	//flag.StringVar(&InputFile, "input", "", "set input file ")
 	flag.Parse()
 
+	// idl2go compat old.json new.json, 检查新版本 idl 是否兼容老版本
+	if flag.Arg(0) == "compat" {
+		os.Exit(runCompat(flag.Args()[1:]))
+	}
+
 	dir, err := os.Getwd()
 	if err != nil {
 		fmt.Print(err)
@@ -99,7 +108,12 @@ func main() {
 	for _, v := range gInputFile {
 		basename := filepath.Base(v)
 		fmt.Printf("load idl json %s/%s \n", IdlDir, basename)
-		if err = GenGoIdleService(IdlDir, basename); err != nil {
+		if dryRun {
+			err = DryRunIdl(IdlDir, basename)
+		} else {
+			err = GenGoIdleService(IdlDir, basename)
+		}
+		if err != nil {
 			fmt.Printf("gen idl %s file error  %s !!!\n ", v, err.Error())
 			os.Exit(-1)
 		}
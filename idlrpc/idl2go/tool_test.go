@@ -1,6 +1,13 @@
 package main
 
-import "testing"
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
 
 func TestDealPbStructField(t *testing.T) {
 	idlCases := []struct {
@@ -22,3 +29,329 @@ func TestDealPbStructField(t *testing.T) {
 		}
 	}
 }
+
+func TestStubDispatchKey(t *testing.T) {
+	gen := &Gen{
+		Name:    "Player",
+		Idlname: "game",
+		Service: &ServiceNode{
+			Name: "Player",
+			Uuid: "1001",
+			Methods: []*MethodNode{
+				{
+					Name:        "login",
+					Index:       1,
+					DispatchKey: "account",
+					Arguments: []*ArgNode{
+						{IdlType: "string", GoType: "string", Index: 1, DeclName: "account"},
+					},
+					RetType: &ArgNode{IdlType: "void", GoType: "void"},
+				},
+				{
+					Name:        "move",
+					Index:       2,
+					DispatchKey: "pid",
+					Arguments: []*ArgNode{
+						{IdlType: "ui64", GoType: "uint64", Index: 1, DeclName: "pid"},
+						{IdlType: "i32", GoType: "int32", Index: 2, DeclName: "x"},
+					},
+					RetType: &ArgNode{IdlType: "void", GoType: "void"},
+				},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := stubtp.Execute(buf, gen); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "player_stub.go", buf.Bytes(), 0); err != nil {
+		t.Fatalf("generated stub is invalid: %v\n%s", err, buf.String())
+	}
+	code := buf.String()
+	for _, want := range []string{
+		"func (sb *PlayerStub) GetDispatchKey(methodId uint32, req []byte)",
+		"key, ok = idlrpc.StringDispatchKey(pbreq.Arg1), true",
+		"key, ok = uint64(pbreq.Arg1), true",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated stub missing %q", want)
+		}
+	}
+}
+
+func TestGenSdk(t *testing.T) {
+	gen := &Gen{Name: "Player", Idlname: "game", Service: &ServiceNode{Name: "Player", Uuid: "1001"}}
+	buf := &bytes.Buffer{}
+	if err := sdktp.Execute(buf, gen); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "player_sdk.go", buf.Bytes(), 0); err != nil {
+		t.Fatalf("generated sdk is invalid: %v\n%s", err, buf.String())
+	}
+	for _, want := range []string{
+		"func NewPlayerSDK(args ...string) (idlrpc.ISDK, error)",
+		"rpc.AddStubCreator(SrvUUID, PlayerStubCreator)",
+		"Creator:     NewPlayerSDK,",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("generated sdk missing %q", want)
+		}
+	}
+}
+
+// proxy depends on framework packages, its ctx first signatures are checked on source,
+// generated fake is run in TestGenContextFirstFake
+func TestGenContextFirst(t *testing.T) {
+	idlpackagename = "idldata"
+	defer func() {
+		idlpackagename = ""
+	}()
+	gen := &Gen{Name: "Player", Idlname: "game", CtxFirst: true, Service: &ServiceNode{Name: "Player", Uuid: "1001", Methods: []*MethodNode{
+		{
+			Name:      "login",
+			Index:     1,
+			TimeOut:   1000,
+			Arguments: []*ArgNode{{IdlType: "string", GoType: "string", Index: 1}},
+			RetType:   &ArgNode{IdlType: "void", GoType: "void"},
+		},
+		{
+			Name:    "level",
+			Index:   2,
+			RetType: &ArgNode{IdlType: "i32", GoType: "int32"},
+		},
+	}}}
+	buf := &bytes.Buffer{}
+	if err := proxytp.Execute(buf, gen); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "player_proxy.go", buf.Bytes(), 0); err != nil {
+		t.Fatalf("generated proxy is invalid: %v\n%s", err, buf.String())
+	}
+	for _, want := range []string{
+		`"context"`,
+		"Login(ctx context.Context, _1 string)",
+		"Level(ctx context.Context)(ret1  int32,err error)",
+		"idlrpc.CallContext(ctx, rpc, sp, 1, 1000, 0, pbarg)",
+		"idlrpc.CallContext(ctx, rpc, sp, 2, 0, 0, pbarg)",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("generated proxy missing %q\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestCompatCheck(t *testing.T) {
+	player := func(uuid string, methods ...*MethodNode) *IdlJsonNode {
+		return &IdlJsonNode{
+			Services: []*ServiceNode{{Name: "Player", Uuid: uuid, Methods: methods}},
+			Enums:    []*EnumNode{{Name: "Camp", Fields: []*EnumFieldNode{{Name: "Red", Value: 1}, {Name: "Blue", Value: 2}}}},
+		}
+	}
+	str := &ArgNode{IdlType: "string", GoType: "string"}
+	void := &ArgNode{IdlType: "void", GoType: "void"}
+	items := &ArgNode{IdlType: "seq", GoType: "[]", Key: &ComplexNode{IdlType: "Item", GoType: "Item", IsStruct: true}}
+	oldIdl := player("1001",
+		&MethodNode{Name: "login", Index: 1, Arguments: []*ArgNode{str}, RetType: void},
+		&MethodNode{Name: "items", Index: 2, RetType: items},
+		&MethodNode{Name: "kick", Index: 3, RetType: void},
+		&MethodNode{Name: "notify", Index: 4, IsOneway: true, RetType: void},
+	)
+	// renamed argument and new method are compatible
+	newIdl := player("1001",
+		&MethodNode{Name: "login", Index: 1, Arguments: []*ArgNode{{IdlType: "string", GoType: "string", DeclName: "account"}}, RetType: void},
+		&MethodNode{Name: "items", Index: 2, RetType: items},
+		&MethodNode{Name: "kick", Index: 3, RetType: void},
+		&MethodNode{Name: "notify", Index: 4, IsOneway: true, RetType: void},
+		&MethodNode{Name: "logout", Index: 5, RetType: void},
+	)
+	if breaks := CompatCheck(oldIdl, newIdl); len(breaks) != 0 {
+		t.Fatalf("unexpected breaking changes %v", breaks)
+	}
+
+	newIdl = player("1002",
+		&MethodNode{Name: "login", Index: 1, Arguments: []*ArgNode{{IdlType: "i64", GoType: "int64"}}, RetType: void},
+		&MethodNode{Name: "items", Index: 2, RetType: &ArgNode{IdlType: "seq", GoType: "[]", Key: &ComplexNode{IdlType: "i32", GoType: "int32"}}},
+		&MethodNode{Name: "ban", Index: 3, RetType: void},
+		&MethodNode{Name: "notify", Index: 5, RetType: void},
+	)
+	newIdl.Enums[0].Fields = newIdl.Enums[0].Fields[:1]
+	want := []string{
+		"service Player uuid changed 1001 -> 1002",
+		"method Player.login argument 1 type changed string -> i64",
+		"method Player.items return type changed seq<Item> -> seq<i32>",
+		"method Player.kick removed",
+		"method Player.notify renumbered 4 -> 5",
+		"method Player.notify oneway changed true -> false",
+		"method Player.ban takes index 3 of kick",
+		"enum value Camp.Blue removed",
+	}
+	breaks := CompatCheck(oldIdl, newIdl)
+	if strings.Join(breaks, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("breaking changes\n%s\nwant\n%s", strings.Join(breaks, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMergeUsrFile(t *testing.T) {
+	void := &ArgNode{IdlType: "void", GoType: "void"}
+	login := &MethodNode{Name: "login", Index: 1, Arguments: []*ArgNode{{IdlType: "string", GoType: "string", Index: 1, DeclName: "account"}}, RetType: void}
+	kick := &MethodNode{Name: "kick", Index: 2, RetType: void}
+	logout := &MethodNode{Name: "logout", Index: 3, RetType: &ArgNode{IdlType: "i32", GoType: "int32"}}
+	gen := &Gen{Name: "Player", Idlname: "game", Service: &ServiceNode{Name: "Player", Uuid: "1001", Methods: []*MethodNode{login, kick}}}
+	code, err := RenderClientImpl(gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// user implements login and adds own import and helper
+	existing := strings.Replace(string(code), "account string) (err error) {\n\treturn", "account string) (err error) {\n\treturn sp.check(strings.TrimSpace(account))", 1)
+	existing = strings.Replace(existing, `import "fmt"`, "import \"fmt\"\nimport \"strings\"", 1)
+	existing += "\nfunc (sp *PlayerImpl) check(account string) error {\n\treturn nil\n}\n"
+
+	gen.Service.Methods = []*MethodNode{login, logout}
+	code, _ = RenderClientImpl(gen)
+	merge, err := MergeUsrFile([]byte(existing), code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(merge.Added, ",") != "Logout" || strings.Join(merge.Deprecated, ",") != "Kick" {
+		t.Fatalf("added %v deprecated %v", merge.Added, merge.Deprecated)
+	}
+	merged := string(merge.Code)
+	for _, want := range []string{
+		"return sp.check(strings.TrimSpace(account))",
+		`import "strings"`,
+		"// Deprecated: Kick is removed from idl",
+		"func (sp *PlayerImpl) Logout(ctx context.Context) (ret1 int32, err error) {",
+	} {
+		if !strings.Contains(merged, want) {
+			t.Errorf("merged impl missing %q\n%s", want, merged)
+		}
+	}
+	if strings.Count(merged, ") Login(") != 1 {
+		t.Errorf("login duplicated\n%s", merged)
+	}
+
+	// merge again changes nothing
+	again, err := MergeUsrFile(merge.Code, code)
+	if err != nil || len(again.Added) != 0 || len(again.Deprecated) != 0 || string(again.Code) != merged {
+		t.Fatalf("merge is not stable, added %v deprecated %v error %v", again.Added, again.Deprecated, err)
+	}
+
+	diff := UnifiedDiff("player_impl.go", []byte(existing), merge.Code)
+	if !strings.HasPrefix(diff, "--- a/player_impl.go\n+++ b/player_impl.go\n@@ ") ||
+		!strings.Contains(diff, "+// Deprecated: Kick") || !strings.Contains(diff, "+func (sp *PlayerImpl) Logout(") || strings.Contains(diff, "-func") {
+		t.Fatalf("diff\n%s", diff)
+	}
+	if UnifiedDiff("player_impl.go", merge.Code, merge.Code) != "" {
+		t.Fatal("diff of same file")
+	}
+}
+
+func TestExceptionCodeRange(t *testing.T) {
+	cases := []struct {
+		exceptions []*ExceptionNode
+		valid      bool
+	}{
+		{[]*ExceptionNode{{Name: "NotEnoughGold", Code: 1001}}, true},
+		{[]*ExceptionNode{{Name: "Busy", Code: 15}}, false},
+		{[]*ExceptionNode{{Name: "Zero", Code: 0}}, false},
+		{[]*ExceptionNode{{Code: 1001}}, false},
+		{[]*ExceptionNode{{Name: "A", Code: 1001}, {Name: "B", Code: 1001}}, false},
+	}
+	for _, c := range cases {
+		ij := &IdlJsonNode{Exceptions: c.exceptions}
+		if ij.IsValid() != c.valid {
+			t.Errorf("exceptions %+v valid expect %t", c.exceptions[0], c.valid)
+		}
+	}
+}
+
+func TestMergeUsrSignatureAndImports(t *testing.T) {
+	existing := `package player_impl
+
+import (
+	"context"
+	"fmt"
+)
+
+type PlayerImpl struct{}
+
+func (sp *PlayerImpl) Move(ctx context.Context, x, y int32) (err error) {
+	return nil
+}
+
+// Login user doc
+func (sp *PlayerImpl) Login(ctx context.Context, account int32) (err error) {
+	return fmt.Errorf("%d", account)
+}
+`
+	generated := `package player_impl
+
+import idldata "game/idldata"
+import "context"
+import "fmt"
+
+type PlayerImpl struct{}
+
+func (sp *PlayerImpl) Move(ctx context.Context, _1 int32, _2 int32) (err error) {
+	return
+}
+
+func (sp *PlayerImpl) Login(ctx context.Context, _1 string) (err error) {
+	return
+}
+
+func (sp *PlayerImpl) Info(ctx context.Context) (ret1 *idldata.Info, err error) {
+	return
+}
+`
+	merge, err := MergeUsrFile([]byte(existing), []byte(generated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(merge.Added, ",") != "Info" || strings.Join(merge.Mismatched, ",") != "Login" || len(merge.Deprecated) != 0 {
+		t.Fatalf("added %v mismatched %v deprecated %v", merge.Added, merge.Mismatched, merge.Deprecated)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "merged.go", merge.Code, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// new import joins the existing block
+	var imports []*ast.GenDecl
+	for _, decl := range file.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			imports = append(imports, gd)
+		}
+	}
+	if len(imports) != 1 || len(imports[0].Specs) != 3 {
+		t.Fatalf("imports are not merged into one block\n%s", merge.Code)
+	}
+	if spec := imports[0].Specs[2].(*ast.ImportSpec); spec.Name == nil || spec.Name.Name != "idldata" || spec.Path.Value != `"game/idldata"` {
+		t.Fatalf("unexpected import %v", spec.Path.Value)
+	}
+	for _, decl := range file.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		doc := ""
+		if fd.Doc != nil {
+			doc = fd.Doc.Text()
+		}
+		mismatch := strings.Contains(doc, "Mismatch:")
+		if mismatch != (fd.Name.Name == "Login") {
+			t.Fatalf("%s doc %q", fd.Name.Name, doc)
+		}
+		if mismatch && (!strings.HasPrefix(doc, "Login user doc") ||
+			!strings.Contains(doc, "idl declares func(context.Context, string) (error)")) {
+			t.Fatalf("login doc %q", doc)
+		}
+	}
+
+	again, err := MergeUsrFile(merge.Code, []byte(generated))
+	if err != nil || string(again.Code) != string(merge.Code) || strings.Join(again.Mismatched, ",") != "Login" {
+		t.Fatalf("merge is not stable, mismatched %v error %v\n%s", again.Mismatched, err, again.Code)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 )
 
+const diffContext = 3 // unchanged lines around change in diff hunk
+
 func PathExits(path string) bool {
 	finfo, err := os.Stat(path)
 	if err != nil {
@@ -73,3 +75,115 @@ func DealPbStructField(field string) string {
 	}
 	return res
 }
+
+// UnifiedDiff line diff of a and b in unified format, empty while same
+func UnifiedDiff(name string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+	al, bl := splitLines(a), splitLines(b)
+	// lcs[i][j] common lines of al[i:] and bl[j:]
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// edit script, op is ' ', '-' or '+'
+	type line struct {
+		op   byte
+		text string
+		ai   int // line index of a before this line
+		bi   int // line index of b before this line
+	}
+	var script []line
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			script = append(script, line{' ', al[i], i, j})
+			i, j = i+1, j+1
+		case j < len(bl) && (i == len(al) || lcs[i][j+1] >= lcs[i+1][j]):
+			script = append(script, line{'+', bl[j], i, j})
+			j++
+		default:
+			script = append(script, line{'-', al[i], i, j})
+			i++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+	for k := 0; k < len(script); {
+		if script[k].op == ' ' {
+			k++
+			continue
+		}
+		// hunk from first change, extended while next change is within context
+		start := k - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := k
+		for end < len(script) {
+			if script[end].op != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(script) && script[next].op == ' ' {
+				next++
+			}
+			if next == len(script) || next-end > 2*diffContext {
+				end += diffContext
+				if end > len(script) {
+					end = len(script)
+				}
+				break
+			}
+			end = next
+		}
+		acount, bcount := 0, 0
+		for _, l := range script[start:end] {
+			if l.op != '+' {
+				acount++
+			}
+			if l.op != '-' {
+				bcount++
+			}
+		}
+		astart, bstart := script[start].ai+1, script[start].bi+1
+		if acount == 0 {
+			astart--
+		}
+		if bcount == 0 {
+			bstart--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", astart, acount, bstart, bcount)
+		for _, l := range script[start:end] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			out.WriteByte('\n')
+		}
+		k = end
+	}
+	return out.String()
+}
+
+func splitLines(data []byte) []string {
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
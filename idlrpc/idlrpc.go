@@ -4,6 +4,8 @@ package idlrpc
 import (
 	"context"
 
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/transport"
 	"google.golang.org/protobuf/proto"
 )
@@ -43,4 +45,67 @@ type (
 		// AddStubCreator add stub creator
 		AddStubCreator(uuid uint64, bc StubCreator) error
 	}
+
+	// optional IRpc methods, IRpc of CreateRpcFramework implements all of them.
+	// call them by functions of the same name, which work with any IRpc
+
+	// IContextCaller call with ctx, see CallContext
+	IContextCaller interface {
+		CallContext(ctx context.Context, proxyId IProxy, methodId, timeout uint32, retry int32, message proto.Message) ([]byte, error)
+	}
+
+	// IStatsProvider runtime statistics of services, see GetServiceStats
+	IStatsProvider interface {
+		GetServiceStats(uuid uint64) (ServiceStats, error)
+	}
+
+	// IProtocolProvider wire format of transports, see Protocol
+	IProtocolProvider interface {
+		Protocol(trans transport.ITransport) protocol.Protocol
+	}
+
+	// IConfigReloader config file reloading, see ReloadConfig
+	IConfigReloader interface {
+		ReloadConfig() error
+	}
 )
+
+// CallContext call like IRpc.Call, trace id, metadata and deadline of ctx are passed to remote.
+// ctx is dropped while rpc is not IContextCaller
+func CallContext(ctx context.Context, rpc IRpc, proxyId IProxy, methodId, timeout uint32, retry int32, message proto.Message) ([]byte, error) {
+	if cc, ok := rpc.(IContextCaller); ok {
+		return cc.CallContext(ctx, proxyId, methodId, timeout, retry, message)
+	}
+	if ctx != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return rpc.Call(proxyId, methodId, timeout, retry, message)
+}
+
+// GetServiceStats get runtime statistics of service registered to rpc
+func GetServiceStats(rpc IRpc, uuid uint64) (ServiceStats, error) {
+	if sp, ok := rpc.(IStatsProvider); ok {
+		return sp.GetServiceStats(uuid)
+	}
+	return ServiceStats{}, errors.NewRpcError(errors.CommErr, "%T has no service stats", rpc)
+}
+
+// Protocol get wire format used by rpc on transport, while rpc is not IProtocolProvider
+// it is protocol of transport or default protocol
+func Protocol(rpc IRpc, trans transport.ITransport) protocol.Protocol {
+	if pp, ok := rpc.(IProtocolProvider); ok {
+		return pp.Protocol(trans)
+	}
+	if pt, ok := trans.(transport.IProtocolTransport); ok && pt.Protocol() != nil {
+		return pt.Protocol()
+	}
+	return protocol.Default()
+}
+
+// ReloadConfig read config file set by WithConfigFile again
+func ReloadConfig(rpc IRpc) error {
+	if cr, ok := rpc.(IConfigReloader); ok {
+		return cr.ReloadConfig()
+	}
+	return errors.NewRpcError(errors.CommErr, "%T can not reload config", rpc)
+}
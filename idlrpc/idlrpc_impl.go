@@ -2,6 +2,7 @@ package idlrpc
 
 import (
 	"context"
+	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -15,10 +16,6 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-var (
-	stackTrace bool
-)
-
 type (
 	proxyFactoryMap map[uint64]ProxyCreator
 	stubFactoryMap  map[uint64]StubCreator
@@ -32,31 +29,81 @@ type (
 		proxyCallMgr   *proxy.ProxyCallManager
 		stubMgr        *StubManager
 		serviceFactory stubFactoryMap
-		logger         log.ILogger //logger handle
-		status         int32       // rpc status
+		logger         log.ILogger       //logger handle
+		flog           log.IFieldLogger  //structured logger handle
+		wire           protocol.Protocol //wire format, transport may override it
+		status         int32             // rpc status
 	}
 )
 
+var (
+	_ IRpc              = (*rpcImpl)(nil)
+	_ IContextCaller    = (*rpcImpl)(nil)
+	_ IStatsProvider    = (*rpcImpl)(nil)
+	_ IProtocolProvider = (*rpcImpl)(nil)
+	_ IConfigReloader   = (*rpcImpl)(nil)
+)
+
 func (r *rpcImpl) Init(opts ...Option) error {
-	r.opt = &Options{
-		stackTrace: false,
-		callTrace:  false,
-		ctx:        context.Background(),
-	}
+	r.opt = defaultOptions()
 	for _, o := range opts {
 		o(r.opt)
 	}
 	r.logger = r.opt.logger
-	stackTrace = r.opt.stackTrace
+	r.flog = r.opt.flog
+	if r.logger == nil && r.flog != nil {
+		r.logger = log.ToLogger(r.flog)
+	}
+	if r.logger == nil {
+		r.logger = &logger.NullLogger{}
+	}
+	if r.flog == nil {
+		r.flog = log.FromLogger(r.logger)
+	}
+	r.wire = r.opt.proto
+	if r.wire == nil {
+		r.wire = protocol.Default()
+	}
+	r.proxyMgr.Init(r.logger)
+	// manager of CreateRpcFramework has default settings, replaced by one of options
+	r.proxyCallMgr.Close()
+	r.proxyCallMgr = proxy.NewCallManager(
+		proxy.WithLogger(r.logger),
+		proxy.WithCallDefault(r.opt.timeOut, r.opt.maxRetry),
+		proxy.WithWheel(r.opt.wheelTick, r.opt.wheelSlots),
+	)
+	if r.opt.confPath != "" {
+		return r.ReloadConfig()
+	}
 	return nil
 }
 
-func (r *rpcImpl) Start() error {
-	if r.logger == nil {
-		r.logger = &logger.NullLogger{}
+// ReloadConfig read config file again, timeout and retry take effect on next call,
+// worker number and queue size take effect on services registered after reload
+func (r *rpcImpl) ReloadConfig() error {
+	if r.opt == nil || r.opt.confPath == "" {
+		return errors.NewRpcError(errors.CommErr, "config file not set")
+	}
+	conf, err := LoadConfig(r.opt.confPath)
+	if err != nil {
+		r.logger.Error("[Rpc] load config error %v", err)
+		return err
 	}
-	r.stubMgr.Init(r.logger)
-	logger.SetLogger(r.logger)
+	timeOut, maxRetry := r.opt.timeOut, r.opt.maxRetry
+	if conf.CallTimeout > 0 {
+		timeOut = conf.CallTimeout
+	}
+	if conf.MaxRetry != nil {
+		maxRetry = *conf.MaxRetry
+	}
+	r.proxyCallMgr.SetCallDefault(timeOut, maxRetry)
+	r.opt.conf.store(conf)
+	r.logger.Info("[Rpc] config %s loaded", r.opt.confPath)
+	return nil
+}
+
+func (r *rpcImpl) Start() error {
+	r.stubMgr.Init(r.logger, r.flog, r.opt)
 	r.status = RpcRunning
 	r.logger.Info("[Rpc] ===== rpc frame work start working =====")
 	return nil
@@ -84,6 +131,8 @@ func (r *rpcImpl) ShutDown() error {
 
 	//close all service
 	r.stubMgr.UnInit()
+	//waiting proxy calls time out
+	r.proxyCallMgr.Close()
 	return nil
 }
 
@@ -91,19 +140,33 @@ func (r *rpcImpl) Options() *Options {
 	return r.opt
 }
 
+// Protocol return wire format used on trans
+func (r *rpcImpl) Protocol(trans transport.ITransport) protocol.Protocol {
+	if pt, ok := trans.(transport.IProtocolTransport); ok {
+		if wire := pt.Protocol(); wire != nil {
+			return wire
+		}
+	}
+	if r.wire == nil {
+		return protocol.Default()
+	}
+	return r.wire
+}
+
 func (r *rpcImpl) OnMessage(trans transport.ITransport, ctx context.Context) error {
 	//read bytes from transport until invalided bytes
 	if atomic.LoadInt32(&r.status) != RpcRunning {
 		return errors.ErrRpcClosed
 	}
+	wire := r.Protocol(trans)
 	for {
 		headers, mLen, err := trans.Peek(protocol.RpcHeadSize)
 		if mLen != 8 || err != nil {
 			return err
 		}
 
-		header := protocol.ReadHeader(headers)
-		if header == nil {
+		header := &protocol.RpcMsgHeader{}
+		if !wire.ReadHeader(headers, header) {
 			trans.Close()
 			return errors.ErrInvalidProto
 		}
@@ -127,49 +190,83 @@ func (r *rpcImpl) OnMessage(trans transport.ITransport, ctx context.Context) err
 		}
 
 		//TODO add context usage
+		switch header.Type {
+		case protocol.RequestMsg, protocol.ResponseMsg, protocol.ProxyRequestMsg, protocol.ProxyResponseMsg, protocol.RpcTimeout:
+		case protocol.NotRpcMsg:
+			continue
+		default:
+			r.logger.Warn("[Rpc] An illegal protocol request %d was received from %s:%d", header.Type, trans.RemoteAddr(), trans.GlobalIndex())
+			return errors.ErrInvalidProto
+		}
+
+		// read whole frame at once, header is decoded in place
+		frame, pooled, err := readFrame(trans, int(header.Length))
+		if err != nil {
+			r.logger.Warn("[Rpc] read %d bytes frame of protocol %d error %v", header.Length, header.Type, err)
+			return err
+		}
+
 		switch header.Type {
 		case protocol.RequestMsg:
-			if err = r.onCall(trans); err != nil {
+			if err = r.onCall(trans, wire, frame, pooled); err != nil {
 				r.logger.Warn("[Rpc] Execution of the rpc request failed, error %v", err)
 			}
 		case protocol.ResponseMsg:
-			if err = r.onReturn(trans); err != nil {
+			if err = r.onReturn(trans, wire, frame); err != nil {
 				r.logger.Info("[Rpc] Execution of the rpc response failed,  error %v", err)
 			}
 		case protocol.ProxyRequestMsg:
-			if err = r.onProxyCall(trans); err != nil {
+			if err = r.onProxyCall(trans, wire, frame, pooled); err != nil {
 				r.logger.Info("[Rpc] Execution of the proxy call failed, error %v", err)
 			}
 		case protocol.ProxyResponseMsg:
-			if err = r.onProxyReturn(trans); err != nil {
+			if err = r.onProxyReturn(trans, wire, frame); err != nil {
 				r.logger.Info("[Rpc] Execution of the proxy response failed, error %v", err)
 			}
 		case protocol.RpcTimeout:
-			if err = r.onOutsideConnTimeout(trans); err != nil {
+			err = r.onOutsideConnTimeout(trans, wire, frame)
+			if pooled {
+				protocol.PutBuffer(frame)
+			}
+			if err != nil {
 				r.logger.Info("[Rpc] Execution of the heartbeat notification failed, error: %v", err)
 			}
-		case protocol.NotRpcMsg:
-			break
-		default:
-			r.logger.Warn("[Rpc] An illegal protocol request %d was received from %s:%d", header.Type, trans.RemoteAddr(), trans.GlobalIndex())
-			return errors.ErrInvalidProto
 		}
 	}
 }
 
+// readFrame remove complete frame of length bytes from transport, without copying if transport supports,
+// pooled is true while frame is got from protocol buffer pool, it can be put back after use
+func readFrame(trans transport.ITransport, length int) (frame []byte, pooled bool, err error) {
+	if fr, ok := trans.(transport.IFrameReader); ok {
+		frame, err = fr.ReadFrame(length)
+		if err == nil && len(frame) != length {
+			err = errors.ErrIllegalProto
+		}
+		return frame, false, err
+	}
+	frame = protocol.GetBuffer(length)
+	if mLen, err := trans.Read(frame, length); mLen != length || err != nil {
+		protocol.PutBuffer(frame)
+		return nil, false, errors.ErrIllegalProto
+	}
+	return frame, true, nil
+}
+
 func (r *rpcImpl) OnProxyMessage(trans transport.ITransport, ph IProxyHandler) error {
 	//read bytes from transport until invalided bytes
 	if atomic.LoadInt32(&r.status) != RpcRunning {
 		return errors.ErrRpcClosed
 	}
+	wire := r.Protocol(trans)
 	for {
 		headers, mLen, err := trans.Peek(protocol.RpcHeadSize)
 		if mLen != 8 || err != nil {
 			return err
 		}
 
-		header := protocol.ReadHeader(headers)
-		if header == nil {
+		header := &protocol.RpcMsgHeader{}
+		if !wire.ReadHeader(headers, header) {
 			trans.Close()
 			r.logger.Error("[RPC] parse rpc header error from addr %s global index %d !", trans.RemoteAddr(), trans.GlobalIndex())
 			return errors.ErrInvalidProto
@@ -233,7 +330,26 @@ func (r *rpcImpl) RegisterService(service IService) error {
 	return nil
 }
 
-func (r *rpcImpl) Call(srvProxy IProxy, methodId, timeout uint32, retry int32, message proto.Message) (buffer []byte, err error) {
+func (r *rpcImpl) Call(srvProxy IProxy, methodId, timeout uint32, retry int32, message proto.Message) ([]byte, error) {
+	return r.call(srvProxy, methodId, timeout, retry, message, time.Time{}, nil)
+}
+
+// CallContext call with ctx, trace id of current call and metadata set by WithMetadata are sent to remote,
+// timeout and retries are shrunk to ctx deadline. ctx is checked before sending, call is not interrupted by cancel
+func (r *rpcImpl) CallContext(ctx context.Context, srvProxy IProxy, methodId, timeout uint32, retry int32, message proto.Message) ([]byte, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	return r.call(srvProxy, methodId, timeout, retry, message, deadline, outgoingInfo(ctx))
+}
+
+// call send request and wait for response, deadline is zero while caller has none,
+// info is sent in Context field of args besides the remaining timeout
+func (r *rpcImpl) call(srvProxy IProxy, methodId, timeout uint32, retry int32, message proto.Message, deadline time.Time, info map[string]string) (buffer []byte, err error) {
 	//get proxy manager
 	if r.proxyMgr == nil {
 		return nil, errors.NewRpcError(errors.CommErr, "proxy manager is invalid")
@@ -244,6 +360,12 @@ func (r *rpcImpl) Call(srvProxy IProxy, methodId, timeout uint32, retry int32, m
 		return nil, errors.ErrProxyInvalid
 	}
 
+	timeout, retry = r.opt.Config().method(srvProxy.GetSrvName(), srvProxy.GetSignature(methodId), timeout, retry)
+	if !deadline.IsZero() {
+		if timeout, retry, err = r.fitDeadline(deadline, timeout, retry); err != nil {
+			return nil, err
+		}
+	}
 	proxyCall := r.proxyCallMgr.CreateProxyCall(proxy.ProxyUuid(srvProxy.GetID()), timeout, retry, srvProxy.GetGlobalIndex())
 	if proxyCall == nil {
 		return nil, errors.ErrProxyInvalid
@@ -264,8 +386,19 @@ func (r *rpcImpl) Call(srvProxy IProxy, methodId, timeout uint32, retry int32, m
 	if err != nil {
 		return
 	}
+	// tell remote how long we will wait, remote drops the call after deadline.
+	// context is appended to encoded args, caller's message is never modified
+	if message != nil {
+		ctxInfo := make(map[string]string, len(info)+1)
+		for k, v := range info {
+			ctxInfo[k] = v
+		}
+		ctxInfo[protocol.CtxTimeoutKey] = strconv.FormatUint(uint64(proxyCall.GetTimeOut()), 10)
+		pkg = protocol.AppendCtxInfo(pkg, protocol.CtxField(message.ProtoReflect().Descriptor()), ctxInfo)
+	}
 
 	var packData []byte
+	wire := r.Protocol(srvProxy.GetTransport())
 
 	if srvProxy.GetGlobalIndex() == InvalidGlobalIndex {
 
@@ -284,7 +417,7 @@ func (r *rpcImpl) Call(srvProxy IProxy, methodId, timeout uint32, retry int32, m
 			Buffer: pkg,
 		}
 
-		packData, _ = protocol.PackReqMsg(reqPb)
+		packData, _ = wire.PackReqMsg(reqPb)
 		//startT := time.Now()
 
 	} else {
@@ -307,7 +440,7 @@ func (r *rpcImpl) Call(srvProxy IProxy, methodId, timeout uint32, retry int32, m
 		} else {
 			reqPb.Header.OneWay = 0
 		}
-		packData, _ = protocol.PackProxyReqMsg(reqPb)
+		packData, _ = wire.PackProxyReqMsg(reqPb)
 	}
 
 	buffer, err = callMethod(r, srvProxy, proxyCall, methodId, packData)
@@ -319,6 +452,31 @@ func (r *rpcImpl) Call(srvProxy IProxy, methodId, timeout uint32, retry int32, m
 	return
 }
 
+// fitDeadline shrink timeout and retries, all attempts end before deadline
+func (r *rpcImpl) fitDeadline(deadline time.Time, timeout uint32, retry int32) (uint32, int32, error) {
+	remain := int64(time.Until(deadline) / time.Millisecond)
+	if remain <= 0 {
+		return 0, 0, context.DeadlineExceeded
+	}
+	defTimeout, maxRetry := r.proxyCallMgr.CallDefault()
+	if timeout == 0 {
+		timeout = defTimeout
+	}
+	if retry > maxRetry {
+		retry = maxRetry
+	}
+	if retry < 0 {
+		retry = 0
+	}
+	if int64(timeout) >= remain {
+		return uint32(remain), 0, nil
+	}
+	if attempts := remain / int64(timeout); attempts < int64(retry)+1 {
+		retry = int32(attempts - 1)
+	}
+	return timeout, retry, nil
+}
+
 func (r *rpcImpl) GetProxyFromPeer(ctx context.Context, uuid uint64) (srvProxy IProxy, err error) {
 	if r == nil {
 		r.logger.Warn("[Rpc] rpc frame work not init!")
@@ -330,7 +488,7 @@ func (r *rpcImpl) GetProxyFromPeer(ctx context.Context, uuid uint64) (srvProxy I
 		return nil, errors.ErrStubCallInvalid
 	}
 
-	stubCall := ctx.Value(callkey{}).(*StubCall)
+	stubCall := stubCallFrom(ctx)
 	if stubCall == nil {
 		return nil, errors.ErrStubCallInvalid
 	}
@@ -356,6 +514,9 @@ func (r *rpcImpl) GetServiceProxy(uuid uint64, trans transport.ITransport) (IPro
 	}
 
 	srvProxy := r.proxyMgr.getOrCreateProxy(uuid, trans.GlobalIndex(), trans)
+	if srvProxy == nil {
+		return nil, errors.NewRpcError(errors.CommErr, "proxy creator of service %d not added", uuid)
+	}
 	srvProxy.SetRpc(r)
 	return srvProxy, nil
 }
@@ -388,122 +549,112 @@ func (r *rpcImpl) AddStubCreator(uuid uint64, bc StubCreator) error {
 	return nil
 }
 
+func (r *rpcImpl) GetServiceStats(uuid uint64) (ServiceStats, error) {
+	srvStub := r.stubMgr.Get(SvcUuid(uuid))
+	if srvStub == nil {
+		return ServiceStats{}, errors.NewServiceNotExist(uuid)
+	}
+	return srvStub.stats(), nil
+}
+
 // ============================= tool function ==============================
 
-func (r *rpcImpl) onCall(trans transport.ITransport) error {
-	//read trans header
-	pkg := make([]byte, protocol.CallHeadSize)
-	if mLen, err := trans.Read(pkg[:], protocol.CallHeadSize); mLen != protocol.CallHeadSize || err != nil {
-		r.logger.Warn("[Rpc] parse rpc message error !")
-		return errors.ErrIllegalProto
-	}
+func (r *rpcImpl) onCall(trans transport.ITransport, wire protocol.Protocol, frame []byte, pooled bool) error {
 	// read protocol header
-	msgHeader := protocol.ReadCallHeader(pkg)
-	if msgHeader == nil {
+	var msgHeader protocol.RpcCallHeader
+	if !wire.ParseReqMsg(frame, &msgHeader) || int(msgHeader.Length) != len(frame) {
 		r.logger.Warn("[Rpc] read req protocol head error !")
+		releaseFrame(frame, pooled)
 		return errors.ErrIllegalReq
 	}
 
-	mLen := int(msgHeader.Length) - protocol.CallHeadSize
-
-	reqMsg := &protocol.RequestPackage{
-		Header: msgHeader,
-		Buffer: make([]byte, mLen),
-	}
-
-	rLen, err := trans.Read(reqMsg.Buffer[:], mLen)
-	if err != nil || rLen != mLen {
-		return errors.ErrIllegalProto
+	reqMsg := protocol.RequestPackage{
+		Header: &msgHeader,
+		Buffer: frame[protocol.CallHeadSize:],
 	}
 
 	srvStub := r.stubMgr.Get(SvcUuid(msgHeader.ServiceUUID))
 	if srvStub == nil {
-		notFound(trans, msgHeader)
+		notFound(trans, wire, &msgHeader)
+		releaseFrame(frame, pooled)
 		return errors.NewServiceNotExist(msgHeader.ServiceUUID)
 	}
 
 	callUuid := r.stubMgr.GeneUuid()
 
 	//create stub call
-	stubCall := newStubCall(trans, reqMsg, callUuid)
+	stubCall := newStubCall(trans, wire, &reqMsg, callUuid)
 	if stubCall == nil {
-		r.logger.Warn("[Rpc] %d,%d,%d create stub call error!", reqMsg.Header.ServiceUUID, reqMsg.Header.MethodID, reqMsg.Header.CallID)
+		r.logger.Warn("[Rpc] %d,%d,%d create stub call error!", msgHeader.ServiceUUID, msgHeader.MethodID, msgHeader.CallID)
+		releaseFrame(frame, pooled)
 		return errors.ErrStubCallInvalid
 	}
+	if pooled {
+		stubCall.frame = frame
+	}
 
-	err = srvStub.doCallService(trans, stubCall)
+	err := srvStub.doCallService(trans, stubCall)
 	if err != nil {
-		r.logger.Warn("[Rpc] %d,%d,%d service all error !", reqMsg.Header.ServiceUUID, reqMsg.Header.MethodID, reqMsg.Header.CallID)
+		r.logger.Warn("[Rpc] %d,%d,%d service all error !", msgHeader.ServiceUUID, msgHeader.MethodID, msgHeader.CallID)
+		stubCall.release()
 		return err
 	}
 	return nil
 }
 
-func (r *rpcImpl) onProxyCall(trans transport.ITransport) error {
-	//read trans header
-	pkg := make([]byte, protocol.ProxyCallHeadSize)
-	if mLen, err := trans.Read(pkg[:], protocol.ProxyCallHeadSize); mLen != protocol.ProxyCallHeadSize || err != nil {
-		r.logger.Warn("[Rpc] parse rpc proxy message error !")
-		return errors.ErrIllegalProto
+// releaseFrame put frame back to buffer pool if it is got from pool
+func releaseFrame(frame []byte, pooled bool) {
+	if pooled {
+		protocol.PutBuffer(frame)
 	}
+}
 
+func (r *rpcImpl) onProxyCall(trans transport.ITransport, wire protocol.Protocol, frame []byte, pooled bool) error {
 	// read protocol header
-	msgHeader := protocol.ReadProxyCallHeader(pkg)
-	if msgHeader == nil {
+	var msgHeader protocol.RpcProxyCallHeader
+	if !wire.ParseProxyReqMsg(frame, &msgHeader) || int(msgHeader.Length) != len(frame) {
 		r.logger.Warn("[Rpc] read req protocol head error !")
+		releaseFrame(frame, pooled)
 		return errors.ErrIllegalReq
 	}
-	mLen := int(msgHeader.Length) - protocol.ProxyCallHeadSize
-	reqMsg := &protocol.ProxyRequestPackage{
-		Header: msgHeader,
-		Buffer: make([]byte, mLen),
-	}
-
-	rLen, err := trans.Read(reqMsg.Buffer[:], mLen)
-	if err != nil || rLen != mLen {
-		return errors.ErrIllegalProto
+	reqMsg := protocol.ProxyRequestPackage{
+		Header: &msgHeader,
+		Buffer: frame[protocol.ProxyCallHeadSize:],
 	}
 
 	srvStub := r.stubMgr.Get(SvcUuid(msgHeader.ServiceUUID))
 	if srvStub == nil {
 		//notFound(trans, msgHeader)
-		notFoundReturnProxy(trans, msgHeader)
+		notFoundReturnProxy(trans, wire, &msgHeader)
+		releaseFrame(frame, pooled)
 		return errors.NewServiceNotExist(msgHeader.ServiceUUID)
 	}
 
 	callUuid := r.stubMgr.GeneUuid()
-	stubCall := newStubCallWithProxy(trans, reqMsg, callUuid)
-	err = srvStub.doCallService(trans, stubCall)
+	stubCall := newStubCallWithProxy(trans, wire, &reqMsg, callUuid)
+	if pooled {
+		stubCall.frame = frame
+	}
+	err := srvStub.doCallService(trans, stubCall)
 	if err != nil {
-		r.logger.Warn("[Rpc] %d,%d,%d service all error !", reqMsg.Header.ServiceUUID, reqMsg.Header.MethodID, reqMsg.Header.CallID)
+		r.logger.Warn("[Rpc] %d,%d,%d service all error !", msgHeader.ServiceUUID, msgHeader.MethodID, msgHeader.CallID)
+		stubCall.release()
 		return err
 	}
 	return nil
 }
 
-func (r *rpcImpl) onReturn(trans transport.ITransport) error {
-	pkg := make([]byte, protocol.RespHeadSize)
-	if mLen, err := trans.Read(pkg, protocol.RespHeadSize); mLen != protocol.RespHeadSize || err != nil {
+func (r *rpcImpl) onReturn(trans transport.ITransport, wire protocol.Protocol, frame []byte) error {
+	var header protocol.RpcCallRetHeader
+	if !wire.ParseRespMsg(frame, &header) || int(header.Length) != len(frame) {
 		r.logger.Warn("[Rpc] rpc return protocol error!")
 		return errors.ErrIllegalProto
 	}
 
-	header := protocol.ReadRetHeader(pkg)
-	if header == nil {
-		return errors.ErrIllegalProto
-	}
-
-	//get resp data
-	mLen := int(header.Length) - protocol.RespHeadSize
+	//resp data refers to frame, which is handed over to caller
 	resp := &protocol.ResponsePackage{
-		Header: header,
-		Buffer: make([]byte, mLen),
-	}
-
-	resLen, err := trans.Read(resp.Buffer, mLen)
-	if err != nil || resLen != mLen {
-		r.logger.Warn("[Rpc] %d proxy call protocol rev error !", header.CallID)
-		return errors.ErrProxyInvalid
+		Header: &header,
+		Buffer: frame[protocol.RespHeadSize:],
 	}
 
 	// 一定要 读取完整的消息结构才能返回错误，否则回出现消息错乱
@@ -525,36 +676,22 @@ func (r *rpcImpl) onReturn(trans transport.ITransport) error {
 		}
 	}
 
-	proxyCall.SetErrorCode(header.ErrorCode)
-
-	//always notify
-	proxyCall.DoRet(resp.Buffer)
+	//always notify, result arrived after timeout is dropped
+	r.proxyCallMgr.Done(proxyCall, header.ErrorCode, resp.Buffer)
 	return nil
 }
 
-func (r *rpcImpl) onProxyReturn(trans transport.ITransport) error {
-	pkg := make([]byte, protocol.ProxyRetHeadSize)
-	if mLen, err := trans.Read(pkg, protocol.ProxyRetHeadSize); mLen != protocol.ProxyRetHeadSize || err != nil {
+func (r *rpcImpl) onProxyReturn(trans transport.ITransport, wire protocol.Protocol, frame []byte) error {
+	var header protocol.RpcProxyCallRetHeader
+	if !wire.ParseProxyRespMsg(frame, &header) || int(header.Length) != len(frame) {
 		r.logger.Warn("[Rpc] rpc proxy protocol return error!")
 		return errors.ErrIllegalProto
 	}
 
-	header := protocol.ReadProxyRetHeader(pkg)
-	if header == nil {
-		return errors.ErrIllegalProto
-	}
-
-	//get resp data
-	mLen := int(header.Length) - protocol.ProxyRetHeadSize
+	//resp data refers to frame, which is handed over to caller
 	resp := &protocol.ProxyRespPackage{
-		Header: header,
-		Buffer: make([]byte, mLen),
-	}
-
-	resLen, err := trans.Read(resp.Buffer, mLen)
-	if err != nil || resLen != mLen {
-		r.logger.Warn("[Rpc] %d proxy call protocol rev error !", header.CallID)
-		return errors.ErrProxyInvalid
+		Header: &header,
+		Buffer: frame[protocol.ProxyRetHeadSize:],
 	}
 
 	//get proxy call
@@ -578,29 +715,23 @@ func (r *rpcImpl) onProxyReturn(trans transport.ITransport) error {
 			srvProxy.SetTargetID(common.InvalidStubId)
 		}
 	}
-	proxyCall.SetErrorCode(header.ErrorCode)
 	//proxyCall.SetGlobalIndex(header.GlobalIndex)
 
-	//always notify
-	proxyCall.DoRet(resp.Buffer)
+	//always notify, result arrived after timeout is dropped
+	r.proxyCallMgr.Done(proxyCall, header.ErrorCode, resp.Buffer)
 
 	return nil
 }
 
 // 外部连接超时
-func (r *rpcImpl) onOutsideConnTimeout(trans transport.ITransport) error {
-	// 读取解析协议
-	pkg := make([]byte, protocol.TimeoutHeaderSize)
-	if mLen, err := trans.Read(pkg, protocol.TimeoutHeaderSize); mLen != protocol.TimeoutHeaderSize || err != nil {
+func (r *rpcImpl) onOutsideConnTimeout(trans transport.ITransport, wire protocol.Protocol, frame []byte) error {
+	// 解析协议
+	header := &protocol.RpcTimeoutHeader{}
+	if !wire.ParsePlatoHeader(frame, header) {
 		r.logger.Warn("[Rpc] Protocol resolution fails because the protocol specifications are inconsistent or the packet is damaged! ")
 		return errors.ErrIllegalProto
 	}
 
-	header := protocol.ReadTimeoutHeader(pkg)
-	if header == nil {
-		return errors.ErrIllegalProto
-	}
-
 	r.logger.Info("[Rpc] The external connection %d has been broken by interrupted signal", header.GlobalIndexId)
 	// 查找proxy 设置下线状态，删除proxy
 	return r.proxyMgr.closeOutsideProxy(header.GlobalIndexId)
@@ -625,33 +756,32 @@ func callMethod(rpc *rpcImpl, pImpl IProxy, call *proxy.ProxyCall, methodId uint
 	//get transport
 	trans := pImpl.GetTransport()
 	call.ReqData = packData
-	err = trans.Send(call.ReqData)
-	if err != nil {
-		return nil, err
-	}
 
 	//do not wait for response while function is oneway
 	if pImpl.IsOneWay(methodId) {
-		return nil, nil
+		return nil, trans.Send(call.ReqData)
 	}
 
-	clicker := time.NewTimer(time.Duration(call.GetTimeOut()) * time.Millisecond)
-	defer clicker.Stop()
-
-	//wait for response and retry
-	select {
-	case buffer = <-call.Ch:
-		if call.GetErrorCode() != protocol.IDL_RPC_TIME_OUT {
-			// do nothing,rpc call successful or throw exception
-			break
+	//timeout and retry are driven by proxy call manager, start waiting before sending
+	err = rpc.proxyCallMgr.Start(call, func(pc *proxy.ProxyCall) uint32 {
+		return retry(rpc, pImpl, pc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = trans.Send(call.ReqData)
+	if err != nil {
+		if rpc.proxyCallMgr.Cancel(call) {
+			return nil, err
 		}
-		// time out error, retry
-		buffer = retry(rpc, pImpl, call)
-	case <-clicker.C:
-		//add retry function code
-		buffer = retry(rpc, pImpl, call)
+		// result has been pushed, drain it
+		<-call.Ch
+		return nil, err
 	}
 
+	//wait for response, or timeout after all retries
+	buffer = <-call.Ch
+
 	errCode := call.GetErrorCode()
 	switch errCode {
 	case protocol.IDL_SUCCESS:
@@ -661,6 +791,10 @@ func callMethod(rpc *rpcImpl, pImpl IProxy, call *proxy.ProxyCall, methodId uint
 	case protocol.IDL_SERVICE_ERROR:
 		rpc.logger.Warn("[Rpc] service %d method %s exec error", pImpl.GetUUID(), pImpl.GetSignature(methodId))
 		err = errors.ErrRpcRet
+		// remote send code and message, old version remote only send ErrRpcRet
+		if code, name, detail, ok := protocol.ReadExceptionInfo(buffer); ok {
+			err = errors.NewRemoteError(errors.RpcErrorCode(code), name, detail)
+		}
 	//case protocol.IDL_SERVICE_EXCEPTION:
 	//	rpc.logger.Warn("[Rpc] service %d method %s exec panic", pImpl.GetUUID(), pImpl.GetSignature(methodId))
 	//	err = errors.ErrRpcException
@@ -672,43 +806,17 @@ func callMethod(rpc *rpcImpl, pImpl IProxy, call *proxy.ProxyCall, methodId uint
 	return
 }
 
-// retry , send rpc call
-func retry(rpc *rpcImpl, proxy IProxy, call *proxy.ProxyCall) (buffer []byte) {
+// retry , send rpc call again after timeout, called by proxy call manager
+func retry(rpc *rpcImpl, proxy IProxy, call *proxy.ProxyCall) uint32 {
 	// check proxy is valid
 	if !proxy.IsConnected() {
 		uuid, id, name := proxy.GetUUID(), proxy.GetID(), proxy.GetSrvName()
-		call.SetErrorCode(protocol.IDL_SERVICE_NOT_FOUND)
 		rpc.logger.Warn("[IProxy] %d,%d,%s is invalid !", uuid, id, name)
-		return
+		return protocol.IDL_SERVICE_NOT_FOUND
 	}
-	// 只要进入了这个函数，就一定是超时了
-	call.SetErrorCode(protocol.IDL_RPC_TIME_OUT)
 	rpc.logger.Warn("[IProxy] proxy call %d service %d:%q's method %q retry ", call.CallID, proxy.GetUUID(), proxy.GetSrvName(), proxy.GetSignature(call.MethodId))
-	trans := proxy.GetTransport()
-	for call.GetRetryTime() > 0 {
-		call.DecRetryTime()
-		err := trans.Send(call.ReqData)
-		if err != nil {
-			return
-		}
-		clicker := time.NewTimer(time.Duration(call.GetTimeOut()) * time.Millisecond)
-		select {
-		case buffer = <-call.Ch:
-			// success
-			errCode := call.GetErrorCode()
-			if errCode == protocol.IDL_SUCCESS {
-				clicker.Stop()
-				return
-			} else if errCode != protocol.IDL_RPC_TIME_OUT {
-				clicker.Stop()
-				return
-			} //retry again
-			clicker.Stop()
-			rpc.logger.Warn("[IProxy] proxy call %d service %d:%q's method %q retry again ", call.CallID, proxy.GetUUID(), proxy.GetSrvName(), proxy.GetSignature(call.MethodId))
-		case <-clicker.C:
-			call.SetErrorCode(protocol.IDL_RPC_TIME_OUT)
-			continue
-		}
+	if err := proxy.GetTransport().Send(call.ReqData); err != nil {
+		return protocol.IDL_RPC_TIME_OUT
 	}
-	return
+	return protocol.IDL_SUCCESS
 }
@@ -0,0 +1,92 @@
+package idlrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/transport"
+)
+
+// plainRpc IRpc without optional methods, like implementations written before they were added
+type plainRpc struct {
+	IRpc
+}
+
+func TestOptionalRpcMethods(t *testing.T) {
+	rpc := CreateRpcFramework()
+	if err := rpc.Init(); err != nil {
+		t.Fatal(err)
+	}
+	trans := newTestTrans()
+	if Protocol(rpc, trans) != protocol.Default() {
+		t.Fatal("framework protocol not used")
+	}
+	if _, err := GetServiceStats(rpc, 1); err == nil {
+		t.Fatal("stats of service not registered")
+	}
+	if err := ReloadConfig(rpc); err == nil {
+		t.Fatal("reload without config file")
+	}
+
+	plain := plainRpc{rpc}
+	if _, ok := IRpc(plain).(IContextCaller); ok {
+		t.Fatal("wrapper exposes optional method")
+	}
+	if Protocol(plain, trans) != protocol.Default() {
+		t.Fatal("default protocol not used")
+	}
+	if _, err := GetServiceStats(plain, 1); err == nil {
+		t.Fatal("stats without provider")
+	}
+	if err := ReloadConfig(plain); err == nil {
+		t.Fatal("reload without reloader")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := CallContext(ctx, plain, nil, 1, 0, 0, nil); err != context.Canceled {
+		t.Fatalf("canceled ctx returns %v", err)
+	}
+	if _, err := CallContext(context.Background(), plain, nil, 1, 0, 0, nil); err == nil {
+		t.Fatal("call falls back to Call, expect invalid proxy error")
+	}
+}
+
+// testProxy proxy of service 1, method 1 is two-way
+type testProxy struct {
+	ProxyBase
+}
+
+func (p *testProxy) GetUUID() uint64            { return 1 }
+func (p *testProxy) GetSignature(uint32) string { return "method" }
+func (p *testProxy) IsOneWay(uint32) bool       { return false }
+func (p *testProxy) GetSrvName() string         { return "test" }
+
+func TestRpcNotInit(t *testing.T) {
+	rpc := CreateRpcFramework()
+	if err := rpc.ShutDown(); err != errors.ErrRpcClosed {
+		t.Fatalf("shut down returns %v", err)
+	}
+	trans := newTestTrans()
+	if _, err := rpc.GetServiceProxy(1, trans); err == nil {
+		t.Fatal("proxy without creator")
+	}
+	if err := rpc.AddProxyCreator(1, func(trans transport.ITransport) IProxy {
+		p := &testProxy{}
+		p.SetTransport(trans)
+		return p
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srvProxy, err := rpc.GetServiceProxy(1, trans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = rpc.Call(srvProxy, 1, 10, 0, nil); !errors.Is(err, errors.ErrRpcTimeOut) {
+		t.Fatalf("call returns %v", err)
+	}
+	if len(trans.ch) != 1 {
+		t.Fatal("request not sent")
+	}
+}
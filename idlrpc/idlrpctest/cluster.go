@@ -0,0 +1,283 @@
+// Package idlrpctest in process cluster of rpc nodes for integration tests.
+// nodes are connected by in memory transports, messages are pumped automatically,
+// links between nodes can be slowed down or made lossy
+package idlrpctest
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/transport"
+)
+
+const defaultTickRate = time.Millisecond
+
+type (
+	Option func(*options)
+
+	options struct {
+		rpcOpts  []idlrpc.Option
+		sdks     []idlrpc.ISDK
+		tickRate time.Duration
+		latency  time.Duration
+		loss     float64
+		seed     int64
+	}
+
+	// Cluster N rpc nodes, every node is connected to every node including itself
+	Cluster struct {
+		nodes     []*Node
+		stop      chan struct{}
+		wg        sync.WaitGroup
+		closeOnce sync.Once
+		randMu    sync.Mutex
+		rand      *rand.Rand
+		delivered uint64
+		dropped   uint64
+	}
+
+	// Node rpc instance of cluster, ticked and fed by its own goroutine
+	Node struct {
+		index int
+		rpc   idlrpc.IRpc
+		trans []*memTransport // transport to peer, indexed by peer
+		links []*link         // link to peer, indexed by peer
+		inbox chan delivery
+	}
+
+	delivery struct {
+		trans *memTransport
+		pkg   []byte
+	}
+)
+
+// WithRpcOptions options of every node
+func WithRpcOptions(opts ...idlrpc.Option) Option {
+	return func(o *options) {
+		o.rpcOpts = append(o.rpcOpts, opts...)
+	}
+}
+
+// WithSDK sdk registered to every node before start
+func WithSDK(sdks ...idlrpc.ISDK) Option {
+	return func(o *options) {
+		o.sdks = append(o.sdks, sdks...)
+	}
+}
+
+// WithTickRate interval of node Tick, default 1ms
+func WithTickRate(rate time.Duration) Option {
+	return func(o *options) {
+		if rate > 0 {
+			o.tickRate = rate
+		}
+	}
+}
+
+// WithLatency latency of every link
+func WithLatency(latency time.Duration) Option {
+	return func(o *options) {
+		o.latency = latency
+	}
+}
+
+// WithLoss rate of packages dropped on every link, 0 to 1
+func WithLoss(rate float64) Option {
+	return func(o *options) {
+		o.loss = rate
+	}
+}
+
+// WithSeed seed of random packet loss
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+	}
+}
+
+// NewCluster create and start n connected nodes, Close it after test
+func NewCluster(n int, opts ...Option) (*Cluster, error) {
+	if n <= 0 {
+		return nil, errors.NewRpcError(errors.CommErr, "invalid node number %d", n)
+	}
+	o := &options{tickRate: defaultTickRate, seed: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	c := &Cluster{
+		stop: make(chan struct{}),
+		rand: rand.New(rand.NewSource(o.seed)),
+	}
+	for i := 0; i < n; i++ {
+		node := &Node{
+			index: i,
+			rpc:   idlrpc.CreateRpcFramework(),
+			trans: make([]*memTransport, n),
+			links: make([]*link, n),
+			inbox: make(chan delivery, 1024),
+		}
+		for peer := 0; peer < n; peer++ {
+			node.trans[peer] = newMemTransport(peer)
+		}
+		c.nodes = append(c.nodes, node)
+	}
+	// package sent by i to j is received by transport of j to i
+	for _, from := range c.nodes {
+		for _, to := range c.nodes {
+			l := &link{
+				cluster: c,
+				to:      to,
+				trans:   to.trans[from.index],
+				latency: o.latency,
+				loss:    o.loss,
+				notify:  make(chan struct{}, 1),
+			}
+			from.links[to.index] = l
+			from.trans[to.index].link = l
+		}
+	}
+
+	for _, node := range c.nodes {
+		if err := node.start(o); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	for _, node := range c.nodes {
+		for _, l := range node.links {
+			c.goRun(l.run)
+		}
+		node := node
+		c.goRun(func(stop chan struct{}) {
+			node.run(o.tickRate, stop)
+		})
+	}
+	return c, nil
+}
+
+func (c *Cluster) goRun(run func(stop chan struct{})) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		run(c.stop)
+	}()
+}
+
+func (c *Cluster) random() float64 {
+	c.randMu.Lock()
+	defer c.randMu.Unlock()
+	return c.rand.Float64()
+}
+
+// Size number of nodes
+func (c *Cluster) Size() int {
+	return len(c.nodes)
+}
+
+// Node node of index, panic if out of range
+func (c *Cluster) Node(index int) *Node {
+	return c.nodes[index]
+}
+
+// SetLatency latency of link from one node to another
+func (c *Cluster) SetLatency(from, to int, latency time.Duration) {
+	l := c.nodes[from].links[to]
+	l.mu.Lock()
+	l.latency = latency
+	l.mu.Unlock()
+}
+
+// SetLoss packet loss rate of link from one node to another, 0 to 1
+func (c *Cluster) SetLoss(from, to int, rate float64) {
+	l := c.nodes[from].links[to]
+	l.mu.Lock()
+	l.loss = rate
+	l.mu.Unlock()
+}
+
+// Delivered packages delivered to nodes
+func (c *Cluster) Delivered() uint64 {
+	return atomic.LoadUint64(&c.delivered)
+}
+
+// Dropped packages dropped by lossy links
+func (c *Cluster) Dropped() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+// Close stop pumping and shut down all nodes
+func (c *Cluster) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		c.wg.Wait()
+		for _, node := range c.nodes {
+			for _, trans := range node.trans {
+				trans.Close()
+			}
+			_ = node.rpc.ShutDown()
+		}
+	})
+}
+
+func (n *Node) start(o *options) error {
+	if err := n.rpc.Init(o.rpcOpts...); err != nil {
+		return err
+	}
+	for _, sdk := range o.sdks {
+		if err := sdk.Register(n.rpc); err != nil {
+			return err
+		}
+	}
+	return n.rpc.Start()
+}
+
+// run feed received packages to rpc and tick it, until cluster closed
+func (n *Node) run(tickRate time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(tickRate)
+	defer ticker.Stop()
+	for {
+		select {
+		case d := <-n.inbox:
+			_, _ = d.trans.Write(d.pkg, len(d.pkg))
+			_ = n.rpc.OnMessage(d.trans, context.Background())
+		case <-ticker.C:
+			_ = n.rpc.Tick()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Index index of node in cluster
+func (n *Node) Index() int {
+	return n.index
+}
+
+// Rpc rpc framework of node
+func (n *Node) Rpc() idlrpc.IRpc {
+	return n.rpc
+}
+
+// RegisterService host service on node
+func (n *Node) RegisterService(service idlrpc.IService) error {
+	return n.rpc.RegisterService(service)
+}
+
+// Transport transport of node to peer
+func (n *Node) Transport(peer int) transport.ITransport {
+	return n.trans[peer]
+}
+
+// Proxy proxy of service hosted on peer, peer may be node itself
+func (n *Node) Proxy(peer int, uuid uint64) (idlrpc.IProxy, error) {
+	if peer < 0 || peer >= len(n.trans) {
+		return nil, errors.NewRpcError(errors.CommErr, "node %d not in cluster", peer)
+	}
+	return n.rpc.GetServiceProxy(uuid, n.trans[peer])
+}
@@ -0,0 +1,104 @@
+package idlrpctest_test
+
+import (
+	"context"
+	gerrors "errors"
+	"testing"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/example"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/example/pbdata"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/idlrpctest"
+	rpcerrors "github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+)
+
+// infoCaller TestCaller keeping info
+func infoCaller() *example.MockTestCaller {
+	mock := example.NewMockTestCaller()
+	var info string
+	mock.SetInfoFunc = func(_ context.Context, v string) error {
+		info = v
+		return nil
+	}
+	mock.GetInfoFunc = func(context.Context) (string, error) {
+		return info, nil
+	}
+	return mock
+}
+
+func proxyOf(t *testing.T, c *idlrpctest.Cluster, from, to int) *example.TestCallerProxy {
+	p, err := c.Node(from).Proxy(to, example.SrvUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*example.TestCallerProxy)
+}
+
+func TestCluster(t *testing.T) {
+	c, err := idlrpctest.NewCluster(3, idlrpctest.WithSDK(&example.TestCallerSDK{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	service := infoCaller()
+	if err = c.Node(1).RegisterService(service); err != nil {
+		t.Fatal(err)
+	}
+
+	// every node reaches service on node 1, node 1 through loopback
+	for from := 0; from < c.Size(); from++ {
+		sp := proxyOf(t, c, from, 1)
+		if err = sp.SetInfo("hello"); err != nil {
+			t.Fatalf("node %d call error %v", from, err)
+		}
+		if info, err := sp.GetInfo(); err != nil || info != "hello" {
+			t.Fatalf("node %d get info %q error %v", from, info, err)
+		}
+	}
+	if service.Calls("SetInfo") != 3 || c.Delivered() != 12 {
+		t.Fatalf("calls %d delivered %d", service.Calls("SetInfo"), c.Delivered())
+	}
+	for _, call := range service.CallsOf("SetInfo") {
+		if len(call.Args) != 1 || call.Args[0] != "hello" {
+			t.Fatalf("set info arguments %v", call.Args)
+		}
+	}
+
+	// slow link
+	c.SetLatency(0, 1, 50*time.Millisecond)
+	start := time.Now()
+	if err = proxyOf(t, c, 0, 1).SetInfo("slow"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("call on slow link takes %v", elapsed)
+	}
+
+	// lost request times out, other links still work
+	c.SetLoss(2, 1, 1)
+	sp := proxyOf(t, c, 2, 1)
+	if _, err = c.Node(2).Rpc().Call(sp, 1, 50, 0, &pbdata.TestCaller_SetInfoArgs{Arg1: "lost"}); !gerrors.Is(err, rpcerrors.ErrRpcTimeOut) {
+		t.Fatalf("expect timeout, got %v", err)
+	}
+	if c.Dropped() != 1 {
+		t.Fatalf("dropped %d", c.Dropped())
+	}
+	if info, _ := proxyOf(t, c, 0, 1).GetInfo(); info != "slow" {
+		t.Fatalf("get info %q", info)
+	}
+}
+
+func TestClusterInvalid(t *testing.T) {
+	if _, err := idlrpctest.NewCluster(0); err == nil {
+		t.Fatal("empty cluster created")
+	}
+	c, err := idlrpctest.NewCluster(1, idlrpctest.WithRpcOptions(idlrpc.WithCallTimeout(10, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err = c.Node(0).Proxy(1, example.SrvUUID); err == nil {
+		t.Fatal("proxy to node out of cluster")
+	}
+}
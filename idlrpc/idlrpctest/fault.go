@@ -0,0 +1,208 @@
+package idlrpctest
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/transport"
+)
+
+// FaultAction fault injected into sent package
+type FaultAction int
+
+const (
+	FaultDelay     FaultAction = iota // send after Delay
+	FaultDrop                         // do not send
+	FaultDuplicate                    // send twice
+	FaultTruncate                     // send first Keep bytes, half of package if Keep is 0
+	FaultReorder                      // hold package, send it after next package or on Flush
+	FaultClose                        // close transport abruptly, package is not sent
+)
+
+type (
+	// FaultRule inject Action into sent packages matching all non zero conditions.
+	// Service and Method only match call requests
+	FaultRule struct {
+		MsgType uint32        // message type, protocol.RequestMsg etc.
+		Service uint64        // service uuid of request
+		Method  uint32        // method id of request
+		Rate    float64       // probability of matched package to be injected, 0 means always
+		Times   int           // max injected times, 0 means unlimited
+		Action  FaultAction   //
+		Delay   time.Duration // delay of FaultDelay
+		Keep    int           // kept bytes of FaultTruncate
+		hits    int32
+	}
+
+	// FaultTransport decorator of transport injecting faults into Send by rules, first matched rule wins.
+	// it has its own transport id, set different id to get proxy besides the one of wrapped transport
+	FaultTransport struct {
+		transport.ITransport
+		id    uint32
+		wire  protocol.Protocol
+		mu    sync.Mutex
+		rules []*FaultRule
+		held  []byte // package held by FaultReorder
+		rand  *rand.Rand
+	}
+)
+
+// Hits times rule injected
+func (rule *FaultRule) Hits() int {
+	return int(atomic.LoadInt32(&rule.hits))
+}
+
+// NewFaultTransport wrap trans with fault rules
+func NewFaultTransport(trans transport.ITransport, rules ...*FaultRule) *FaultTransport {
+	wire := protocol.Default()
+	if pt, ok := trans.(transport.IProtocolTransport); ok && pt.Protocol() != nil {
+		wire = pt.Protocol()
+	}
+	return &FaultTransport{
+		ITransport: trans,
+		id:         trans.GetID(),
+		wire:       wire,
+		rules:      rules,
+		rand:       rand.New(rand.NewSource(1)),
+	}
+}
+
+// AddRule append rule, it is checked after existing rules
+func (f *FaultTransport) AddRule(rule *FaultRule) {
+	f.mu.Lock()
+	f.rules = append(f.rules, rule)
+	f.mu.Unlock()
+}
+
+// ClearRules remove all rules, held package is sent
+func (f *FaultTransport) ClearRules() {
+	f.mu.Lock()
+	f.rules = nil
+	f.mu.Unlock()
+	f.Flush()
+}
+
+// Flush send package held by FaultReorder
+func (f *FaultTransport) Flush() {
+	f.mu.Lock()
+	held := f.held
+	f.held = nil
+	f.mu.Unlock()
+	if held != nil {
+		_ = f.ITransport.Send(held)
+	}
+}
+
+func (f *FaultTransport) GetID() uint32 {
+	return atomic.LoadUint32(&f.id)
+}
+
+func (f *FaultTransport) SetID(id uint32) {
+	atomic.StoreUint32(&f.id, id)
+}
+
+// Protocol wire format of wrapped transport
+func (f *FaultTransport) Protocol() protocol.Protocol {
+	if pt, ok := f.ITransport.(transport.IProtocolTransport); ok {
+		return pt.Protocol()
+	}
+	return nil
+}
+
+// CopyOnSend packages kept by faults are copied, same as wrapped transport otherwise
+func (f *FaultTransport) CopyOnSend() bool {
+	cs, ok := f.ITransport.(transport.ICopySender)
+	return ok && cs.CopyOnSend()
+}
+
+func (f *FaultTransport) Send(pkg []byte) error {
+	if f.IsClose() {
+		return errors.ErrTransClose
+	}
+	rule := f.match(pkg)
+	if rule == nil {
+		err := f.ITransport.Send(pkg)
+		f.Flush()
+		return err
+	}
+
+	switch rule.Action {
+	case FaultDelay:
+		pkg = append([]byte(nil), pkg...)
+		time.AfterFunc(rule.Delay, func() {
+			_ = f.ITransport.Send(pkg)
+		})
+	case FaultDrop:
+	case FaultDuplicate:
+		dup := append([]byte(nil), pkg...)
+		if err := f.ITransport.Send(pkg); err != nil {
+			return err
+		}
+		return f.ITransport.Send(dup)
+	case FaultTruncate:
+		keep := rule.Keep
+		if keep <= 0 || keep > len(pkg) {
+			keep = len(pkg) / 2
+		}
+		return f.ITransport.Send(append([]byte(nil), pkg[:keep]...))
+	case FaultReorder:
+		f.mu.Lock()
+		prev := f.held
+		f.held = append([]byte(nil), pkg...)
+		f.mu.Unlock()
+		if prev != nil {
+			return f.ITransport.Send(prev)
+		}
+	case FaultClose:
+		f.ITransport.Close()
+		return errors.ErrTransClose
+	}
+	return nil
+}
+
+// match first rule matching pkg, hits of rule is counted
+func (f *FaultTransport) match(pkg []byte) *FaultRule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.rules) == 0 {
+		return nil
+	}
+
+	header := &protocol.RpcMsgHeader{}
+	if !f.wire.ReadHeader(pkg, header) {
+		return nil
+	}
+	var service uint64
+	var method uint32
+	switch header.Type {
+	case protocol.RequestMsg:
+		req := &protocol.RpcCallHeader{}
+		if f.wire.ParseReqMsg(pkg, req) {
+			service, method = req.ServiceUUID, req.MethodID
+		}
+	case protocol.ProxyRequestMsg:
+		req := &protocol.RpcProxyCallHeader{}
+		if f.wire.ParseProxyReqMsg(pkg, req) {
+			service, method = req.ServiceUUID, req.MethodID
+		}
+	}
+
+	for _, rule := range f.rules {
+		if rule.MsgType != 0 && rule.MsgType != header.Type ||
+			rule.Service != 0 && rule.Service != service ||
+			rule.Method != 0 && rule.Method != method ||
+			rule.Times > 0 && rule.Hits() >= rule.Times {
+			continue
+		}
+		if rule.Rate > 0 && f.rand.Float64() >= rule.Rate {
+			continue
+		}
+		atomic.AddInt32(&rule.hits, 1)
+		return rule
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package idlrpctest_test
+
+import (
+	"context"
+	gerrors "errors"
+	"testing"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/example"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/example/pbdata"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/idlrpctest"
+	rpcerrors "github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+// packReq call request of TestCaller method
+func packReq(method, callId uint32) []byte {
+	body, _ := proto.Marshal(&pbdata.TestCaller_SetInfoArgs{Arg1: "fault"})
+	pkg, _ := protocol.PackReqMsg(&protocol.RequestPackage{
+		Header: &protocol.RpcCallHeader{
+			RpcMsgHeader: protocol.RpcMsgHeader{Length: uint32(protocol.CallHeadSize + len(body)), Type: protocol.RequestMsg},
+			ServiceUUID:  example.SrvUUID,
+			CallID:       callId,
+			MethodID:     method,
+		},
+		Buffer: body,
+	})
+	return pkg
+}
+
+// popCall call id of next sent request, 0 if nothing sent in time
+func popCall(trans *example.TransportRing, wait time.Duration) uint32 {
+	sent := make(chan []byte, 1)
+	go func() {
+		sent <- trans.PopSend()
+	}()
+	select {
+	case pkg := <-sent:
+		header := &protocol.RpcCallHeader{}
+		if !protocol.Default().ParseReqMsg(pkg, header) || int(header.Length) != len(pkg) {
+			return 0xFFFFFFFF
+		}
+		return header.CallID
+	case <-time.After(wait):
+		// leave goroutine to drain next package
+		return 0
+	}
+}
+
+func TestFaultActions(t *testing.T) {
+	ring := example.NewTransportRing()
+	fault := idlrpctest.NewFaultTransport(ring)
+	send := func(method, callId uint32) error {
+		return fault.Send(packReq(method, callId))
+	}
+
+	// delay only matched method
+	delay := &idlrpctest.FaultRule{Method: 2, Action: idlrpctest.FaultDelay, Delay: 30 * time.Millisecond, Times: 1}
+	fault.AddRule(delay)
+	_ = send(2, 1)
+	_ = send(1, 2)
+	if id := popCall(ring, time.Second); id != 2 {
+		t.Fatalf("first sent %d, expect 2", id)
+	}
+	if id := popCall(ring, time.Second); id != 1 || delay.Hits() != 1 {
+		t.Fatalf("delayed call %d hits %d", id, delay.Hits())
+	}
+
+	// drop and duplicate by message type
+	fault.ClearRules()
+	fault.AddRule(&idlrpctest.FaultRule{MsgType: protocol.RequestMsg, Action: idlrpctest.FaultDrop, Times: 1})
+	fault.AddRule(&idlrpctest.FaultRule{MsgType: protocol.RequestMsg, Action: idlrpctest.FaultDuplicate, Times: 1})
+	_ = send(1, 3)
+	_ = send(1, 4)
+	if popCall(ring, time.Second) != 4 || popCall(ring, time.Second) != 4 {
+		t.Fatal("request not dropped then duplicated")
+	}
+
+	// reorder swaps with next package
+	fault.ClearRules()
+	fault.AddRule(&idlrpctest.FaultRule{Action: idlrpctest.FaultReorder, Times: 1})
+	_ = send(1, 5)
+	_ = send(1, 6)
+	if popCall(ring, time.Second) != 6 || popCall(ring, time.Second) != 5 {
+		t.Fatal("request not reordered")
+	}
+
+	// truncated frame
+	fault.ClearRules()
+	fault.AddRule(&idlrpctest.FaultRule{Service: example.SrvUUID, Action: idlrpctest.FaultTruncate, Keep: protocol.CallHeadSize})
+	_ = send(1, 7)
+	if id := popCall(ring, time.Second); id != 0xFFFFFFFF {
+		t.Fatalf("frame not truncated %d", id)
+	}
+
+	// abrupt close
+	fault.ClearRules()
+	fault.AddRule(&idlrpctest.FaultRule{Action: idlrpctest.FaultClose})
+	if err := send(1, 8); err != rpcerrors.ErrTransClose || !ring.IsClose() {
+		t.Fatalf("transport not closed, send error %v", err)
+	}
+}
+
+func TestFaultRetry(t *testing.T) {
+	c, err := idlrpctest.NewCluster(2, idlrpctest.WithSDK(&example.TestCallerSDK{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	service := infoCaller()
+	_ = c.Node(1).RegisterService(service)
+
+	drop := &idlrpctest.FaultRule{Service: example.SrvUUID, Method: 1, Action: idlrpctest.FaultDrop, Times: 1}
+	fault := idlrpctest.NewFaultTransport(c.Node(0).Transport(1), drop)
+	fault.SetID(100)
+	caller := c.Node(0).Rpc()
+	sp, err := caller.GetServiceProxy(example.SrvUUID, fault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := &pbdata.TestCaller_SetInfoArgs{Arg1: "retry"}
+
+	// first request is lost, retry reaches service
+	if _, err = caller.Call(sp, 1, 50, 1, args); err != nil {
+		t.Fatalf("retry call error %v", err)
+	}
+	if drop.Hits() != 1 || service.Calls("SetInfo") != 1 {
+		t.Fatalf("hits %d calls %d", drop.Hits(), service.Calls("SetInfo"))
+	}
+
+	// no retry left
+	fault.AddRule(&idlrpctest.FaultRule{Method: 1, Action: idlrpctest.FaultDrop, Times: 1})
+	if _, err = caller.Call(sp, 1, 50, 0, args); !gerrors.Is(err, rpcerrors.ErrRpcTimeOut) {
+		t.Fatalf("expect timeout, got %v", err)
+	}
+
+	// duplicated request runs twice, caller gets one result
+	fault.AddRule(&idlrpctest.FaultRule{Method: 1, Action: idlrpctest.FaultDuplicate, Times: 1})
+	if _, err = caller.Call(sp, 1, 1000, 0, args); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for service.Calls("SetInfo") != 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if service.Calls("SetInfo") != 3 {
+		t.Fatalf("calls %d, expect 3", service.Calls("SetInfo"))
+	}
+}
+
+func TestFaultCloseOutsideProxy(t *testing.T) {
+	c, err := idlrpctest.NewCluster(2, idlrpctest.WithSDK(&example.TestCallerSDK{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// service keeps proxy of outside caller
+	backend := c.Node(1).Rpc()
+	outside := make(chan idlrpc.IProxy, 1)
+	service := example.NewMockTestCaller()
+	service.SetInfoFunc = func(ctx context.Context, _ string) error {
+		p, err := backend.GetProxyFromPeer(ctx, example.SrvUUID)
+		outside <- p
+		return err
+	}
+	_ = c.Node(1).RegisterService(service)
+
+	// node 0 plays gateway, notice of broken connection is delayed and duplicated
+	delay := &idlrpctest.FaultRule{MsgType: protocol.RpcTimeout, Action: idlrpctest.FaultDelay, Delay: 50 * time.Millisecond, Times: 1}
+	dup := &idlrpctest.FaultRule{MsgType: protocol.RpcTimeout, Action: idlrpctest.FaultDuplicate}
+	gateway := idlrpctest.NewFaultTransport(c.Node(0).Transport(1), delay, dup)
+
+	const globalIndex = 7
+	body, _ := proto.Marshal(&pbdata.TestCaller_SetInfoArgs{Arg1: "outside"})
+	req, _ := protocol.PackProxyReqMsg(&protocol.ProxyRequestPackage{
+		Header: &protocol.RpcProxyCallHeader{
+			RpcMsgHeader: protocol.RpcMsgHeader{Length: uint32(protocol.ProxyCallHeadSize + len(body)), Type: protocol.ProxyRequestMsg},
+			ServiceUUID:  example.SrvUUID,
+			CallID:       1,
+			MethodID:     1,
+			GlobalIndex:  globalIndex,
+		},
+		Buffer: body,
+	})
+	_ = gateway.Send(req)
+	var sp idlrpc.IProxy
+	select {
+	case sp = <-outside:
+	case <-time.After(time.Second):
+		t.Fatal("proxy call not arrived")
+	}
+	if sp == nil || !sp.IsConnected() || sp.GetGlobalIndex() != globalIndex {
+		t.Fatalf("invalid outside proxy %v", sp)
+	}
+
+	timeout := &protocol.RpcTimeoutHeader{
+		RpcMsgHeader:  protocol.RpcMsgHeader{Type: protocol.RpcTimeout},
+		GlobalIndexId: globalIndex,
+	}
+	timeout.Length = uint32(timeout.HeaderSize())
+	notice, _ := protocol.Default().PackPlatoMsg(timeout, nil, 0)
+	for i := 0; i < 2; i++ {
+		// first notice is delayed, second is duplicated
+		_ = gateway.Send(append([]byte(nil), notice...))
+	}
+	if !sp.IsConnected() {
+		t.Fatal("outside proxy closed before notice arrived")
+	}
+	deadline := time.Now().Add(time.Second)
+	for sp.IsConnected() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if sp.IsConnected() || delay.Hits() != 1 || dup.Hits() != 1 {
+		t.Fatalf("outside proxy connected %v, hits %d %d", sp.IsConnected(), delay.Hits(), dup.Hits())
+	}
+	if _, err = backend.Call(sp, 1, 50, 0, &pbdata.TestCaller_SetInfoArgs{}); err == nil {
+		t.Fatal("call on closed outside proxy")
+	}
+
+	// abrupt close of gateway connection
+	gateway.AddRule(&idlrpctest.FaultRule{Action: idlrpctest.FaultClose})
+	if err = gateway.Send(req); err != rpcerrors.ErrTransClose {
+		t.Fatalf("expect closed transport, got %v", err)
+	}
+	if _, err = c.Node(0).Rpc().GetServiceProxy(example.SrvUUID, gateway); err == nil {
+		t.Fatal("proxy created on closed transport")
+	}
+}
@@ -0,0 +1,186 @@
+// Package replay replays recorded service traffic against a rpc instance and diffs responses,
+// fixtures are capture files written by capture.Transport on service side
+package replay
+
+import (
+	"io"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/capture"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+)
+
+type (
+	// Call recorded request hitting service and response of it
+	Call struct {
+		Time        time.Time
+		Conn        uint32                   // connection id in capture
+		Type        uint32                   // RequestMsg or ProxyRequestMsg
+		ServiceUUID uint64                   // called service
+		MethodID    uint32                   // called method
+		CallID      uint32                   // call id of caller
+		GlobalIndex protocol.GlobalIndexType // global index of proxy request
+		Request     []byte                   // request frame
+		Response    []byte                   // response frame, nil for oneway method or unanswered call
+	}
+
+	// Fixture calls in order of arrival
+	Fixture struct {
+		Calls []*Call
+	}
+
+	// callKey response is matched to request by it
+	callKey struct {
+		conn   uint32
+		callID uint32
+		global protocol.GlobalIndexType
+	}
+)
+
+func (c *Call) key() callKey {
+	return callKey{c.Conn, c.CallID, c.GlobalIndex}
+}
+
+// Load read fixture from capture file
+func Load(path string) (*Fixture, error) {
+	r, err := capture.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return Read(r)
+}
+
+// Read collect requests received by service and responses sent back,
+// other frames in capture are skipped
+func Read(r *capture.Reader) (*Fixture, error) {
+	f := &Fixture{}
+	pending := make(map[callKey]*Call)
+	for {
+		p, err := r.Next()
+		if err == io.EOF {
+			return f, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch p.Dir {
+		case capture.In:
+			call := parseRequest(p.Data)
+			if call == nil {
+				continue
+			}
+			call.Time, call.Conn = p.Time, p.Conn
+			f.Calls = append(f.Calls, call)
+			pending[call.key()] = call
+		case capture.Out:
+			key, ok := parseResponse(p.Data)
+			if !ok {
+				continue
+			}
+			key.conn = p.Conn
+			if call, ok := pending[key]; ok {
+				call.Response = p.Data
+				delete(pending, key)
+			}
+		}
+	}
+}
+
+// Save write fixture as capture file, each request is followed by its response
+func (f *Fixture) Save(path string) error {
+	w, err := capture.Create(path)
+	if err != nil {
+		return err
+	}
+	for _, call := range f.Calls {
+		if err = w.WritePacket(&capture.Packet{Time: call.Time, Dir: capture.In, Conn: call.Conn, Data: call.Request}); err != nil {
+			break
+		}
+		if call.Response == nil {
+			continue
+		}
+		if err = w.WritePacket(&capture.Packet{Time: call.Time, Dir: capture.Out, Conn: call.Conn, Data: call.Response}); err != nil {
+			break
+		}
+	}
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// NewCall build call from request frame
+func NewCall(conn uint32, request []byte) (*Call, error) {
+	call := parseRequest(request)
+	if call == nil {
+		return nil, errors.NewRpcError(errors.CommErr, "not a request frame")
+	}
+	call.Time, call.Conn = time.Now(), conn
+	return call, nil
+}
+
+func parseRequest(frame []byte) *Call {
+	header := protocol.ReadHeader(frame)
+	if header == nil || int(header.Length) != len(frame) {
+		return nil
+	}
+	switch header.Type {
+	case protocol.RequestMsg:
+		ch := &protocol.RpcCallHeader{}
+		if !protocol.ParseCallHeader(frame, ch) {
+			return nil
+		}
+		return &Call{Type: header.Type, ServiceUUID: ch.ServiceUUID, MethodID: ch.MethodID, CallID: ch.CallID, Request: frame}
+	case protocol.ProxyRequestMsg:
+		ph := &protocol.RpcProxyCallHeader{}
+		if !protocol.ParseProxyCallHeader(frame, ph) {
+			return nil
+		}
+		return &Call{Type: header.Type, ServiceUUID: ph.ServiceUUID, MethodID: ph.MethodID, CallID: ph.CallID, GlobalIndex: ph.GlobalIndex, Request: frame}
+	}
+	return nil
+}
+
+// parseResponse key of response frame, conn is not set
+func parseResponse(frame []byte) (key callKey, ok bool) {
+	header := protocol.ReadHeader(frame)
+	if header == nil || int(header.Length) != len(frame) {
+		return
+	}
+	switch header.Type {
+	case protocol.ResponseMsg:
+		rh := &protocol.RpcCallRetHeader{}
+		if protocol.ParseRetHeader(frame, rh) {
+			return callKey{callID: rh.CallID}, true
+		}
+	case protocol.ProxyResponseMsg:
+		rh := &protocol.RpcProxyCallRetHeader{}
+		if protocol.ParseProxyRetHeader(frame, rh) {
+			return callKey{callID: rh.CallID, global: rh.GlobalIndex}, true
+		}
+	}
+	return
+}
+
+// responseBody error code and body of response frame
+func responseBody(frame []byte) (code uint32, body []byte, ok bool) {
+	header := protocol.ReadHeader(frame)
+	if header == nil {
+		return
+	}
+	switch header.Type {
+	case protocol.ResponseMsg:
+		rh := &protocol.RpcCallRetHeader{}
+		if protocol.ParseRetHeader(frame, rh) {
+			return rh.ErrorCode, frame[protocol.RespHeadSize:], true
+		}
+	case protocol.ProxyResponseMsg:
+		rh := &protocol.RpcProxyCallRetHeader{}
+		if protocol.ParseProxyRetHeader(frame, rh) {
+			return rh.ErrorCode, frame[protocol.ProxyRetHeadSize:], true
+		}
+	}
+	return
+}
@@ -0,0 +1,277 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type (
+	// Rule how response of matched method is compared, volatile fields are ignored by it
+	Rule struct {
+		Service uint64   // service uuid, 0 matches any
+		Method  uint32   // method id, 0 matches any
+		Ignore  []string // field paths of ret message not compared, "1" or "1.2" for field of nested message
+		// Equal compare body of responses instead of bytes, ignored fields are already removed
+		Equal func(want, got []byte) bool
+	}
+
+	// Result replay result of one call
+	Result struct {
+		Call *Call
+		Got  []byte // response frame of replay, nil for none
+		Diff string // why response differs, empty while matched
+	}
+
+	// Report results in order of calls
+	Report struct {
+		Results []*Result
+	}
+
+	Option func(*options)
+
+	options struct {
+		rules   []*Rule
+		timeout time.Duration
+	}
+)
+
+// WithRules add compare rules, all matched rules are applied
+func WithRules(rules ...*Rule) Option {
+	return func(o *options) {
+		o.rules = append(o.rules, rules...)
+	}
+}
+
+// WithTimeout how long to wait for response of each call, default 1s
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+func (r *Rule) match(call *Call) bool {
+	return (r.Service == 0 || r.Service == call.ServiceUUID) && (r.Method == 0 || r.Method == call.MethodID)
+}
+
+// Replay feed requests of fixture to started rpc one by one, and diff responses with recorded ones.
+// each recorded connection is replaced by an in memory transport, rpc is ticked while waiting response,
+// so service of tick mode works too
+func Replay(rpc idlrpc.IRpc, f *Fixture, opts ...Option) (*Report, error) {
+	o := &options{timeout: time.Second}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	conns := make(map[uint32]*replayTransport)
+	report := &Report{}
+	for _, call := range f.Calls {
+		trans, ok := conns[call.Conn]
+		if !ok {
+			trans = newReplayTransport(call.Conn)
+			conns[call.Conn] = trans
+		}
+		_, _ = trans.Write(call.Request, len(call.Request))
+		if err := rpc.OnMessage(trans, context.Background()); err != nil {
+			return report, errors.NewRpcError(errors.CommErr, "replay call %d of service %d method %d error %v", call.CallID, call.ServiceUUID, call.MethodID, err)
+		}
+
+		result := &Result{Call: call}
+		if call.Response != nil {
+			result.Got = wait(rpc, trans, call.key(), o.timeout)
+		} else {
+			// oneway or unanswered, response sent so far is unexpected
+			result.Got, _ = trans.take(call.key())
+		}
+		result.Diff = o.diff(call, result.Got)
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// wait response of call until timeout
+func wait(rpc idlrpc.IRpc, trans *replayTransport, key callKey, timeout time.Duration) []byte {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if resp, ok := trans.take(key); ok {
+			return resp
+		}
+		select {
+		case <-trans.notify:
+		case <-ticker.C:
+			_ = rpc.Tick()
+		case <-deadline.C:
+			return nil
+		}
+	}
+}
+
+// diff compare recorded response with replayed one
+func (o *options) diff(call *Call, got []byte) string {
+	if call.Response == nil || got == nil {
+		switch {
+		case call.Response == nil && got == nil:
+			return ""
+		case got == nil:
+			return "no response"
+		default:
+			return "unexpected response"
+		}
+	}
+
+	wantCode, want, ok1 := responseBody(call.Response)
+	gotCode, body, ok2 := responseBody(got)
+	if !ok1 || !ok2 {
+		return "broken response frame"
+	}
+	if wantCode != gotCode {
+		return fmt.Sprintf("error code %d, want %d", gotCode, wantCode)
+	}
+
+	var equal func(want, got []byte) bool
+	for _, rule := range o.rules {
+		if !rule.match(call) {
+			continue
+		}
+		for _, path := range rule.Ignore {
+			want, body = strip(want, path), strip(body, path)
+		}
+		if rule.Equal != nil {
+			equal = rule.Equal
+		}
+	}
+	if equal != nil {
+		if equal(want, body) {
+			return ""
+		}
+		return "body differs"
+	}
+	if bytes.Equal(want, body) {
+		return ""
+	}
+	return diffFields(want, body)
+}
+
+// strip remove field of path from serialized message
+func strip(msg []byte, path string) []byte {
+	num, rest := path, ""
+	if i := strings.IndexByte(path, '.'); i >= 0 {
+		num, rest = path[:i], path[i+1:]
+	}
+	n, err := strconv.ParseUint(num, 10, 32)
+	if err != nil {
+		return msg
+	}
+
+	out := make([]byte, 0, len(msg))
+	for len(msg) > 0 {
+		field, typ, l := protowire.ConsumeField(msg)
+		if l < 0 {
+			// not a message, leave the rest as it is
+			return append(out, msg...)
+		}
+		if field != protowire.Number(n) {
+			out = append(out, msg[:l]...)
+		} else if rest != "" && typ == protowire.BytesType {
+			_, _, tl := protowire.ConsumeTag(msg)
+			value, _ := protowire.ConsumeBytes(msg[tl:])
+			out = protowire.AppendTag(out, field, typ)
+			out = protowire.AppendBytes(out, strip(value, rest))
+		}
+		msg = msg[l:]
+	}
+	return out
+}
+
+// diffFields numbers of top level fields which differ
+func diffFields(want, got []byte) string {
+	wf, gf := fields(want), fields(got)
+	if wf == nil || gf == nil {
+		return "body differs"
+	}
+	var nums []int
+	for num, value := range wf {
+		if !bytes.Equal(value, gf[num]) {
+			nums = append(nums, int(num))
+		}
+	}
+	for num := range gf {
+		if _, ok := wf[num]; !ok {
+			nums = append(nums, int(num))
+		}
+	}
+	if len(nums) == 0 {
+		// same fields in different order, map entries for example
+		return "field order differs"
+	}
+	sort.Ints(nums)
+	diff := make([]string, 0, len(nums))
+	for _, num := range nums {
+		diff = append(diff, strconv.Itoa(num))
+	}
+	return "field " + strings.Join(diff, ",") + " differs"
+}
+
+// fields raw bytes of each field number, nil while msg is broken
+func fields(msg []byte) map[protowire.Number][]byte {
+	res := make(map[protowire.Number][]byte)
+	for len(msg) > 0 {
+		num, _, l := protowire.ConsumeField(msg)
+		if l < 0 {
+			return nil
+		}
+		res[num] = append(res[num], msg[:l]...)
+		msg = msg[l:]
+	}
+	return res
+}
+
+// Failed results whose response differs
+func (r *Report) Failed() []*Result {
+	var failed []*Result
+	for _, result := range r.Results {
+		if result.Diff != "" {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err describe differences, nil while all responses match
+func (r *Report) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(failed))
+	for _, result := range failed {
+		lines = append(lines, result.String())
+	}
+	return errors.NewRpcError(errors.CommErr, "%d of %d calls differ:\n%s", len(failed), len(r.Results), strings.Join(lines, "\n"))
+}
+
+// Fixture calls with replayed responses, save it to update fixture file
+func (r *Report) Fixture() *Fixture {
+	f := &Fixture{Calls: make([]*Call, 0, len(r.Results))}
+	for _, result := range r.Results {
+		call := *result.Call
+		call.Response = result.Got
+		f.Calls = append(f.Calls, &call)
+	}
+	return f
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("conn %d call %d service %d method %d: %s", r.Call.Conn, r.Call.CallID, r.Call.ServiceUUID, r.Call.MethodID, r.Diff)
+}
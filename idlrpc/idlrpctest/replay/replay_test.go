@@ -0,0 +1,125 @@
+package replay_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/example"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/example/pbdata"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/idlrpctest/replay"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/capture"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+// startCaller start rpc hosting TestCaller, GetInfo returns prefix + info
+func startCaller(t *testing.T, prefix string) idlrpc.IRpc {
+	rpc := idlrpc.CreateRpcFramework()
+	_ = rpc.Init()
+	_ = (&example.TestCallerSDK{}).Register(rpc)
+	_ = rpc.Start()
+	service := example.NewMockTestCaller()
+	var info string
+	service.SetInfoFunc = func(_ context.Context, v string) error {
+		info = v
+		return nil
+	}
+	service.GetInfoFunc = func(context.Context) (string, error) {
+		return prefix + info, nil
+	}
+	if err := rpc.RegisterService(service); err != nil {
+		t.Fatal(err)
+	}
+	return rpc
+}
+
+func request(callID, methodID uint32, global protocol.GlobalIndexType, arg proto.Message) []byte {
+	body, _ := proto.Marshal(arg)
+	header := &protocol.RpcCallHeader{
+		RpcMsgHeader: protocol.RpcMsgHeader{Length: uint32(protocol.CallHeadSize + len(body)), Type: protocol.RequestMsg},
+		ServiceUUID:  example.SrvUUID,
+		CallID:       callID,
+		MethodID:     methodID,
+	}
+	if global == 0 {
+		pkg, _ := protocol.PackReqMsg(&protocol.RequestPackage{Header: header, Buffer: body})
+		return pkg
+	}
+	pkg, _ := protocol.PackProxyReqMsg(&protocol.ProxyRequestPackage{Header: protocol.BuildProxyCallHeader(header, global), Buffer: body})
+	return pkg
+}
+
+func TestRecordReplay(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "replay")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "caller.cap")
+
+	// record traffic hitting service
+	rpc := startCaller(t, "v1:")
+	w, err := capture.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring := example.NewTransportRing()
+	trans := capture.NewTransport(ring, w)
+	for _, req := range [][]byte{
+		request(1, 1, 0, &pbdata.TestCaller_SetInfoArgs{Arg1: "hello"}),
+		request(2, 2, 0, &pbdata.TestCaller_GetInfoArgs{}),
+		request(3, 2, 7, &pbdata.TestCaller_GetInfoArgs{}),
+	} {
+		_, _ = trans.Write(req, len(req))
+		_ = rpc.OnMessage(trans, context.Background())
+		ring.PopSend()
+	}
+	_ = w.Close()
+	_ = rpc.ShutDown()
+
+	f, err := replay.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Calls) != 3 || f.Calls[2].GlobalIndex != 7 {
+		t.Fatalf("load %d calls", len(f.Calls))
+	}
+	for _, call := range f.Calls {
+		if call.Response == nil {
+			t.Fatalf("call %d has no response", call.CallID)
+		}
+	}
+
+	// same build, responses match
+	rpc = startCaller(t, "v1:")
+	report, err := replay.Replay(rpc, f)
+	_ = rpc.ShutDown()
+	if err != nil || report.Err() != nil {
+		t.Fatalf("replay same build, error %v %v", err, report.Err())
+	}
+
+	// new build changes GetInfo
+	rpc = startCaller(t, "v2:")
+	defer rpc.ShutDown()
+	report, _ = replay.Replay(rpc, f)
+	if failed := report.Failed(); len(failed) != 2 || !strings.Contains(failed[0].Diff, "field 1 differs") {
+		t.Fatalf("expect GetInfo differs, got %v", report.Err())
+	}
+	report, _ = replay.Replay(rpc, f, replay.WithRules(&replay.Rule{Service: example.SrvUUID, Method: 2, Ignore: []string{"1"}}))
+	if err = report.Err(); err != nil {
+		t.Fatalf("volatile field not ignored %v", err)
+	}
+
+	// update fixture with new responses
+	if err = report.Fixture().Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = replay.Load(path); err != nil || len(f.Calls) != 3 {
+		t.Fatalf("reload fixture error %v", err)
+	}
+	if report, _ = replay.Replay(rpc, f); report.Err() != nil {
+		t.Fatalf("replay updated fixture %v", report.Err())
+	}
+}
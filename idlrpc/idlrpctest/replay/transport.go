@@ -0,0 +1,131 @@
+package replay
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+)
+
+// replayTransport stands for recorded connection, requests are written into it,
+// responses sent by service are collected by call key
+type replayTransport struct {
+	conn    uint32 // connection id in capture
+	id      uint32
+	isclose uint32
+	mu      sync.Mutex
+	buffer  []byte
+	sent    map[callKey][]byte
+	notify  chan struct{}
+}
+
+func newReplayTransport(conn uint32) *replayTransport {
+	return &replayTransport{
+		conn:   conn,
+		id:     conn,
+		sent:   make(map[callKey][]byte),
+		notify: make(chan struct{}, 1),
+	}
+}
+
+func (trans *replayTransport) Write(pkg []byte, length int) (int, error) {
+	trans.mu.Lock()
+	defer trans.mu.Unlock()
+	trans.buffer = append(trans.buffer, pkg[:length]...)
+	return length, nil
+}
+
+func (trans *replayTransport) Read(pkg []byte, length int) (int, error) {
+	trans.mu.Lock()
+	defer trans.mu.Unlock()
+	if len(trans.buffer) < length {
+		return 0, nil
+	}
+	copy(pkg, trans.buffer[:length])
+	trans.buffer = trans.buffer[length:]
+	return length, nil
+}
+
+func (trans *replayTransport) Peek(length int) ([]byte, int, error) {
+	trans.mu.Lock()
+	defer trans.mu.Unlock()
+	if len(trans.buffer) < length {
+		return nil, len(trans.buffer), nil
+	}
+	return trans.buffer[:length], length, nil
+}
+
+// Send keep copy of response, other packages are ignored
+func (trans *replayTransport) Send(pkg []byte) error {
+	if trans.IsClose() {
+		return errors.ErrTransClose
+	}
+	key, ok := parseResponse(pkg)
+	if !ok {
+		return nil
+	}
+	key.conn = trans.conn
+	trans.mu.Lock()
+	trans.sent[key] = append([]byte(nil), pkg...)
+	trans.mu.Unlock()
+	select {
+	case trans.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// take remove response of call
+func (trans *replayTransport) take(key callKey) ([]byte, bool) {
+	trans.mu.Lock()
+	defer trans.mu.Unlock()
+	resp, ok := trans.sent[key]
+	if ok {
+		delete(trans.sent, key)
+	}
+	return resp, ok
+}
+
+// CopyOnSend pkg is copied by Send
+func (trans *replayTransport) CopyOnSend() bool {
+	return true
+}
+
+func (trans *replayTransport) Close() {
+	atomic.StoreUint32(&trans.isclose, 1)
+}
+
+func (trans *replayTransport) Size() uint32 {
+	trans.mu.Lock()
+	defer trans.mu.Unlock()
+	return uint32(len(trans.buffer))
+}
+
+func (trans *replayTransport) IsClose() bool {
+	return atomic.LoadUint32(&trans.isclose) == 1
+}
+
+func (trans *replayTransport) GetID() uint32 {
+	return atomic.LoadUint32(&trans.id)
+}
+
+func (trans *replayTransport) SetID(id uint32) {
+	atomic.StoreUint32(&trans.id, id)
+}
+
+func (trans *replayTransport) LocalAddr() string {
+	return ""
+}
+
+func (trans *replayTransport) RemoteAddr() string {
+	return ""
+}
+
+func (trans *replayTransport) GlobalIndex() protocol.GlobalIndexType {
+	return 0
+}
+
+func (trans *replayTransport) Heartbeat() error {
+	return nil
+}
@@ -0,0 +1,176 @@
+package idlrpctest
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+)
+
+type (
+	// memTransport in memory transport of node to peer, sent packages travel on link
+	memTransport struct {
+		peer    int
+		id      uint32
+		isclose uint32
+		mu      sync.Mutex
+		buffer  []byte // received bytes, read by node goroutine
+		link    *link  // link to peer
+	}
+
+	// packet package on its way, delivered at due time
+	packet struct {
+		pkg []byte
+		due time.Time
+	}
+
+	// link one direction connection between two nodes, keeps packages in order
+	link struct {
+		cluster *Cluster
+		to      *Node
+		trans   *memTransport // transport of receiving node
+		mu      sync.Mutex
+		latency time.Duration
+		loss    float64
+		queue   []packet
+		notify  chan struct{}
+	}
+)
+
+func newMemTransport(peer int) *memTransport {
+	return &memTransport{peer: peer, id: uint32(peer)}
+}
+
+func (trans *memTransport) Write(pkg []byte, length int) (int, error) {
+	trans.mu.Lock()
+	defer trans.mu.Unlock()
+	trans.buffer = append(trans.buffer, pkg[:length]...)
+	return length, nil
+}
+
+func (trans *memTransport) Read(pkg []byte, length int) (int, error) {
+	trans.mu.Lock()
+	defer trans.mu.Unlock()
+	if len(trans.buffer) < length {
+		return 0, nil
+	}
+	copy(pkg, trans.buffer[:length])
+	trans.buffer = trans.buffer[length:]
+	return length, nil
+}
+
+func (trans *memTransport) Peek(length int) ([]byte, int, error) {
+	trans.mu.Lock()
+	defer trans.mu.Unlock()
+	if len(trans.buffer) < length {
+		return nil, len(trans.buffer), nil
+	}
+	return trans.buffer[:length], length, nil
+}
+
+// Send copy pkg on link to peer, drop it while link is lossy
+func (trans *memTransport) Send(pkg []byte) error {
+	if trans.IsClose() {
+		return errors.ErrTransClose
+	}
+	trans.link.send(append([]byte(nil), pkg...))
+	return nil
+}
+
+// CopyOnSend pkg is copied by Send
+func (trans *memTransport) CopyOnSend() bool {
+	return true
+}
+
+func (trans *memTransport) Close() {
+	atomic.StoreUint32(&trans.isclose, 1)
+}
+
+func (trans *memTransport) Size() uint32 {
+	trans.mu.Lock()
+	defer trans.mu.Unlock()
+	return uint32(len(trans.buffer))
+}
+
+func (trans *memTransport) IsClose() bool {
+	return atomic.LoadUint32(&trans.isclose) == 1
+}
+
+func (trans *memTransport) GetID() uint32 {
+	return atomic.LoadUint32(&trans.id)
+}
+
+func (trans *memTransport) SetID(id uint32) {
+	atomic.StoreUint32(&trans.id, id)
+}
+
+func (trans *memTransport) LocalAddr() string {
+	return ""
+}
+
+func (trans *memTransport) RemoteAddr() string {
+	return ""
+}
+
+func (trans *memTransport) GlobalIndex() protocol.GlobalIndexType {
+	return protocol.GlobalIndexType(trans.peer)
+}
+
+func (trans *memTransport) Heartbeat() error {
+	return nil
+}
+
+func (l *link) send(pkg []byte) {
+	l.mu.Lock()
+	if l.loss > 0 && l.cluster.random() < l.loss {
+		l.mu.Unlock()
+		atomic.AddUint64(&l.cluster.dropped, 1)
+		return
+	}
+	l.queue = append(l.queue, packet{pkg: pkg, due: time.Now().Add(l.latency)})
+	l.mu.Unlock()
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run deliver packages to receiving node in order when they are due
+func (l *link) run(stop chan struct{}) {
+	for {
+		l.mu.Lock()
+		if len(l.queue) == 0 {
+			l.mu.Unlock()
+			select {
+			case <-l.notify:
+				continue
+			case <-stop:
+				return
+			}
+		}
+		p := l.queue[0]
+		l.mu.Unlock()
+
+		if wait := time.Until(p.due); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-stop:
+				timer.Stop()
+				return
+			}
+		}
+		l.mu.Lock()
+		l.queue = l.queue[1:]
+		l.mu.Unlock()
+
+		select {
+		case l.to.inbox <- delivery{trans: l.trans, pkg: p.pkg}:
+			atomic.AddUint64(&l.cluster.delivered, 1)
+		case <-stop:
+			return
+		}
+	}
+}
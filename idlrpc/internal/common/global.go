@@ -1,13 +1,21 @@
 package common
 
-//some global var
+import "time"
 
-var (
+//default settings, rpc instance may override them by options
+
+const (
 	DefaultTimeOut       uint32 = 2000 // to second
 	DefaultCallCache     uint32 = 128
 	DefaultServiceWorker uint32 = 1
 	DefaultServiceCache  uint32 = 8
-	MaxRetryTime         int32  = 5 // max retry time TODO read from config
+	MaxRetryTime         int32  = 5 // max retry time
+
+	DefaultTickCalls uint32 = 128                  // max calls per tick of tick mode service
+	DefaultTickTime         = 5 * time.Millisecond // max time per tick of tick mode service
+
+	DefaultWheelTick  = 10 * time.Millisecond // precision of proxy call timeout
+	DefaultWheelSlots = 512                   // slots of proxy call timing wheel
 )
 
 const InvalidStubId = 0
@@ -0,0 +1,101 @@
+// Package idlclient client calling service described by idl json and protobuf descriptors,
+// without generated proxy, shared by rpccall and rpcbench
+package idlclient
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/idljson"
+	"google.golang.org/protobuf/proto"
+)
+
+const tickInterval = 10 * time.Millisecond
+
+// Client rpc instance connected to one tcp endpoint
+type Client struct {
+	rpc     idlrpc.IRpc
+	trans   *TcpTransport
+	stop    chan struct{}
+	mu      sync.Mutex
+	proxies map[uint64]idlrpc.IProxy
+}
+
+// Dial start rpc with dynamic proxies of services, and connect addr
+func Dial(addr string, timeout time.Duration, services []*idljson.Service, opts ...idlrpc.Option) (*Client, error) {
+	rpc := idlrpc.CreateRpcFramework()
+	if err := rpc.Init(opts...); err != nil {
+		return nil, err
+	}
+	for _, srv := range services {
+		_ = rpc.AddProxyCreator(srv.ID, ProxyCreator(srv))
+	}
+	if err := rpc.Start(); err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		_ = rpc.ShutDown()
+		return nil, err
+	}
+
+	c := &Client{
+		rpc:     rpc,
+		trans:   NewTcpTransport(conn, 1),
+		stop:    make(chan struct{}),
+		proxies: make(map[uint64]idlrpc.IProxy),
+	}
+	go c.trans.Serve(rpc)
+	go c.tick()
+	return c, nil
+}
+
+// Rpc rpc instance of client
+func (c *Client) Rpc() idlrpc.IRpc {
+	return c.rpc
+}
+
+// Call call method of srv, return serialized ret message
+func (c *Client) Call(srv *idljson.Service, m *idljson.Method, timeout uint32, retry int32, args proto.Message) ([]byte, error) {
+	proxy, err := c.proxy(srv)
+	if err != nil {
+		return nil, err
+	}
+	return c.rpc.Call(proxy, m.Index, timeout, retry, args)
+}
+
+func (c *Client) proxy(srv *idljson.Service) (idlrpc.IProxy, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if proxy, ok := c.proxies[srv.ID]; ok {
+		return proxy, nil
+	}
+	proxy, err := c.rpc.GetServiceProxy(srv.ID, c.trans)
+	if err != nil {
+		return nil, err
+	}
+	c.proxies[srv.ID] = proxy
+	return proxy, nil
+}
+
+// Close close connection and shut down rpc
+func (c *Client) Close() {
+	close(c.stop)
+	c.trans.Close()
+	_ = c.rpc.ShutDown()
+}
+
+func (c *Client) tick() {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = c.rpc.Tick()
+		case <-c.stop:
+			return
+		}
+	}
+}
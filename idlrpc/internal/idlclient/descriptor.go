@@ -0,0 +1,127 @@
+package idlclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/idljson"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Messages proto messages of loaded descriptor sets, by message name without package
+type Messages map[string]protoreflect.MessageDescriptor
+
+// Load add descriptor set written by protoc --include_imports --descriptor_set_out
+func (ms Messages) Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err = proto.Unmarshal(data, set); err != nil {
+		return fmt.Errorf("parse descriptor set %s error %v", path, err)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return fmt.Errorf("load descriptor set %s error %v", path, err)
+	}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		ms.add(fd.Messages())
+		return true
+	})
+	return nil
+}
+
+func (ms Messages) add(list protoreflect.MessageDescriptors) {
+	for i := 0; i < list.Len(); i++ {
+		md := list.Get(i)
+		ms[string(md.Name())] = md
+		ms.add(md.Messages())
+	}
+}
+
+// Args args message of method, named <service>_<method>_args by golang_pb_layer.py
+func (ms Messages) Args(srv *idljson.Service, m *idljson.Method) (protoreflect.MessageDescriptor, error) {
+	return ms.find(fmt.Sprintf("%s_%s_args", srv.Name, m.Name))
+}
+
+// Ret return message of method
+func (ms Messages) Ret(srv *idljson.Service, m *idljson.Method) (protoreflect.MessageDescriptor, error) {
+	return ms.find(fmt.Sprintf("%s_%s_ret", srv.Name, m.Name))
+}
+
+func (ms Messages) find(name string) (protoreflect.MessageDescriptor, error) {
+	md, ok := ms[name]
+	if !ok {
+		return nil, fmt.Errorf("message %s not found in descriptors", name)
+	}
+	return md, nil
+}
+
+// BuildArgs args message from json object, or json array of arguments in order
+func BuildArgs(md protoreflect.MessageDescriptor, input string) (proto.Message, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		input = "{}"
+	}
+	if strings.HasPrefix(input, "[") {
+		var values []json.RawMessage
+		if err := json.Unmarshal([]byte(input), &values); err != nil {
+			return nil, fmt.Errorf("invalid arguments %v", err)
+		}
+		obj := make(map[string]json.RawMessage, len(values))
+		for i, v := range values {
+			fd := md.Fields().ByNumber(protoreflect.FieldNumber(i + 1))
+			if fd == nil {
+				return nil, fmt.Errorf("too many arguments, %s has no field %d", md.Name(), i+1)
+			}
+			obj[fd.JSONName()] = v
+		}
+		data, _ := json.Marshal(obj)
+		input = string(data)
+	}
+	msg := dynamicpb.NewMessage(md)
+	opt := protojson.UnmarshalOptions{Resolver: protoregistry.GlobalTypes}
+	if err := opt.Unmarshal([]byte(input), msg); err != nil {
+		return nil, fmt.Errorf("invalid arguments %v", err)
+	}
+	return msg, nil
+}
+
+// FormatRet render return message as json
+func FormatRet(md protoreflect.MessageDescriptor, body []byte) (string, error) {
+	msg := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(body, msg); err != nil {
+		return "", fmt.Errorf("decode %s error %v", md.Name(), err)
+	}
+	data, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(msg)
+	return string(data), err
+}
+
+// FieldType proto spelling of field type
+func FieldType(fd protoreflect.FieldDescriptor) string {
+	name := func(fd protoreflect.FieldDescriptor) string {
+		switch fd.Kind() {
+		case protoreflect.MessageKind, protoreflect.GroupKind:
+			return string(fd.Message().Name())
+		case protoreflect.EnumKind:
+			return string(fd.Enum().Name())
+		}
+		return fd.Kind().String()
+	}
+	switch {
+	case fd.IsMap():
+		return fmt.Sprintf("map<%s, %s>", name(fd.MapKey()), name(fd.MapValue()))
+	case fd.IsList():
+		return "repeated " + name(fd)
+	}
+	return name(fd)
+}
@@ -0,0 +1,45 @@
+package idlclient
+
+import (
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/idljson"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/transport"
+)
+
+// dynamicProxy proxy of service described by idl json
+type dynamicProxy struct {
+	idlrpc.ProxyBase
+	srv *idljson.Service
+}
+
+// ProxyCreator creator of dynamic proxy of srv
+func ProxyCreator(srv *idljson.Service) idlrpc.ProxyCreator {
+	return func(trans transport.ITransport) idlrpc.IProxy {
+		if trans == nil || trans.IsClose() {
+			return nil
+		}
+		p := &dynamicProxy{srv: srv}
+		p.SetTransport(trans)
+		return p
+	}
+}
+
+func (p *dynamicProxy) GetUUID() uint64 {
+	return p.srv.ID
+}
+
+func (p *dynamicProxy) GetSrvName() string {
+	return p.srv.Name
+}
+
+func (p *dynamicProxy) GetSignature(methodId uint32) string {
+	if m := p.srv.Method(methodId); m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (p *dynamicProxy) IsOneWay(methodId uint32) bool {
+	m := p.srv.Method(methodId)
+	return m != nil && m.IsOneway
+}
@@ -0,0 +1,122 @@
+package idlclient
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+)
+
+// TcpTransport transport on tcp connection, received bytes are fed to rpc by Serve
+type TcpTransport struct {
+	conn    net.Conn
+	id      uint32
+	isclose uint32
+	sendMu  sync.Mutex
+	mu      sync.Mutex
+	buffer  []byte
+}
+
+func NewTcpTransport(conn net.Conn, id uint32) *TcpTransport {
+	return &TcpTransport{conn: conn, id: id}
+}
+
+// Serve read connection until it is closed, every read is handed to rpc
+func (t *TcpTransport) Serve(rpc idlrpc.IRpc) error {
+	defer t.Close()
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := t.conn.Read(buf)
+		if n > 0 {
+			_, _ = t.Write(buf, n)
+			if err := rpc.OnMessage(t, context.Background()); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (t *TcpTransport) Write(pkg []byte, length int) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buffer = append(t.buffer, pkg[:length]...)
+	return length, nil
+}
+
+func (t *TcpTransport) Read(pkg []byte, length int) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.buffer) < length {
+		return 0, nil
+	}
+	copy(pkg, t.buffer[:length])
+	t.buffer = t.buffer[length:]
+	return length, nil
+}
+
+func (t *TcpTransport) Peek(length int) ([]byte, int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.buffer) < length {
+		return nil, len(t.buffer), nil
+	}
+	return t.buffer[:length], length, nil
+}
+
+func (t *TcpTransport) Send(pkg []byte) error {
+	t.sendMu.Lock()
+	defer t.sendMu.Unlock()
+	_, err := t.conn.Write(pkg)
+	return err
+}
+
+// CopyOnSend pkg is written to connection in Send
+func (t *TcpTransport) CopyOnSend() bool {
+	return true
+}
+
+func (t *TcpTransport) Close() {
+	if atomic.CompareAndSwapUint32(&t.isclose, 0, 1) {
+		_ = t.conn.Close()
+	}
+}
+
+func (t *TcpTransport) Size() uint32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return uint32(len(t.buffer))
+}
+
+func (t *TcpTransport) IsClose() bool {
+	return atomic.LoadUint32(&t.isclose) == 1
+}
+
+func (t *TcpTransport) GetID() uint32 {
+	return atomic.LoadUint32(&t.id)
+}
+
+func (t *TcpTransport) SetID(id uint32) {
+	atomic.StoreUint32(&t.id, id)
+}
+
+func (t *TcpTransport) LocalAddr() string {
+	return t.conn.LocalAddr().String()
+}
+
+func (t *TcpTransport) RemoteAddr() string {
+	return t.conn.RemoteAddr().String()
+}
+
+func (t *TcpTransport) GlobalIndex() protocol.GlobalIndexType {
+	return 0
+}
+
+func (t *TcpTransport) Heartbeat() error {
+	return nil
+}
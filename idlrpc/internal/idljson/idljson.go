@@ -0,0 +1,182 @@
+// Package idljson idl json description generated by rpc frontend, the input of idl2go,
+// only fields used by tools are kept
+package idljson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Type type of argument, return value, struct field or container element
+	Type struct {
+		IdlType  string `json:"IdlType"`
+		IsStruct bool   `json:"isStruct"`
+		IsEnum   bool   `json:"isEnum"`
+		GoType   string `json:"type"`
+		Name     string `json:"name"`      // name of struct field
+		DeclName string `json:"decl_name"` // name of argument
+		Key      *Type  `json:"key"`       // element of seq and set, key of dict
+		Value    *Type  `json:"value"`     // value of dict
+	}
+
+	Method struct {
+		Name      string  `json:"name"`
+		Index     uint32  `json:"index"`
+		Arguments []*Type `json:"arguments"`
+		RetType   *Type   `json:"retType"`
+		IsOneway  bool    `json:"oneway"`
+		Retry     uint32  `json:"retry"`
+		TimeOut   uint32  `json:"timeout"`
+	}
+
+	Service struct {
+		Name    string    `json:"name"`
+		Uuid    string    `json:"uuid"`
+		Methods []*Method `json:"methods"`
+		ID      uint64    `json:"-"` // parsed uuid
+	}
+
+	Struct struct {
+		Name   string  `json:"name"`
+		Fields []*Type `json:"fields"`
+	}
+
+	EnumField struct {
+		Name  string `json:"name"`
+		Value int32  `json:"value"`
+	}
+
+	Enum struct {
+		Name   string       `json:"name"`
+		Fields []*EnumField `json:"fields"`
+	}
+
+	File struct {
+		IdlName  string     `json:"idlname"`
+		Services []*Service `json:"services"`
+		Structs  []*Struct  `json:"structs"`
+		Enums    []*Enum    `json:"enums"`
+	}
+
+	// Schema types of all loaded idl files
+	Schema struct {
+		Services map[uint64]*Service
+		Structs  map[string]*Struct
+		Enums    map[string]map[int32]string
+	}
+)
+
+func NewSchema() *Schema {
+	return &Schema{
+		Services: make(map[uint64]*Service),
+		Structs:  make(map[string]*Struct),
+		Enums:    make(map[string]map[int32]string),
+	}
+}
+
+// Load add idl json file to schema
+func (s *Schema) Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	idl := &File{}
+	if err = json.Unmarshal(data, idl); err != nil {
+		return fmt.Errorf("parse idl %s error %v", path, err)
+	}
+	for _, srv := range idl.Services {
+		if srv.ID, err = strconv.ParseUint(srv.Uuid, 10, 64); err != nil {
+			return fmt.Errorf("idl %s service %s has invalid uuid %q", path, srv.Name, srv.Uuid)
+		}
+		s.Services[srv.ID] = srv
+	}
+	for _, st := range idl.Structs {
+		s.Structs[st.Name] = st
+	}
+	for _, en := range idl.Enums {
+		values := make(map[int32]string)
+		for _, f := range en.Fields {
+			values[f.Value] = f.Name
+		}
+		s.Enums[en.Name] = values
+	}
+	return nil
+}
+
+// Method service and method by id, method is nil while service is known but method is not
+func (s *Schema) Method(uuid uint64, methId uint32) (*Service, *Method) {
+	srv, ok := s.Services[uuid]
+	if !ok {
+		return nil, nil
+	}
+	return srv, srv.Method(methId)
+}
+
+// Service service by name, case insensitive
+func (s *Schema) Service(name string) *Service {
+	for _, srv := range s.Services {
+		if strings.EqualFold(srv.Name, name) {
+			return srv
+		}
+	}
+	return nil
+}
+
+// Method method by id
+func (srv *Service) Method(methId uint32) *Method {
+	for _, m := range srv.Methods {
+		if m.Index == methId {
+			return m
+		}
+	}
+	return nil
+}
+
+// MethodByName method by name, case insensitive
+func (srv *Service) MethodByName(name string) *Method {
+	for _, m := range srv.Methods {
+		if strings.EqualFold(m.Name, name) {
+			return m
+		}
+	}
+	return nil
+}
+
+// String idl spelling of type
+func (t *Type) String() string {
+	switch {
+	case t == nil:
+		return "void"
+	case t.IdlType == "seq" || t.IdlType == "set":
+		return fmt.Sprintf("%s<%s>", t.IdlType, t.Key)
+	case t.IdlType == "dict":
+		return fmt.Sprintf("dict<%s,%s>", t.Key, t.Value)
+	case t.IsStruct || t.IsEnum:
+		return t.GoType
+	}
+	return t.IdlType
+}
+
+// Signature idl spelling of method
+func (m *Method) Signature() string {
+	args := make([]string, 0, len(m.Arguments))
+	for i, arg := range m.Arguments {
+		if arg.IdlType == "void" {
+			continue
+		}
+		name := arg.DeclName
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i+1)
+		}
+		args = append(args, fmt.Sprintf("%s %s", arg, name))
+	}
+	sign := fmt.Sprintf("%s %s(%s)", m.RetType, m.Name, strings.Join(args, ", "))
+	if m.IsOneway {
+		sign = "oneway " + sign
+	}
+	return sign
+}
@@ -4,79 +4,257 @@ import (
 	common2 "github.com/CloudGuan/rpc-backend-go/idlrpc/internal/common"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/logger"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/log"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type CallMap map[uint32]*ProxyCall
 
+const (
+	callShardNum = 64 // shard number of call table, must be power of 2
+	callWheelNum = 8  // timing wheel number, must be power of 2
+)
+
+// callShard part of call table, calls are spread by call id
+type callShard struct {
+	sync.RWMutex
+	calls CallMap
+	_     [32]byte // keep shards on different cache lines
+}
+
 // ProxyCallManager manager proxy call for multi goroutine
 type ProxyCallManager struct {
-	callMap CallMap
-	callID  uint32
-	rwMutex sync.RWMutex //loc
+	shards     [callShardNum]callShard
+	callID     uint32
+	pool       sync.Pool                  //released proxy calls with their result channel
+	wheels     [callWheelNum]*timingWheel //timeout of waiting proxy calls, spread by call id
+	logger     log.ILogger                //logger of rpc instance
+	timeOut    uint32                     //timeout of call without its own, millisecond
+	maxRetry   int32                      //max retry time of call
+	wheelTick  time.Duration              //precision of timing wheel
+	wheelSlots int                        //slots of timing wheel
+}
+
+// ManagerOption setting of proxy call manager
+type ManagerOption func(*ProxyCallManager)
+
+// WithLogger set logger of manager
+func WithLogger(logger log.ILogger) ManagerOption {
+	return func(pcm *ProxyCallManager) {
+		if logger != nil {
+			pcm.logger = logger
+		}
+	}
+}
+
+// WithCallDefault set timeout of call without its own and max retry time of all calls
+func WithCallDefault(timeOut uint32, maxRetry int32) ManagerOption {
+	return func(pcm *ProxyCallManager) {
+		if timeOut > 0 {
+			pcm.timeOut = timeOut
+		}
+		pcm.maxRetry = maxRetry
+	}
+}
+
+// WithWheel set precision and slots of timing wheel
+func WithWheel(tick time.Duration, slots int) ManagerOption {
+	return func(pcm *ProxyCallManager) {
+		if tick > 0 {
+			pcm.wheelTick = tick
+		}
+		if slots > 0 {
+			pcm.wheelSlots = slots
+		}
+	}
 }
 
-func NewCallManager() *ProxyCallManager {
-	return &ProxyCallManager{
-		make(map[uint32]*ProxyCall),
-		1,
-		sync.RWMutex{},
+func NewCallManager(opts ...ManagerOption) *ProxyCallManager {
+	pcm := &ProxyCallManager{
+		callID:     1,
+		logger:     &logger.NullLogger{},
+		timeOut:    common2.DefaultTimeOut,
+		maxRetry:   common2.MaxRetryTime,
+		wheelTick:  common2.DefaultWheelTick,
+		wheelSlots: common2.DefaultWheelSlots,
+	}
+	for _, o := range opts {
+		o(pcm)
+	}
+	for i := range pcm.shards {
+		pcm.shards[i].calls = make(CallMap)
+	}
+	pcm.pool.New = func() interface{} {
+		return &ProxyCall{Ch: make(chan []byte, 1)}
 	}
+	for i := range pcm.wheels {
+		pcm.wheels[i] = newTimingWheel(pcm.wheelTick, pcm.wheelSlots, pcm.expire)
+	}
+	return pcm
+}
+
+// SetCallDefault change timeout of call without its own and max retry time of all calls at runtime
+func (pcm *ProxyCallManager) SetCallDefault(timeOut uint32, maxRetry int32) {
+	if timeOut > 0 {
+		atomic.StoreUint32(&pcm.timeOut, timeOut)
+	}
+	atomic.StoreInt32(&pcm.maxRetry, maxRetry)
+}
+
+// CallDefault timeout of call without its own and max retry time of all calls
+func (pcm *ProxyCallManager) CallDefault() (uint32, int32) {
+	return atomic.LoadUint32(&pcm.timeOut), atomic.LoadInt32(&pcm.maxRetry)
 }
 
 func (pcm *ProxyCallManager) GenCallID() uint32 {
 	return atomic.AddUint32(&pcm.callID, 1)
 }
 
+// wheel timing wheel of proxy call, call id is not changed while call is waiting
+func (pcm *ProxyCallManager) wheel(pc *ProxyCall) *timingWheel {
+	return pcm.wheels[pc.CallID&(callWheelNum-1)]
+}
+
+func (pcm *ProxyCallManager) shard(callId uint32) *callShard {
+	return &pcm.shards[callId&(callShardNum-1)]
+}
+
+// CreateProxyCall get proxy call from pool, it returns to pool after Destroy
+// and all holders got by Get are done
 func (pcm *ProxyCallManager) CreateProxyCall(proxyId ProxyUuid, timeOut uint32, retryTime int32, globalIndex protocol.GlobalIndexType) *ProxyCall {
-	if retryTime > common2.MaxRetryTime {
-		retryTime = common2.MaxRetryTime
+	if maxRetry := atomic.LoadInt32(&pcm.maxRetry); retryTime > maxRetry {
+		retryTime = maxRetry
 	}
-	pc := &ProxyCall{
-		ProxyId:     proxyId,
-		CallID:      pcm.GenCallID(),
-		timeOut:     timeOut,
-		retryTime:   retryTime,
-		globalIndex: globalIndex,
-		Ch:          make(chan []byte, 1),
+	if timeOut == 0 {
+		timeOut = atomic.LoadUint32(&pcm.timeOut)
 	}
+	pc := pcm.pool.Get().(*ProxyCall)
+	pc.ProxyId = proxyId
+	pc.CallID = pcm.GenCallID()
+	pc.timeOut = timeOut
+	pc.retryTime = retryTime
+	pc.globalIndex = globalIndex
+	pc.refs = 1
 	return pc
 }
 
 func (pcm *ProxyCallManager) Add(pc *ProxyCall) error {
-	// lock map
-	pcm.rwMutex.Lock()
-	defer pcm.rwMutex.Unlock()
+	// lock shard
+	shard := pcm.shard(pc.CallID)
+	shard.Lock()
+	defer shard.Unlock()
 	//check proxy call id repetition
-	_, ok := pcm.callMap[pc.CallID]
+	_, ok := shard.calls[pc.CallID]
 	if ok {
-		logger.Error("[IProxy] %d,0,0 proxy call has existed ", pc.CallID)
+		pcm.logger.Error("[IProxy] %d,0,0 proxy call has existed ", pc.CallID)
 		return errors.NewRpcError(errors.CommErr, "proxy call %d is exist!!", pc.CallID)
 	}
 
-	pcm.callMap[pc.CallID] = pc
+	shard.calls[pc.CallID] = pc
 	return nil
 }
 
+// Get get waiting proxy call, the call is held until Done or Put is called,
+// it is not reused by other call meanwhile
 func (pcm *ProxyCallManager) Get(callId uint32) *ProxyCall {
 	//lock
-	pcm.rwMutex.RLock()
-	defer pcm.rwMutex.RUnlock()
+	shard := pcm.shard(callId)
+	shard.RLock()
+	defer shard.RUnlock()
 
-	pc, ok := pcm.callMap[callId]
+	pc, ok := shard.calls[callId]
 	if !ok {
-		logger.Warn("[IProxy] %d,0,0 is not exist", callId)
+		pcm.logger.Warn("[IProxy] %d,0,0 is not exist", callId)
 		return nil
 	}
+	pc.retain()
 	return pc
 }
 
+// Put release proxy call got by Get
+func (pcm *ProxyCallManager) Put(pc *ProxyCall) {
+	if pc.release() {
+		pc.reset()
+		pcm.pool.Put(pc)
+	}
+}
+
+// Destroy remove proxy call, it returns to pool once no one holds it
 func (pcm *ProxyCallManager) Destroy(callId uint32) {
-	// lock map
-	pcm.rwMutex.Lock()
-	defer pcm.rwMutex.Unlock()
+	// lock shard
+	shard := pcm.shard(callId)
+	shard.Lock()
+	pc, ok := shard.calls[callId]
+	delete(shard.calls, callId)
+	shard.Unlock()
+
+	if ok {
+		pcm.wheel(pc).remove(pc)
+		pcm.Put(pc)
+	}
+}
+
+// Start begin waiting for response of sent call, must be called before request is sent.
+// call times out after its timeout, then it is sent again by resend while retry time left,
+// otherwise IDL_RPC_TIME_OUT is pushed to Ch
+func (pcm *ProxyCallManager) Start(pc *ProxyCall, resend ResendFunc) error {
+	pc.resend = resend
+	if !pcm.wheel(pc).add(pc, pc.timeout()) {
+		return errors.ErrRpcClosed
+	}
+	return nil
+}
+
+// Cancel stop waiting for response, return false while result has been pushed to Ch
+func (pcm *ProxyCallManager) Cancel(pc *ProxyCall) bool {
+	return pcm.wheel(pc).remove(pc)
+}
 
-	delete(pcm.callMap, callId)
+// Done push remote result to call got by Get and release it, IDL_RPC_TIME_OUT of remote is retried as local timeout.
+// return false while call is not waiting, result arrived after timeout or twice
+func (pcm *ProxyCallManager) Done(pc *ProxyCall, errCode uint32, body []byte) bool {
+	defer pcm.Put(pc)
+	if !pcm.wheel(pc).remove(pc) {
+		return false
+	}
+	if errCode == protocol.IDL_RPC_TIME_OUT {
+		pcm.retry(pc)
+		return true
+	}
+	pc.DoRet(errCode, body)
+	return true
+}
+
+// Close stop timing wheel, all waiting calls time out at once
+func (pcm *ProxyCallManager) Close() {
+	for _, wheel := range pcm.wheels {
+		wheel.close()
+	}
+}
+
+// expire call timeout by timing wheel, call is held by wheel while expiring
+func (pcm *ProxyCallManager) expire(pc *ProxyCall) {
+	pcm.retry(pc)
+	pcm.Put(pc)
+}
+
+// retry send timeout call again, or finish it with IDL_RPC_TIME_OUT
+func (pcm *ProxyCallManager) retry(pc *ProxyCall) {
+	if pc.GetRetryTime() <= 0 || pc.resend == nil {
+		pc.DoRet(protocol.IDL_RPC_TIME_OUT, nil)
+		return
+	}
+	pc.DecRetryTime()
+	pcm.logger.Warn("[IProxy] %d,%d,0 proxy call of method %d time out, retry", pc.CallID, pc.ProxyId, pc.MethodId)
+	// schedule before send, response may arrive before resend returns
+	if !pcm.wheel(pc).add(pc, pc.timeout()) {
+		pc.DoRet(protocol.IDL_RPC_TIME_OUT, nil)
+		return
+	}
+	if errCode := pc.resend(pc); errCode != protocol.IDL_SUCCESS && pcm.wheel(pc).remove(pc) {
+		pc.DoRet(errCode, nil)
+	}
 }
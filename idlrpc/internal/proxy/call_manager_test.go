@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+)
+
+func TestProxyCallReuse(t *testing.T) {
+	pcm := NewCallManager()
+	defer pcm.Close()
+
+	pc := pcm.CreateProxyCall(1, 0, 0, 0)
+	callID := pc.CallID
+	_ = pcm.Add(pc)
+	_ = pcm.Start(pc, nil)
+
+	// response goroutine holds the call, destroyed call must not be reused before it is done
+	held := pcm.Get(callID)
+	pcm.Destroy(callID)
+	if pcm.Get(callID) != nil {
+		t.Fatal("destroyed call still in table")
+	}
+	if held.CallID != callID || held.refs != 1 {
+		t.Fatalf("held call has been reset, call id %d refs %d", held.CallID, held.refs)
+	}
+	if pcm.Done(held, protocol.IDL_SUCCESS, nil) {
+		t.Fatal("destroyed call accept result")
+	}
+	if held.refs != 0 || held.CallID != 0 {
+		t.Fatalf("call not reset after release, call id %d refs %d", held.CallID, held.refs)
+	}
+}
+
+// lockedCallTable previous design, one map guarded by one lock, call and channel allocated per call
+type lockedCallTable struct {
+	mu     sync.RWMutex
+	calls  CallMap
+	callID uint32
+}
+
+func (lt *lockedCallTable) call(body []byte) {
+	pc := &ProxyCall{CallID: atomic.AddUint32(&lt.callID, 1), Ch: make(chan []byte, 1)}
+	lt.mu.Lock()
+	lt.calls[pc.CallID] = pc
+	lt.mu.Unlock()
+
+	lt.mu.RLock()
+	got := lt.calls[pc.CallID]
+	lt.mu.RUnlock()
+	got.DoRet(protocol.IDL_SUCCESS, body)
+	<-pc.Ch
+
+	lt.mu.Lock()
+	delete(lt.calls, pc.CallID)
+	lt.mu.Unlock()
+}
+
+func BenchmarkLockedCallTable(b *testing.B) {
+	lt := &lockedCallTable{calls: make(CallMap)}
+	body := []byte("resp")
+
+	b.ReportAllocs()
+	b.SetParallelism(16)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			lt.call(body)
+		}
+	})
+}
+
+func BenchmarkCallManager(b *testing.B) {
+	pcm := NewCallManager()
+	defer pcm.Close()
+	body := []byte("resp")
+
+	b.ReportAllocs()
+	b.SetParallelism(16)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			pc := pcm.CreateProxyCall(1, 0, 0, 0)
+			_ = pcm.Add(pc)
+			_ = pcm.Start(pc, nil)
+			pcm.Done(pcm.Get(pc.CallID), protocol.IDL_SUCCESS, body)
+			<-pc.Ch
+			pcm.Destroy(pc.CallID)
+		}
+	})
+}
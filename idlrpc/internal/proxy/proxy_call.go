@@ -1,9 +1,9 @@
 package proxy
 
 import (
-	common2 "github.com/CloudGuan/rpc-backend-go/idlrpc/internal/common"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
 	"sync/atomic"
+	"time"
 )
 
 type ProxyUuid uint32
@@ -19,27 +19,43 @@ type ProxyCall struct {
 	globalIndex protocol.GlobalIndexType // proxy call global index
 	retryTime   int32                    //left retry time
 	MethodId    uint32                   // method id 提供给日志使用
+	resend      ResendFunc               // send request again while retrying, set by Start
+	refs        int32                    // holders of this call, back to pool while it drops to zero
+
+	// timing wheel links, guarded by wheel lock
+	slot      int
+	rounds    int
+	scheduled bool
+	prev      *ProxyCall
+	next      *ProxyCall
 }
 
-// DecRetryTime decrease retry time, read & write in worker goroutine
+// ResendFunc send request of proxy call again, return IDL_SUCCESS if sent,
+// or the error code which finishes the call
+type ResendFunc func(pc *ProxyCall) uint32
+
+// DecRetryTime decrease retry time, read & write by timing wheel and response goroutine
 func (pc *ProxyCall) DecRetryTime() {
-	pc.retryTime--
+	atomic.AddInt32(&pc.retryTime, -1)
 }
 
 // GetRetryTime get left retry time
 func (pc *ProxyCall) GetRetryTime() int32 {
-	return pc.retryTime
+	return atomic.LoadInt32(&pc.retryTime)
 }
 
 func (pc *ProxyCall) SetErrorCode(errCode uint32) {
 	atomic.StoreUint32(&pc.errCode, errCode)
 }
 
-func (pc ProxyCall) GetErrorCode() uint32 {
+func (pc *ProxyCall) GetErrorCode() uint32 {
 	return atomic.LoadUint32(&pc.errCode)
 }
 
-func (pc *ProxyCall) DoRet(body []byte) {
+// DoRet notify caller result of call, must be called only once per call,
+// use ProxyCallManager.Done in response handler
+func (pc *ProxyCall) DoRet(errCode uint32, body []byte) {
+	pc.SetErrorCode(errCode)
 	pc.Ch <- body
 }
 
@@ -51,10 +67,31 @@ func (pc *ProxyCall) SetGlobalIndex(index protocol.GlobalIndexType) {
 	pc.globalIndex = index
 }
 
-// GetTimeOut timeout millisecond
+// GetTimeOut timeout millisecond, default timeout of manager while call is created without timeout
 func (pc *ProxyCall) GetTimeOut() uint32 {
-	if pc.timeOut == 0 {
-		return common2.DefaultTimeOut
-	}
 	return pc.timeOut
 }
+
+func (pc *ProxyCall) timeout() time.Duration {
+	return time.Duration(pc.GetTimeOut()) * time.Millisecond
+}
+
+// retain hold call, keep it from being reused
+func (pc *ProxyCall) retain() {
+	atomic.AddInt32(&pc.refs, 1)
+}
+
+// release drop hold, return true while no one holds the call
+func (pc *ProxyCall) release() bool {
+	return atomic.AddInt32(&pc.refs, -1) == 0
+}
+
+// reset clear call before it is put back to pool, result channel is kept
+func (pc *ProxyCall) reset() {
+	select {
+	case <-pc.Ch:
+	default:
+	}
+	ch := pc.Ch
+	*pc = ProxyCall{Ch: ch}
+}
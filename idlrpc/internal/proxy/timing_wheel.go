@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"sync"
+	"time"
+)
+
+// timingWheel hashed timing wheel, one ticker goroutine expires all proxy calls.
+// proxy call is linked into slot list directly, add and remove cost no allocation
+type timingWheel struct {
+	mu       sync.Mutex
+	interval time.Duration
+	slots    []*ProxyCall     // head of call list of each slot
+	cursor   int              // slot of current tick
+	running  bool             // ticker goroutine started
+	closed   bool             // wheel stopped, no call can be added
+	stopCh   chan struct{}    // stop ticker goroutine
+	onExpire func(*ProxyCall) // called out of lock for every expired call, which is retained by wheel
+}
+
+func newTimingWheel(interval time.Duration, slotNum int, onExpire func(*ProxyCall)) *timingWheel {
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+	if slotNum <= 0 {
+		slotNum = 1
+	}
+	return &timingWheel{
+		interval: interval,
+		slots:    make([]*ProxyCall, slotNum),
+		stopCh:   make(chan struct{}),
+		onExpire: onExpire,
+	}
+}
+
+// add schedule call to expire after d, return false while wheel has been closed
+func (tw *timingWheel) add(pc *ProxyCall, d time.Duration) bool {
+	ticks := int((d + tw.interval - 1) / tw.interval)
+	if ticks < 1 {
+		ticks = 1
+	}
+
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.closed {
+		return false
+	}
+	if !tw.running {
+		tw.running = true
+		go tw.run()
+	}
+	if pc.scheduled {
+		tw.unlink(pc)
+	}
+	slot := (tw.cursor + ticks) % len(tw.slots)
+	pc.rounds = (ticks - 1) / len(tw.slots)
+	pc.slot = slot
+	pc.scheduled = true
+	pc.prev = nil
+	pc.next = tw.slots[slot]
+	if pc.next != nil {
+		pc.next.prev = pc
+	}
+	tw.slots[slot] = pc
+	return true
+}
+
+// remove cancel call, return false while call is not scheduled,
+// which means it has expired or been removed by others
+func (tw *timingWheel) remove(pc *ProxyCall) bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !pc.scheduled {
+		return false
+	}
+	tw.unlink(pc)
+	return true
+}
+
+func (tw *timingWheel) unlink(pc *ProxyCall) {
+	if pc.prev != nil {
+		pc.prev.next = pc.next
+	} else {
+		tw.slots[pc.slot] = pc.next
+	}
+	if pc.next != nil {
+		pc.next.prev = pc.prev
+	}
+	pc.prev, pc.next, pc.scheduled = nil, nil, false
+}
+
+func (tw *timingWheel) run() {
+	ticker := time.NewTicker(tw.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			tw.advance()
+		case <-tw.stopCh:
+			return
+		}
+	}
+}
+
+// advance move cursor one slot, expire calls whose rounds are used up
+func (tw *timingWheel) advance() {
+	var expired *ProxyCall
+	tw.mu.Lock()
+	tw.cursor = (tw.cursor + 1) % len(tw.slots)
+	for pc := tw.slots[tw.cursor]; pc != nil; {
+		next := pc.next
+		if pc.rounds > 0 {
+			pc.rounds--
+		} else {
+			tw.unlink(pc)
+			pc.retain()
+			pc.next = expired
+			expired = pc
+		}
+		pc = next
+	}
+	tw.mu.Unlock()
+
+	for expired != nil {
+		next := expired.next
+		expired.next = nil
+		tw.onExpire(expired)
+		expired = next
+	}
+}
+
+// close stop ticker goroutine, expire all scheduled calls at once
+func (tw *timingWheel) close() {
+	var expired *ProxyCall
+	tw.mu.Lock()
+	if tw.closed {
+		tw.mu.Unlock()
+		return
+	}
+	tw.closed = true
+	close(tw.stopCh)
+	for i := range tw.slots {
+		for pc := tw.slots[i]; pc != nil; {
+			next := pc.next
+			tw.unlink(pc)
+			pc.retain()
+			pc.next = expired
+			expired = pc
+			pc = next
+		}
+	}
+	tw.mu.Unlock()
+
+	for expired != nil {
+		next := expired.next
+		expired.next = nil
+		tw.onExpire(expired)
+		expired = next
+	}
+}
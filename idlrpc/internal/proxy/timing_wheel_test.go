@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+)
+
+func TestCallTimeoutRetry(t *testing.T) {
+	pcm := NewCallManager()
+	defer pcm.Close()
+
+	pc := pcm.CreateProxyCall(1, 20, 2, 0)
+	if err := pcm.Add(pc); err != nil {
+		t.Fatal(err)
+	}
+	defer pcm.Destroy(pc.CallID)
+
+	var resent int32
+	start := time.Now()
+	if err := pcm.Start(pc, func(*ProxyCall) uint32 {
+		atomic.AddInt32(&resent, 1)
+		return protocol.IDL_SUCCESS
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-pc.Ch:
+	case <-time.After(time.Second):
+		t.Fatal("proxy call not time out")
+	}
+	if pc.GetErrorCode() != protocol.IDL_RPC_TIME_OUT || atomic.LoadInt32(&resent) != 2 {
+		t.Fatalf("expect time out after 2 retries, got code %d retries %d", pc.GetErrorCode(), resent)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("time out too early %v", elapsed)
+	}
+	// result arrived after timeout is dropped
+	if pcm.Done(pcm.Get(pc.CallID), protocol.IDL_SUCCESS, []byte("late")) {
+		t.Fatal("late result accepted")
+	}
+}
+
+func TestCallDone(t *testing.T) {
+	pcm := NewCallManager()
+	defer pcm.Close()
+
+	pc := pcm.CreateProxyCall(1, 20, 1, 0)
+	_ = pcm.Add(pc)
+	defer pcm.Destroy(pc.CallID)
+	if err := pcm.Start(pc, func(*ProxyCall) uint32 { return protocol.IDL_SUCCESS }); err != nil {
+		t.Fatal(err)
+	}
+
+	// remote time out is retried
+	if !pcm.Done(pcm.Get(pc.CallID), protocol.IDL_RPC_TIME_OUT, nil) {
+		t.Fatal("remote time out not accepted")
+	}
+	if !pcm.Done(pcm.Get(pc.CallID), protocol.IDL_SUCCESS, []byte("ok")) {
+		t.Fatal("result of retry not accepted")
+	}
+	if body := <-pc.Ch; string(body) != "ok" || pc.GetErrorCode() != protocol.IDL_SUCCESS {
+		t.Fatalf("unexpected result %q %d", body, pc.GetErrorCode())
+	}
+	if pcm.Done(pcm.Get(pc.CallID), protocol.IDL_SUCCESS, nil) {
+		t.Fatal("result accepted twice")
+	}
+
+	// waiting calls time out on close
+	pc2 := pcm.CreateProxyCall(1, 60000, 0, 0)
+	_ = pcm.Add(pc2)
+	_ = pcm.Start(pc2, nil)
+	pcm.Close()
+	<-pc2.Ch
+	if pc2.GetErrorCode() != protocol.IDL_RPC_TIME_OUT {
+		t.Fatalf("unexpected code %d after close", pc2.GetErrorCode())
+	}
+	if err := pcm.Start(pcm.CreateProxyCall(1, 0, 0, 0), nil); err == nil {
+		t.Fatal("start call after close")
+	}
+}
+
+// BenchmarkTimerPerCall previous design, one runtime timer per call and caller parked on select
+func BenchmarkTimerPerCall(b *testing.B) {
+	pcm := NewCallManager()
+	defer pcm.Close()
+	body := []byte("resp")
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			pc := pcm.CreateProxyCall(1, 0, 0, 0)
+			_ = pcm.Add(pc)
+			clicker := time.NewTimer(time.Duration(pc.GetTimeOut()) * time.Millisecond)
+			pc.DoRet(protocol.IDL_SUCCESS, body)
+			select {
+			case <-pc.Ch:
+			case <-clicker.C:
+			}
+			clicker.Stop()
+			pcm.Destroy(pc.CallID)
+		}
+	})
+}
+
+// BenchmarkTimingWheel timeout owned by manager's timing wheel
+func BenchmarkTimingWheel(b *testing.B) {
+	pcm := NewCallManager()
+	defer pcm.Close()
+	body := []byte("resp")
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			pc := pcm.CreateProxyCall(1, 0, 0, 0)
+			_ = pcm.Add(pc)
+			_ = pcm.Start(pc, nil)
+			pcm.Done(pcm.Get(pc.CallID), protocol.IDL_SUCCESS, body)
+			<-pc.Ch
+			pcm.Destroy(pc.CallID)
+		}
+	})
+}
+
+func TestCallDefaultTimeout(t *testing.T) {
+	pcm := NewCallManager(WithCallDefault(30, 0))
+	defer pcm.Close()
+
+	pc := pcm.CreateProxyCall(1, 0, 0, 0)
+	if pc.GetTimeOut() != 30 {
+		t.Fatalf("call without timeout uses %dms, expect configured 30ms", pc.GetTimeOut())
+	}
+	_ = pcm.Add(pc)
+	defer pcm.Destroy(pc.CallID)
+	start := time.Now()
+	if err := pcm.Start(pc, nil); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-pc.Ch:
+	case <-time.After(time.Second):
+		t.Fatal("proxy call not time out")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("time out after %v, configured default not used", elapsed)
+	}
+
+	pcm.SetCallDefault(80, 0)
+	if pc = pcm.CreateProxyCall(1, 0, 0, 0); pc.GetTimeOut() != 80 {
+		t.Fatalf("call without timeout uses %dms after change, expect 80ms", pc.GetTimeOut())
+	}
+	pcm.Put(pc)
+}
@@ -2,18 +2,54 @@ package idlrpc
 
 import (
 	"context"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/common"
 
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/log"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
 )
 
 type (
 	Options struct {
 		ctx        context.Context
 		logger     log.ILogger
+		flog       log.IFieldLogger // structured logger, fields of call are attached to its logs
 		stackTrace bool
 		callTrace  bool
+		proto      protocol.Protocol           // wire format of rpc instance, protocol.Default() if nil
+		services   map[uint64]*serviceOverride // per service settings set by options, key is service uuid
+		timeOut    uint32                      // timeout of proxy call without idl timeout, millisecond
+		maxRetry   int32                       // max retry time of proxy call
+		wheelTick  time.Duration               // precision of proxy call timeout
+		wheelSlots int                         // slots of proxy call timing wheel
+		workers    uint32                      // workers of service not declaring multiple number
+		queueSize  uint32                      // call queue size of service
+		confPath   string                      // config file, loaded on Init and reload
+		conf       configHolder                // config loaded from confPath
 	}
 	Option func(*Options)
+
+	// serviceOptions execution settings of one service
+	serviceOptions struct {
+		dispatch  DispatchMode
+		tickCalls uint32        // max calls executed per tick in tick mode, 0 is unlimited
+		tickTime  time.Duration // max time spent on calls per tick in tick mode, 0 is unlimited
+		workers   uint32        // workers of service not declaring multiple number
+		queueSize uint32        // call queue size
+		trace     bool          // log stack trace of panic
+
+		fixedWorkers bool // workers override idl multiple number
+	}
+
+	// serviceOverride per service settings set by options, only what is set explicitly,
+	// the rest is taken from Options when the service is added
+	serviceOverride struct {
+		dispatch  DispatchMode // DispatchDefault if not set
+		tickSet   bool         // tick budget is set, zero budget is unlimited
+		tickCalls uint32
+		tickTime  time.Duration
+	}
 )
 
 func (o *Options) StackTrace() bool {
@@ -24,6 +60,76 @@ func (o *Options) CallTrace() bool {
 	return o.callTrace
 }
 
+// defaultOptions options of rpc instance before applying user options
+func defaultOptions() *Options {
+	return &Options{
+		stackTrace: false,
+		callTrace:  false,
+		ctx:        context.Background(),
+		timeOut:    common.DefaultTimeOut,
+		maxRetry:   common.MaxRetryTime,
+		wheelTick:  common.DefaultWheelTick,
+		wheelSlots: common.DefaultWheelSlots,
+		workers:    common.DefaultServiceWorker,
+		queueSize:  common.DefaultCallCache,
+	}
+}
+
+// serviceDefault settings of service without its own
+func (o *Options) serviceDefault() serviceOptions {
+	return serviceOptions{
+		dispatch:  DispatchDefault,
+		tickCalls: common.DefaultTickCalls,
+		tickTime:  common.DefaultTickTime,
+		workers:   o.workers,
+		queueSize: o.queueSize,
+	}
+}
+
+// serviceOpt get settings set for service uuid, create it if not exist
+func (o *Options) serviceOpt(uuid uint64) *serviceOverride {
+	if o.services == nil {
+		o.services = make(map[uint64]*serviceOverride)
+	}
+	so, ok := o.services[uuid]
+	if !ok {
+		so = &serviceOverride{}
+		o.services[uuid] = so
+	}
+	return so
+}
+
+// serviceSetting settings of service uuid named name, independent of the order options are applied.
+// later one overrides former one: default, WithServiceWorker, service_worker and queue_size of config file,
+// WithDispatchMode and WithTickBudget of the service, config file settings of the service
+func (o *Options) serviceSetting(uuid uint64, name string) serviceOptions {
+	setting := o.serviceDefault()
+	conf := o.Config()
+	conf.global(&setting)
+	if so, ok := o.services[uuid]; ok {
+		if so.dispatch != DispatchDefault {
+			setting.dispatch = so.dispatch
+		}
+		if so.tickSet {
+			setting.tickCalls = so.tickCalls
+			setting.tickTime = so.tickTime
+		}
+	}
+	conf.service(name, &setting)
+	setting.trace = o.stackTrace
+	return setting
+}
+
+// Config return config loaded from config file, empty config if not set
+func (o *Options) Config() *Config {
+	return o.conf.load()
+}
+
+// DispatchMode return dispatch mode set for service uuid
+func (o *Options) DispatchMode(uuid uint64) DispatchMode {
+	return o.serviceSetting(uuid, "").dispatch
+}
+
 func WithUserData(key, val interface{}) Option {
 	return func(o *Options) {
 		o.ctx = context.WithValue(o.ctx, key, val)
@@ -36,6 +142,66 @@ func WithLogger(logger log.ILogger) Option {
 	}
 }
 
+// WithFieldLogger set structured logger, service, method, callID, globalIndex and traceID
+// are attached to logs of each call. it is also the framework logger while WithLogger not set
+func WithFieldLogger(logger log.IFieldLogger) Option {
+	return func(o *Options) {
+		o.flog = logger
+	}
+}
+
+// WithProtocol set wire format of rpc instance, transport implements transport.IProtocolTransport
+// can speak another one
+func WithProtocol(proto protocol.Protocol) Option {
+	return func(o *Options) {
+		o.proto = proto
+	}
+}
+
+// WithCallTimeout set timeout of proxy call whose method has no idl timeout, and max retry time of proxy call
+func WithCallTimeout(timeOut uint32, maxRetry int32) Option {
+	return func(o *Options) {
+		if timeOut > 0 {
+			o.timeOut = timeOut
+		}
+		o.maxRetry = maxRetry
+	}
+}
+
+// WithTimingWheel set precision and slots of timing wheel which drives proxy call timeout
+func WithTimingWheel(tick time.Duration, slots int) Option {
+	return func(o *Options) {
+		if tick > 0 {
+			o.wheelTick = tick
+		}
+		if slots > 0 {
+			o.wheelSlots = slots
+		}
+	}
+}
+
+// WithServiceWorker set worker number of service not declaring multiple number and call queue size of services,
+// zero keeps default
+func WithServiceWorker(workers, queueSize uint32) Option {
+	return func(o *Options) {
+		if workers > 0 {
+			o.workers = workers
+		}
+		if queueSize > 0 {
+			o.queueSize = queueSize
+		}
+	}
+}
+
+// WithConfigFile load framework, service and method settings from json or toml file on Init,
+// settings of config file override options of the same scope, settings of one service
+// override settings of all services. file is read again by IRpc.ReloadConfig
+func WithConfigFile(path string) Option {
+	return func(o *Options) {
+		o.confPath = path
+	}
+}
+
 func WithStackTrace(open bool) Option {
 	return func(o *Options) {
 		o.stackTrace = open
@@ -47,3 +213,22 @@ func WithCallTrace(open bool) Option {
 		o.callTrace = open
 	}
 }
+
+// WithDispatchMode set how calls of service uuid are dispatched to its workers,
+// overrides the mode declared by idl
+func WithDispatchMode(uuid uint64, mode DispatchMode) Option {
+	return func(o *Options) {
+		o.serviceOpt(uuid).dispatch = mode
+	}
+}
+
+// WithTickBudget limit calls executed in one rpc Tick for service in DispatchTick mode,
+// by call count and by elapsed time, zero means no limit
+func WithTickBudget(uuid uint64, calls uint32, elapsed time.Duration) Option {
+	return func(o *Options) {
+		so := o.serviceOpt(uuid)
+		so.tickSet = true
+		so.tickCalls = calls
+		so.tickTime = elapsed
+	}
+}
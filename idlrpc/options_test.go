@@ -0,0 +1,65 @@
+package idlrpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/common"
+)
+
+func TestServiceSettingOrder(t *testing.T) {
+	const uuid = 7
+	budget := WithTickBudget(uuid, 16, time.Millisecond)
+	workers := WithServiceWorker(4, 512)
+	expect := serviceOptions{dispatch: DispatchTick, tickCalls: 16, tickTime: time.Millisecond, workers: 4, queueSize: 512}
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"budget first", []Option{WithDispatchMode(uuid, DispatchTick), budget, workers}},
+		{"workers first", []Option{workers, budget, WithDispatchMode(uuid, DispatchTick)}},
+	}
+	for _, c := range cases {
+		o := defaultOptions()
+		for _, opt := range c.opts {
+			opt(o)
+		}
+		if setting := o.serviceSetting(uuid, "test"); setting != expect {
+			t.Errorf("%s: setting %+v, expect %+v", c.name, setting, expect)
+		}
+		// other services keep default budget and dispatch
+		if other := o.serviceSetting(uuid+1, "other"); other.dispatch != DispatchDefault || other.tickCalls != common.DefaultTickCalls || other.workers != 4 || other.queueSize != 512 {
+			t.Errorf("%s: other service setting %+v", c.name, other)
+		}
+	}
+}
+
+func TestServiceSettingPrecedence(t *testing.T) {
+	const uuid = 7
+	o := defaultOptions()
+	for _, opt := range []Option{WithTickBudget(uuid, 16, time.Millisecond), WithServiceWorker(4, 512)} {
+		opt(o)
+	}
+	o.conf.store(&Config{
+		ServiceWorker: 2,
+		Services: map[string]ServiceConfig{
+			"test":  {QueueSize: 32},
+			"fixed": {Workers: 8},
+		},
+	})
+	cases := []struct {
+		name   string
+		uuid   uint64
+		expect serviceOptions
+	}{
+		// queue size of file only set for the service, its workers and code budget are kept
+		{"test", uuid, serviceOptions{tickCalls: 16, tickTime: time.Millisecond, workers: 2, queueSize: 32}},
+		{"fixed", uuid + 1, serviceOptions{tickCalls: common.DefaultTickCalls, tickTime: common.DefaultTickTime, workers: 8, queueSize: 512, fixedWorkers: true}},
+		{"other", uuid + 2, serviceOptions{tickCalls: common.DefaultTickCalls, tickTime: common.DefaultTickTime, workers: 2, queueSize: 512}},
+	}
+	for _, c := range cases {
+		if setting := o.serviceSetting(c.uuid, c.name); setting != c.expect {
+			t.Errorf("%s: setting %+v, expect %+v", c.name, setting, c.expect)
+		}
+	}
+}
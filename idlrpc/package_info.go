@@ -1,11 +1,25 @@
 package idlrpc
 
+import (
+	"sort"
+	"sync"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+)
+
+// arguments of SdkCreateHandle
+const (
+	SdkHost  = "host"  // sdk hosts service, registers stub and proxy creators, default
+	SdkProxy = "proxy" // sdk only calls service, registers proxy creator
+)
+
 type (
 	//SdkCreateHandle rpc sdk creator, return sdk instance
 	SdkCreateHandle func(...string) (ISDK, error)
 	// PackageInfo service package information
 	PackageInfo struct {
 		ServiceUUID uint64          //service uuid
+		ServiceName string          //service name, key of package registry
 		Creator     SdkCreateHandle //Sdk Creator handle
 	}
 
@@ -17,3 +31,68 @@ type (
 		Register(IRpc) error
 	}
 )
+
+// packages registered by generated sdk on init, shared by all rpc instances
+var packages = struct {
+	sync.RWMutex
+	byName map[string]*PackageInfo
+}{byName: make(map[string]*PackageInfo)}
+
+// RegisterPackage register service package, called by init of generated sdk, panic on duplicate name
+func RegisterPackage(info *PackageInfo) {
+	if info == nil || info.Creator == nil || info.ServiceName == "" {
+		panic("[Package] register invalid package")
+	}
+	packages.Lock()
+	defer packages.Unlock()
+	if _, ok := packages.byName[info.ServiceName]; ok {
+		panic("[Package] package " + info.ServiceName + " registered twice")
+	}
+	packages.byName[info.ServiceName] = info
+}
+
+// GetPackage get registered package by service name
+func GetPackage(name string) (*PackageInfo, bool) {
+	packages.RLock()
+	defer packages.RUnlock()
+	info, ok := packages.byName[name]
+	return info, ok
+}
+
+// PackageNames names of all registered packages, sorted
+func PackageNames() []string {
+	packages.RLock()
+	defer packages.RUnlock()
+	names := make([]string, 0, len(packages.byName))
+	for name := range packages.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadPackage create sdk of registered package and register it to rpc, args are passed to sdk creator
+func LoadPackage(rpc IRpc, name string, args ...string) (ISDK, error) {
+	info, ok := GetPackage(name)
+	if !ok {
+		return nil, errors.NewRpcError(errors.CommErr, "package %s not registered", name)
+	}
+	sdk, err := info.Creator(args...)
+	if err != nil {
+		return nil, err
+	}
+	if err = sdk.Register(rpc); err != nil {
+		return nil, err
+	}
+	return sdk, nil
+}
+
+// IsProxyArgs sdk creator arguments ask for proxy only sdk
+func IsProxyArgs(args []string) bool {
+	for _, arg := range args {
+		if arg == SdkProxy {
+			return true
+		}
+	}
+	return false
+}
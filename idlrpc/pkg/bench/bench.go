@@ -0,0 +1,226 @@
+// Package bench load generator of services, drives a weighted request mix at fixed rate or
+// fixed concurrency, and reports latency percentiles and error code breakdown.
+// requests are plain functions, call generated proxy or dynamic idl client in them
+package bench
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+)
+
+type (
+	// Request one kind of request in mix
+	Request struct {
+		Name   string
+		Weight int // share in mix, 1 while not set
+		Call   func() error
+	}
+
+	Option func(*options)
+
+	options struct {
+		rate        float64
+		concurrency int
+		duration    time.Duration
+		total       int64
+		seed        int64
+	}
+
+	// sample one finished call
+	sample struct {
+		latency time.Duration
+		err     error
+	}
+
+	// scheduled request of fixed rate mode
+	scheduled struct {
+		index int
+		at    time.Time // when it should be issued, latency counts from it
+	}
+)
+
+// WithRate fixed rate mode, requests per second, concurrency is max calls in flight.
+// latency counts from the time request is scheduled, waiting for a free worker included
+func WithRate(rate float64) Option {
+	return func(o *options) {
+		o.rate = rate
+	}
+}
+
+// WithConcurrency number of workers, default 1
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// WithDuration stop after d, default 10s
+func WithDuration(d time.Duration) Option {
+	return func(o *options) {
+		o.duration = d
+	}
+}
+
+// WithTotal stop after n requests are issued, run is still limited by duration
+func WithTotal(n int) Option {
+	return func(o *options) {
+		o.total = int64(n)
+	}
+}
+
+// WithSeed seed of request picking, mix is reproducible with same seed
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+	}
+}
+
+// Run drive request mix until duration passes or total requests are issued
+func Run(mix []*Request, opts ...Option) (*Result, error) {
+	o := &options{concurrency: 1, duration: 10 * time.Second, seed: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if len(mix) == 0 {
+		return nil, errors.NewRpcError(errors.CommErr, "empty request mix")
+	}
+	if o.concurrency <= 0 || o.rate < 0 {
+		return nil, errors.NewRpcError(errors.CommErr, "invalid concurrency %d or rate %v", o.concurrency, o.rate)
+	}
+	picker, err := newPicker(mix)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &runner{mix: mix, options: o, stats: make([]*Stats, len(mix))}
+	for i, req := range mix {
+		r.stats[i] = &Stats{Name: req.Name}
+	}
+	start := time.Now()
+	r.deadline = start.Add(o.duration)
+	if o.rate > 0 {
+		r.fixedRate(picker)
+	} else {
+		r.fixedConcurrency(picker)
+	}
+	return r.result(time.Since(start)), nil
+}
+
+// runner state of one run
+type runner struct {
+	*options
+	mix      []*Request
+	deadline time.Time
+	issued   int64
+	skipped  int64
+	mu       sync.Mutex
+	stats    []*Stats
+}
+
+// next take a request slot, false while run is over
+func (r *runner) next() bool {
+	if !time.Now().Before(r.deadline) {
+		return false
+	}
+	return r.total == 0 || atomic.AddInt64(&r.issued, 1) <= r.total
+}
+
+// do call request i, latency counts from start
+func (r *runner) do(i int, start time.Time) {
+	err := r.mix[i].Call()
+	s := sample{latency: time.Since(start), err: err}
+	r.mu.Lock()
+	r.stats[i].add(s)
+	r.mu.Unlock()
+}
+
+// fixedConcurrency every worker calls one request after another
+func (r *runner) fixedConcurrency(p *picker) {
+	wg := sync.WaitGroup{}
+	for w := 0; w < r.concurrency; w++ {
+		wg.Add(1)
+		go func(rnd *rand.Rand) {
+			defer wg.Done()
+			for r.next() {
+				r.do(p.pick(rnd), time.Now())
+			}
+		}(rand.New(rand.NewSource(r.seed + int64(w))))
+	}
+	wg.Wait()
+}
+
+// fixedRate requests are issued on schedule. while all workers are busy, scheduling waits and
+// requests are issued late, their latency still counts from schedule. requests scheduled
+// before deadline but not issued are skipped
+func (r *runner) fixedRate(p *picker) {
+	queue := make(chan scheduled, r.concurrency)
+	wg := sync.WaitGroup{}
+	for w := 0; w < r.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range queue {
+				r.do(req.index, req.at)
+			}
+		}()
+	}
+
+	rnd := rand.New(rand.NewSource(r.seed))
+	interval := time.Duration(float64(time.Second) / r.rate)
+	next := time.Now()
+	for r.next() {
+		if d := time.Until(next); d > 0 {
+			time.Sleep(d)
+		}
+		queue <- scheduled{index: p.pick(rnd), at: next}
+		next = next.Add(interval)
+	}
+	close(queue)
+	for ; next.Before(r.deadline) && (r.total == 0 || r.issued < r.total); next = next.Add(interval) {
+		r.issued++
+		r.skipped++
+	}
+	wg.Wait()
+}
+
+func (r *runner) result(elapsed time.Duration) *Result {
+	res := &Result{Elapsed: elapsed, Skipped: int(r.skipped), Total: &Stats{Name: "total"}, Requests: r.stats}
+	for _, s := range r.stats {
+		res.Total.merge(s)
+		s.finish(elapsed)
+	}
+	res.Total.finish(elapsed)
+	return res
+}
+
+// picker weighted random choice of mix
+type picker struct {
+	cumulative []int
+}
+
+func newPicker(mix []*Request) (*picker, error) {
+	p := &picker{cumulative: make([]int, len(mix))}
+	sum := 0
+	for i, req := range mix {
+		if req.Call == nil || req.Weight < 0 {
+			return nil, errors.NewRpcError(errors.CommErr, "invalid request %s", req.Name)
+		}
+		weight := req.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		sum += weight
+		p.cumulative[i] = sum
+	}
+	return p, nil
+}
+
+func (p *picker) pick(rnd *rand.Rand) int {
+	n := rnd.Intn(p.cumulative[len(p.cumulative)-1])
+	return sort.SearchInts(p.cumulative, n+1)
+}
@@ -0,0 +1,101 @@
+package bench
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+)
+
+func TestRunConcurrency(t *testing.T) {
+	var n int64
+	mix := []*Request{
+		{Name: "get", Weight: 3, Call: func() error { return nil }},
+		{Name: "set", Call: func() error {
+			switch atomic.AddInt64(&n, 1) % 4 {
+			case 1:
+				return errors.ErrRpcTimeOut
+			case 2:
+				return errors.NewRemoteError(1001, "NotEnoughGold", "gold 0")
+			}
+			return nil
+		}},
+	}
+	res, err := Run(mix, WithConcurrency(4), WithTotal(1000), WithDuration(10*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get, set := res.Requests[0], res.Requests[1]
+	if res.Total.Count != 1000 || get.Count+set.Count != 1000 || get.Count < set.Count {
+		t.Fatalf("get %d set %d total %d", get.Count, set.Count, res.Total.Count)
+	}
+	if get.Errors != 0 || set.Codes[CodeTimeout] == 0 || set.Codes["1001 NotEnoughGold"] == 0 || res.Total.Errors != set.Errors {
+		t.Fatalf("error breakdown %v", set.Codes)
+	}
+	if res.Total.P50 > res.Total.P99 || res.Total.P99 > res.Total.Max {
+		t.Fatalf("percentiles out of order %v %v %v", res.Total.P50, res.Total.P99, res.Total.Max)
+	}
+
+	buf := &bytes.Buffer{}
+	if err = res.WriteCSV(buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "request,count,errors") || !strings.HasPrefix(lines[3], "total,1000,") {
+		t.Fatalf("csv\n%s", buf.String())
+	}
+	buf.Reset()
+	_ = res.WriteSummary(buf)
+	if !strings.Contains(buf.String(), "set errors: 1001 NotEnoughGold:") {
+		t.Fatalf("summary\n%s", buf.String())
+	}
+}
+
+func TestRunRate(t *testing.T) {
+	var n int64
+	mix := []*Request{{Name: "ping", Call: func() error {
+		atomic.AddInt64(&n, 1)
+		return nil
+	}}}
+	res, err := Run(mix, WithRate(200), WithConcurrency(2), WithDuration(200*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 40 calls are scheduled in 200ms
+	if res.Total.Count < 20 || res.Total.Count > 45 || int64(res.Total.Count) != n {
+		t.Fatalf("fixed rate issued %d calls", res.Total.Count)
+	}
+	if res.Skipped != 0 {
+		t.Fatalf("%d requests skipped by idle workers", res.Skipped)
+	}
+
+	if _, err = Run(nil); err == nil {
+		t.Fatal("empty mix accepted")
+	}
+	if _, err = Run(mix, WithConcurrency(0)); err == nil {
+		t.Fatal("zero concurrency accepted")
+	}
+}
+
+func TestRunRateBusy(t *testing.T) {
+	const service = 20 * time.Millisecond
+	mix := []*Request{{Name: "slow", Call: func() error {
+		time.Sleep(service)
+		return nil
+	}}}
+	// 40 calls are scheduled in 200ms, one worker finishes about 10
+	res, err := Run(mix, WithRate(200), WithConcurrency(1), WithDuration(200*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total.Count+res.Skipped < 38 || res.Skipped < 20 {
+		t.Fatalf("%d calls finished, %d skipped", res.Total.Count, res.Skipped)
+	}
+	// late calls count waiting from schedule, not only service time
+	if res.Total.Max < 5*service || res.Total.P50 < 2*service {
+		t.Fatalf("latency p50 %v max %v hides waiting for worker", res.Total.P50, res.Total.Max)
+	}
+}
@@ -0,0 +1,181 @@
+package bench
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+)
+
+const (
+	CodeTimeout = "timeout" // call timeout of proxy
+	CodeOther   = "other"   // error without code
+)
+
+type (
+	// Stats statistics of one request of mix, or of all
+	Stats struct {
+		Name       string
+		Count      int            // finished calls
+		Errors     int            // failed calls
+		Codes      map[string]int // failed calls by error code
+		Throughput float64        // finished calls per second
+		Min        time.Duration
+		Mean       time.Duration
+		P50        time.Duration
+		P90        time.Duration
+		P95        time.Duration
+		P99        time.Duration
+		Max        time.Duration
+		latencies  []time.Duration
+	}
+
+	// Result result of run
+	Result struct {
+		Elapsed  time.Duration
+		Skipped  int      // requests scheduled but not issued before deadline in fixed rate mode, all workers were busy
+		Total    *Stats   // all requests
+		Requests []*Stats // each request, in order of mix
+	}
+)
+
+// ErrorCode code of call error in breakdown, exception code with name if remote threw one
+func ErrorCode(err error) string {
+	var ex errors.IException
+	switch {
+	case errors.As(err, &ex):
+		if ex.ExceptionName() != "" {
+			return fmt.Sprintf("%d %s", ex.ExceptionCode(), ex.ExceptionName())
+		}
+		return strconv.FormatUint(uint64(ex.ExceptionCode()), 10)
+	case errors.Is(err, errors.ErrRpcTimeOut):
+		return CodeTimeout
+	}
+	return CodeOther
+}
+
+func (s *Stats) add(smp sample) {
+	s.latencies = append(s.latencies, smp.latency)
+	if smp.err == nil {
+		return
+	}
+	s.Errors++
+	if s.Codes == nil {
+		s.Codes = make(map[string]int)
+	}
+	s.Codes[ErrorCode(smp.err)]++
+}
+
+func (s *Stats) merge(other *Stats) {
+	s.latencies = append(s.latencies, other.latencies...)
+	s.Errors += other.Errors
+	for code, n := range other.Codes {
+		if s.Codes == nil {
+			s.Codes = make(map[string]int)
+		}
+		s.Codes[code] += n
+	}
+}
+
+// finish compute percentiles of recorded latencies
+func (s *Stats) finish(elapsed time.Duration) {
+	s.Count = len(s.latencies)
+	if s.Count == 0 {
+		return
+	}
+	if elapsed > 0 {
+		s.Throughput = float64(s.Count) / elapsed.Seconds()
+	}
+	sort.Slice(s.latencies, func(i, j int) bool {
+		return s.latencies[i] < s.latencies[j]
+	})
+	var sum time.Duration
+	for _, l := range s.latencies {
+		sum += l
+	}
+	s.Mean = sum / time.Duration(s.Count)
+	s.Min, s.Max = s.latencies[0], s.latencies[s.Count-1]
+	s.P50, s.P90, s.P95, s.P99 = s.Percentile(50), s.Percentile(90), s.Percentile(95), s.Percentile(99)
+}
+
+// Percentile latency below which p percent of calls finished, nearest rank
+func (s *Stats) Percentile(p float64) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(s.latencies))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(s.latencies) {
+		rank = len(s.latencies) - 1
+	}
+	return s.latencies[rank]
+}
+
+// codes error code breakdown sorted by code, "timeout:3;1001 NotEnoughGold:1"
+func (s *Stats) codes() string {
+	keys := make([]string, 0, len(s.Codes))
+	for code := range s.Codes {
+		keys = append(keys, code)
+	}
+	sort.Strings(keys)
+	for i, code := range keys {
+		keys[i] = fmt.Sprintf("%s:%d", code, s.Codes[code])
+	}
+	return strings.Join(keys, ";")
+}
+
+// WriteSummary human readable summary
+func (r *Result) WriteSummary(w io.Writer) error {
+	fmt.Fprintf(w, "elapsed %v, %d calls, %.1f calls/s, %d errors", r.Elapsed.Round(time.Millisecond), r.Total.Count, r.Total.Throughput, r.Total.Errors)
+	if r.Skipped > 0 {
+		fmt.Fprintf(w, ", %d skipped", r.Skipped)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%-24s %8s %8s %10s %10s %10s %10s %10s %10s\n", "request", "count", "errors", "mean", "p50", "p90", "p95", "p99", "max")
+	for _, s := range append(r.Requests, r.Total) {
+		fmt.Fprintf(w, "%-24s %8d %8d %10v %10v %10v %10v %10v %10v\n", s.Name, s.Count, s.Errors,
+			round(s.Mean), round(s.P50), round(s.P90), round(s.P95), round(s.P99), round(s.Max))
+	}
+	for _, s := range r.Requests {
+		if len(s.Codes) > 0 {
+			fmt.Fprintf(w, "%s errors: %s\n", s.Name, strings.Replace(s.codes(), ";", ", ", -1))
+		}
+	}
+	return nil
+}
+
+// WriteCSV one row per request and total row, latencies in millisecond
+func (r *Result) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"request", "count", "errors", "throughput", "min_ms", "mean_ms", "p50_ms", "p90_ms", "p95_ms", "p99_ms", "max_ms", "codes"})
+	for _, s := range append(r.Requests, r.Total) {
+		_ = cw.Write([]string{
+			s.Name,
+			strconv.Itoa(s.Count),
+			strconv.Itoa(s.Errors),
+			strconv.FormatFloat(s.Throughput, 'f', 2, 64),
+			ms(s.Min), ms(s.Mean), ms(s.P50), ms(s.P90), ms(s.P95), ms(s.P99), ms(s.Max),
+			s.codes(),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func ms(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+func round(d time.Duration) time.Duration {
+	if d > time.Millisecond {
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
@@ -0,0 +1,166 @@
+// Package capture record rpc frames on transport to file with timestamp and direction, and read them back.
+// file starts with magic, followed by records of
+// | nanosecond timestamp int64 | direction uint8 | transport id uint32 | length uint32 | frame |, big endian
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+)
+
+const (
+	magic      = "IRPCCAP1"
+	recordHead = 8 + 1 + 4 + 4
+	maxFrame   = 64 << 20
+)
+
+// Direction of captured frame
+type Direction uint8
+
+const (
+	In  Direction = iota + 1 // received by transport
+	Out                      // sent by transport
+)
+
+func (d Direction) String() string {
+	switch d {
+	case In:
+		return "IN"
+	case Out:
+		return "OUT"
+	}
+	return "UNKNOWN"
+}
+
+type (
+	// Packet captured frame
+	Packet struct {
+		Time time.Time
+		Dir  Direction
+		Conn uint32 // id of transport
+		Data []byte // whole frame, header included
+	}
+
+	// Writer write packets to capture file, safe for concurrent use
+	Writer struct {
+		mu     sync.Mutex
+		w      io.Writer
+		closer io.Closer
+	}
+
+	// Reader read packets from capture file
+	Reader struct {
+		r      *bufio.Reader
+		closer io.Closer
+	}
+)
+
+// NewWriter write magic and return writer on w
+func NewWriter(w io.Writer) (*Writer, error) {
+	if _, err := io.WriteString(w, magic); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w}, nil
+}
+
+// Create create capture file, truncate it if exists
+func Create(path string) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewWriter(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	w.closer = f
+	return w, nil
+}
+
+// WritePacket write one record, whole record is written at once
+func (w *Writer) WritePacket(p *Packet) error {
+	record := make([]byte, recordHead+len(p.Data))
+	binary.BigEndian.PutUint64(record, uint64(p.Time.UnixNano()))
+	record[8] = byte(p.Dir)
+	binary.BigEndian.PutUint32(record[9:], p.Conn)
+	binary.BigEndian.PutUint32(record[13:], uint32(len(p.Data)))
+	copy(record[recordHead:], p.Data)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.w.Write(record)
+	return err
+}
+
+// Close close file created by Create
+func (w *Writer) Close() error {
+	if w.closer == nil {
+		return nil
+	}
+	return w.closer.Close()
+}
+
+// NewReader check magic and return reader on r
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	head := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, head); err != nil || string(head) != magic {
+		return nil, errors.NewRpcError(errors.CommErr, "not a capture file")
+	}
+	return &Reader{r: br}, nil
+}
+
+// Open open capture file
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+// Next read next packet, io.EOF at end of capture
+func (r *Reader) Next() (*Packet, error) {
+	head := make([]byte, recordHead)
+	if _, err := io.ReadFull(r.r, head); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.NewRpcError(errors.CommErr, "truncated capture record")
+		}
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(head[13:])
+	if length > maxFrame {
+		return nil, errors.NewRpcError(errors.CommErr, "capture record of %d bytes is too large", length)
+	}
+	p := &Packet{
+		Time: time.Unix(0, int64(binary.BigEndian.Uint64(head))),
+		Dir:  Direction(head[8]),
+		Conn: binary.BigEndian.Uint32(head[9:]),
+		Data: make([]byte, length),
+	}
+	if _, err := io.ReadFull(r.r, p.Data); err != nil {
+		return nil, errors.NewRpcError(errors.CommErr, "truncated capture record")
+	}
+	return p, nil
+}
+
+// Close close file opened by Open
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
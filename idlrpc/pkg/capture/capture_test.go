@@ -0,0 +1,97 @@
+package capture
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/transport"
+)
+
+// nopTransport transport keeping nothing
+type nopTransport struct {
+	transport.ITransport
+	sent     int
+	received []byte
+}
+
+func (t *nopTransport) Send(pkg []byte) error {
+	t.sent++
+	return nil
+}
+
+func (t *nopTransport) Write(pkg []byte, length int) (int, error) {
+	t.received = append(t.received, pkg[:length]...)
+	return length, nil
+}
+
+func (t *nopTransport) GetID() uint32 {
+	return 9
+}
+
+func frame(callId uint32, body string) []byte {
+	pkg, _ := protocol.PackRespMsg(&protocol.ResponsePackage{
+		Header: &protocol.RpcCallRetHeader{
+			RpcMsgHeader: protocol.RpcMsgHeader{Length: uint32(protocol.RespHeadSize + len(body)), Type: protocol.ResponseMsg},
+			CallID:       callId,
+			ErrorCode:    protocol.IDL_SUCCESS,
+		},
+		Buffer: []byte(body),
+	})
+	return append([]byte(nil), pkg...)
+}
+
+func TestCaptureTransport(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "capture")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rpc.cap")
+	w, err := Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := &nopTransport{}
+	trans := NewTransport(inner, w)
+
+	out := frame(1, "request")
+	_ = trans.Send(out)
+	// received frames arrive in pieces
+	in1, in2 := frame(2, "first"), frame(3, "second")
+	stream := append(append([]byte(nil), in1...), in2...)
+	for _, piece := range [][]byte{stream[:5], stream[5 : len(in1)+3], stream[len(in1)+3:]} {
+		_, _ = trans.Write(piece, len(piece))
+	}
+	_ = w.Close()
+	if inner.sent != 1 || !bytes.Equal(inner.received, stream) {
+		t.Fatal("wrapped transport not called")
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	expects := []struct {
+		dir  Direction
+		data []byte
+	}{{Out, out}, {In, in1}, {In, in2}}
+	for _, expect := range expects {
+		p, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Dir != expect.dir || p.Conn != 9 || !bytes.Equal(p.Data, expect.data) || p.Time.IsZero() {
+			t.Fatalf("unexpected packet %s %d %x", p.Dir, p.Conn, p.Data)
+		}
+	}
+	if _, err = r.Next(); err != io.EOF {
+		t.Fatalf("expect end of capture, got %v", err)
+	}
+
+	if _, err = NewReader(bytes.NewReader([]byte("not capture"))); err == nil {
+		t.Fatal("invalid capture accepted")
+	}
+}
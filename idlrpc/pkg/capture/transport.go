@@ -0,0 +1,91 @@
+package capture
+
+import (
+	"sync"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/transport"
+)
+
+// Transport decorator of transport writing sent and received frames to capture,
+// capture error never fails the transport
+type Transport struct {
+	transport.ITransport
+	w       *Writer
+	wire    protocol.Protocol
+	mu      sync.Mutex
+	pending []byte // received bytes of incomplete frame
+}
+
+// NewTransport capture frames on trans to w
+func NewTransport(trans transport.ITransport, w *Writer) *Transport {
+	wire := protocol.Default()
+	if pt, ok := trans.(transport.IProtocolTransport); ok && pt.Protocol() != nil {
+		wire = pt.Protocol()
+	}
+	return &Transport{ITransport: trans, w: w, wire: wire}
+}
+
+func (t *Transport) Send(pkg []byte) error {
+	t.record(Out, pkg)
+	return t.ITransport.Send(pkg)
+}
+
+// Write received bytes are split into frames by header length
+func (t *Transport) Write(pkg []byte, length int) (int, error) {
+	n, err := t.ITransport.Write(pkg, length)
+	if err != nil {
+		return n, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, pkg[:length]...)
+	for len(t.pending) >= protocol.RpcHeadSize {
+		header := &protocol.RpcMsgHeader{}
+		if !t.wire.ReadHeader(t.pending, header) || int(header.Length) < protocol.RpcHeadSize {
+			// broken stream, keep what we have and start over
+			t.record(In, t.pending)
+			t.pending = nil
+			break
+		}
+		if len(t.pending) < int(header.Length) {
+			break
+		}
+		t.record(In, t.pending[:header.Length])
+		t.pending = append([]byte(nil), t.pending[header.Length:]...)
+	}
+	return n, nil
+}
+
+// ReadFrame frame of wrapped transport, or read by Read if not supported
+func (t *Transport) ReadFrame(length int) ([]byte, error) {
+	if fr, ok := t.ITransport.(transport.IFrameReader); ok {
+		return fr.ReadFrame(length)
+	}
+	frame := make([]byte, length)
+	if n, err := t.ITransport.Read(frame, length); err != nil || n != length {
+		return nil, errors.ErrIllegalProto
+	}
+	return frame, nil
+}
+
+// Protocol wire format of wrapped transport
+func (t *Transport) Protocol() protocol.Protocol {
+	if pt, ok := t.ITransport.(transport.IProtocolTransport); ok {
+		return pt.Protocol()
+	}
+	return nil
+}
+
+// CopyOnSend same as wrapped transport, frame is copied to capture during Send
+func (t *Transport) CopyOnSend() bool {
+	cs, ok := t.ITransport.(transport.ICopySender)
+	return ok && cs.CopyOnSend()
+}
+
+func (t *Transport) record(dir Direction, frame []byte) {
+	_ = t.w.WritePacket(&Packet{Time: time.Now(), Dir: dir, Conn: t.GetID(), Data: frame})
+}
@@ -23,8 +23,12 @@ const (
 	ServicePanic
 	SERVICE_NOT_FOUND
 	FUNCTION_NOT_FOUND
+	ServiceBusy
 )
 
+// ExceptionCodeMin codes of idl exceptions start from it, smaller codes are reserved by framework
+const ExceptionCodeMin RpcErrorCode = 1000
+
 var (
 	ErrRpcNotFound  = gerror.New("rpc service or method not found")
 	ErrRpcTimeOut   = gerror.New("rpc proxy call time out")
@@ -36,20 +40,34 @@ var (
 )
 
 var (
-	ErrTransClose      = &RpcError{TransportClosed, "Transport has been closed"}
-	ErrProxyInvalid    = &RpcError{ProxyNotExist, "Proxy Is Invalid"}
-	ErrStubCallInvalid = &RpcError{StubcallInvalid, "stub's call invalid"}
-	ErrServicePanic    = &RpcError{ServicePanic, "service exec panic"}
+	ErrTransClose      = &RpcError{errCode: TransportClosed, errStr: "Transport has been closed"}
+	ErrProxyInvalid    = &RpcError{errCode: ProxyNotExist, errStr: "Proxy Is Invalid"}
+	ErrStubCallInvalid = &RpcError{errCode: StubcallInvalid, errStr: "stub's call invalid"}
+	ErrServicePanic    = &RpcError{errCode: ServicePanic, errStr: "service exec panic"}
 	ErrIllegalReq      = &RpcError{errCode: CommErr, errStr: "invalid request message!"}
 	ErrIllegalProto    = &RpcError{errCode: CommErr, errStr: "rpc protocol message buffer error !"}
+	ErrCallReplied     = &RpcError{errCode: StubcallInvalid, errStr: "stub call has been replied"}
 )
 
+// IException error carrying a numeric code across rpc call,
+// implemented by RpcError and exception types generated from idl
+type IException interface {
+	error
+	ExceptionCode() uint32
+	ExceptionName() string
+}
+
 type RpcError struct {
 	errCode RpcErrorCode
 	errStr  string
+	name    string // exception name, empty for framework error
+	cause   error  // wrapped error, ErrRpcRet for remote error
 }
 
 func (re *RpcError) Error() string {
+	if re.name != "" {
+		return re.name + ": " + re.errStr
+	}
 	return re.errStr
 }
 
@@ -57,48 +75,110 @@ func (re *RpcError) Code() RpcErrorCode {
 	return re.errCode
 }
 
+// Name exception name declared in idl, empty if remote returned plain error
+func (re *RpcError) Name() string {
+	return re.name
+}
+
+// Detail error message without exception name
+func (re *RpcError) Detail() string {
+	return re.errStr
+}
+
+func (re *RpcError) ExceptionCode() uint32 {
+	return uint32(re.errCode)
+}
+
+func (re *RpcError) ExceptionName() string {
+	return re.name
+}
+
+// Unwrap remote error unwraps to ErrRpcRet
+func (re *RpcError) Unwrap() error {
+	return re.cause
+}
+
+// Is framework errors only match themselves, which is checked by errors.Is already.
+// exception matches target with same code and same non-empty name
+func (re *RpcError) Is(target error) bool {
+	ex, ok := target.(IException)
+	if !ok || re.name == "" {
+		return false
+	}
+	return ex.ExceptionCode() == uint32(re.errCode) && ex.ExceptionName() == re.name
+}
+
 func NewRpcError(code RpcErrorCode, format string, args ...interface{}) *RpcError {
 	return &RpcError{
 		errCode: code,
-		errStr:  fmt.Sprintf(format, args),
+		errStr:  fmt.Sprintf(format, args...),
+	}
+}
+
+// NewException create exception with name, returned by service method to tell caller what happened
+func NewException(code RpcErrorCode, name, format string, args ...interface{}) *RpcError {
+	return &RpcError{
+		errCode: code,
+		errStr:  fmt.Sprintf(format, args...),
+		name:    name,
+	}
+}
+
+// NewRemoteError error returned by remote service method, unwraps to ErrRpcRet
+func NewRemoteError(code RpcErrorCode, name, detail string) *RpcError {
+	return &RpcError{
+		errCode: code,
+		errStr:  detail,
+		name:    name,
+		cause:   ErrRpcRet,
 	}
 }
 
 func NewProxyNotExit(proxyid uint32) *RpcError {
 	return &RpcError{
-		ProxyNotExist,
-		fmt.Sprintf("Proxy %d Not Exist", proxyid),
+		errCode: ProxyNotExist,
+		errStr:  fmt.Sprintf("Proxy %d Not Exist", proxyid),
 	}
 }
 
 func NewProxyDisconnected(uuid uint64, id uint32, name string) *RpcError {
 	return &RpcError{
-		ProxyDisconnected,
-		fmt.Sprintf("%s's proxy uuid:%d id:%d has been disconnected", name, uuid, id),
+		errCode: ProxyDisconnected,
+		errStr:  fmt.Sprintf("%s's proxy uuid:%d id:%d has been disconnected", name, uuid, id),
 	}
 }
 
 func NewMethodExecError(service, method string) *RpcError {
 	return &RpcError{
-		MethodException,
-		fmt.Sprintf("%::%s execute error !", service, method),
+		errCode: MethodException,
+		errStr:  fmt.Sprintf("%s::%s execute error !", service, method),
 	}
 }
 
 func NewServiceNotExist(uuid uint64) *RpcError {
 	return &RpcError{
-		ServiceNotExist,
-		fmt.Sprintf("service %d not exist", uuid),
+		errCode: ServiceNotExist,
+		errStr:  fmt.Sprintf("service %d not exist", uuid),
 	}
 }
 
 func NewProxyNotFound(callid uint32) *RpcError {
 	return &RpcError{
-		ProxyCallNoFound,
-		fmt.Sprintf("proxy call %d not exits", callid),
+		errCode: ProxyCallNoFound,
+		errStr:  fmt.Sprintf("proxy call %d not exits", callid),
 	}
 }
 
+// Is same as standard errors.Is, for generated code which imports this package as errors
+func Is(err, target error) bool {
+	return gerror.Is(err, target)
+}
+
+// As same as standard errors.As
+func As(err error, target interface{}) bool {
+	return gerror.As(err, target)
+}
+
 type RpcPanicInfo struct {
 	Info interface{}
 	Pkg  []byte
@@ -0,0 +1,39 @@
+package errors
+
+import (
+	gerror "errors"
+	"fmt"
+	"testing"
+)
+
+type goldException struct{}
+
+func (e *goldException) Error() string         { return "NotEnoughGold" }
+func (e *goldException) ExceptionCode() uint32 { return 1001 }
+func (e *goldException) ExceptionName() string { return "NotEnoughGold" }
+
+func TestRpcErrorIs(t *testing.T) {
+	remote := NewRemoteError(1001, "NotEnoughGold", "need gold")
+	cases := []struct {
+		name   string
+		err    error
+		target error
+		match  bool
+	}{
+		{"sentinel itself", fmt.Errorf("wrap: %w", ErrCallReplied), ErrCallReplied, true},
+		{"sentinel same code", ErrCallReplied, ErrStubCallInvalid, false},
+		{"framework error by sentinel code", NewRpcError(ProxyNotExist, "proxy 1"), ErrProxyInvalid, false},
+		{"exception", remote, &goldException{}, true},
+		{"exception unwraps to ret", remote, ErrRpcRet, true},
+		{"plain remote error", NewRemoteError(1001, "", "need gold"), &goldException{}, false},
+		{"empty target name", remote, NewRpcError(1001, "any"), false},
+		{"other name", remote, NewException(1001, "ItemLocked", ""), false},
+		{"other code", NewRemoteError(1002, "NotEnoughGold", ""), &goldException{}, false},
+		{"same exception", remote, NewException(1001, "NotEnoughGold", ""), true},
+	}
+	for _, c := range cases {
+		if gerror.Is(c.err, c.target) != c.match {
+			t.Errorf("%s: Is expect %t", c.name, c.match)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// printfLogger adapt ILogger to IFieldLogger, fields are formatted as key=value after message
+type printfLogger struct {
+	logger ILogger
+	fields []Field
+}
+
+// FromLogger adapt printf style ILogger to IFieldLogger
+func FromLogger(logger ILogger) IFieldLogger {
+	if logger == nil {
+		return NopLogger()
+	}
+	// unwrap adapter, avoid double formatting
+	if fl, ok := logger.(*fieldPrintfLogger); ok {
+		return fl.logger
+	}
+	return &printfLogger{logger: logger}
+}
+
+func (p *printfLogger) Debug(msg string, fields ...Field) {
+	p.logger.Debug("%s", p.format(msg, fields))
+}
+
+func (p *printfLogger) Info(msg string, fields ...Field) {
+	p.logger.Info("%s", p.format(msg, fields))
+}
+
+func (p *printfLogger) Warn(msg string, fields ...Field) {
+	p.logger.Warn("%s", p.format(msg, fields))
+}
+
+func (p *printfLogger) Error(msg string, fields ...Field) {
+	p.logger.Error("%s", p.format(msg, fields))
+}
+
+func (p *printfLogger) Fatal(msg string, fields ...Field) {
+	p.logger.Fatal("%s", p.format(msg, fields))
+}
+
+func (p *printfLogger) With(fields ...Field) IFieldLogger {
+	return &printfLogger{
+		logger: p.logger,
+		fields: joinFields(p.fields, fields),
+	}
+}
+
+func (p *printfLogger) format(msg string, fields []Field) string {
+	if len(p.fields) == 0 && len(fields) == 0 {
+		return msg
+	}
+	builder := strings.Builder{}
+	builder.WriteString(msg)
+	for _, fs := range [][]Field{p.fields, fields} {
+		for _, f := range fs {
+			builder.WriteByte(' ')
+			builder.WriteString(f.Key)
+			builder.WriteByte('=')
+			builder.WriteString(formatValue(f.Value))
+		}
+	}
+	return builder.String()
+}
+
+func formatValue(v interface{}) string {
+	str := fmt.Sprint(v)
+	if str == "" || strings.ContainsAny(str, " \t\n\"=") {
+		return strconv.Quote(str)
+	}
+	return str
+}
+
+// joinFields copy fields to a new slice, loggers created by With must not share backing array
+func joinFields(base, fields []Field) []Field {
+	all := make([]Field, 0, len(base)+len(fields))
+	all = append(all, base...)
+	return append(all, fields...)
+}
+
+// fieldPrintfLogger adapt IFieldLogger to printf style ILogger
+type fieldPrintfLogger struct {
+	logger IFieldLogger
+}
+
+// ToLogger adapt IFieldLogger to printf style ILogger, formatted text become the message
+func ToLogger(logger IFieldLogger) ILogger {
+	if logger == nil {
+		return nil
+	}
+	if pl, ok := logger.(*printfLogger); ok && len(pl.fields) == 0 {
+		return pl.logger
+	}
+	return &fieldPrintfLogger{logger: logger}
+}
+
+func (f *fieldPrintfLogger) Debug(format string, args ...interface{}) {
+	f.logger.Debug(fmt.Sprintf(format, args...))
+}
+
+func (f *fieldPrintfLogger) Info(format string, args ...interface{}) {
+	f.logger.Info(fmt.Sprintf(format, args...))
+}
+
+func (f *fieldPrintfLogger) Warn(format string, args ...interface{}) {
+	f.logger.Warn(fmt.Sprintf(format, args...))
+}
+
+func (f *fieldPrintfLogger) Error(format string, args ...interface{}) {
+	f.logger.Error(fmt.Sprintf(format, args...))
+}
+
+// Fatal forwards to Fatal of wrapped logger, logs as error while it is not IFatalLogger
+func (f *fieldPrintfLogger) Fatal(format string, args ...interface{}) {
+	if fl, ok := f.logger.(IFatalLogger); ok {
+		fl.Fatal(fmt.Sprintf(format, args...))
+		return
+	}
+	f.logger.Error(fmt.Sprintf(format, args...))
+}
+
+// nopLogger drop all logs
+type nopLogger struct{}
+
+// NopLogger IFieldLogger which logs nothing
+func NopLogger() IFieldLogger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(string, ...Field)       {}
+func (nopLogger) Info(string, ...Field)        {}
+func (nopLogger) Warn(string, ...Field)        {}
+func (nopLogger) Error(string, ...Field)       {}
+func (n nopLogger) With(...Field) IFieldLogger { return n }
@@ -0,0 +1,114 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// lineLogger printf logger keeping last line of each level
+type lineLogger struct {
+	lines map[string]string
+}
+
+func newLineLogger() *lineLogger {
+	return &lineLogger{lines: make(map[string]string)}
+}
+
+func (l *lineLogger) Debug(format string, args ...interface{}) {
+	l.lines["debug"] = fmt.Sprintf(format, args...)
+}
+func (l *lineLogger) Info(format string, args ...interface{}) {
+	l.lines["info"] = fmt.Sprintf(format, args...)
+}
+func (l *lineLogger) Warn(format string, args ...interface{}) {
+	l.lines["warn"] = fmt.Sprintf(format, args...)
+}
+func (l *lineLogger) Error(format string, args ...interface{}) {
+	l.lines["error"] = fmt.Sprintf(format, args...)
+}
+func (l *lineLogger) Fatal(format string, args ...interface{}) {
+	l.lines["fatal"] = fmt.Sprintf(format, args...)
+}
+
+func TestPrintfLogger(t *testing.T) {
+	ll := newLineLogger()
+	fl := FromLogger(ll)
+
+	fl.Info("plain")
+	fl.Warn("call", KV("id", 7), KV("name", "a b"), KV("empty", ""))
+	if ll.lines["info"] != "plain" || ll.lines["warn"] != `call id=7 name="a b" empty=""` {
+		t.Fatalf("unexpected lines %v", ll.lines)
+	}
+
+	// With merges fields, siblings must not share fields
+	base := fl.With(KV("service", "Player"))
+	login := base.With(KV("method", "login"))
+	logout := base.With(KV("method", "logout"))
+	login.Error("failed", KV("code", 3))
+	if ll.lines["error"] != "failed service=Player method=login code=3" {
+		t.Fatalf("unexpected line %q", ll.lines["error"])
+	}
+	logout.Debug("done")
+	if ll.lines["debug"] != "done service=Player method=logout" {
+		t.Fatalf("unexpected line %q", ll.lines["debug"])
+	}
+	base.(IFatalLogger).Fatal("stop")
+	if ll.lines["fatal"] != "stop service=Player" {
+		t.Fatalf("unexpected line %q", ll.lines["fatal"])
+	}
+}
+
+func TestToLogger(t *testing.T) {
+	ll := newLineLogger()
+	if ToLogger(FromLogger(ll)) != ILogger(ll) {
+		t.Fatal("adapter without fields is not unwrapped")
+	}
+	if FromLogger(ToLogger(NopLogger())) != NopLogger() {
+		t.Fatal("field logger is not unwrapped")
+	}
+
+	pl := ToLogger(FromLogger(ll).With(KV("service", "Player")))
+	pl.Info("%s %d", "call", 1)
+	pl.Fatal("exit %d", 2)
+	if ll.lines["info"] != "call 1 service=Player" || ll.lines["fatal"] != "exit 2 service=Player" {
+		t.Fatalf("unexpected lines %v", ll.lines)
+	}
+	if _, ok := ll.lines["error"]; ok {
+		t.Fatal("fatal is logged as error")
+	}
+	// logger without fatal level logs as error
+	ToLogger(NopLogger()).Fatal("nothing")
+}
+
+func TestContextLogger(t *testing.T) {
+	if FromContext(context.Background()) != NopLogger() || FieldsFromContext(context.Background()) != nil {
+		t.Fatal("empty context carries logger")
+	}
+
+	ll := newLineLogger()
+	fields := []Field{KV("callID", 1)}
+	ctx := NewContext(context.Background(), FromLogger(ll).With(fields...), fields...)
+	FromContext(ctx).Info("hi")
+	if ll.lines["info"] != "hi callID=1" {
+		t.Fatalf("unexpected line %q", ll.lines["info"])
+	}
+	got := FieldsFromContext(ctx)
+	got[0].Value = 2
+	if fields[0].Value != 1 {
+		t.Fatal("fields of context modified by caller")
+	}
+
+	loads := 0
+	ctx = NewLazyContext(context.Background(), func() (IFieldLogger, []Field) {
+		loads++
+		return FromLogger(ll), fields
+	})
+	if loads != 0 {
+		t.Fatal("lazy logger built before lookup")
+	}
+	FromContext(ctx).Warn("lazy")
+	if loads != 1 || ll.lines["warn"] != "lazy" || len(FieldsFromContext(ctx)) != 1 {
+		t.Fatalf("lazy logger loads %d lines %v", loads, ll.lines)
+	}
+}
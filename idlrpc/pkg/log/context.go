@@ -0,0 +1,58 @@
+package log
+
+import "context"
+
+// ctxKey context key of call logger
+type ctxKey struct{}
+
+type ctxLogger struct {
+	logger IFieldLogger
+	fields []Field
+	load   func() (IFieldLogger, []Field) // build logger and fields on lookup, nil if given directly
+}
+
+func (cl *ctxLogger) get() (IFieldLogger, []Field) {
+	if cl.load != nil {
+		return cl.load()
+	}
+	return cl.logger, cl.fields
+}
+
+// NewContext return context carrying logger and fields, logger should have fields attached already
+func NewContext(ctx context.Context, logger IFieldLogger, fields ...Field) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &ctxLogger{
+		logger: logger,
+		fields: fields,
+	})
+}
+
+// NewLazyContext return context carrying logger and fields built by load when handler asks for them,
+// load is called on every lookup and should cache what it builds
+func NewLazyContext(ctx context.Context, load func() (IFieldLogger, []Field)) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &ctxLogger{load: load})
+}
+
+// FromContext get logger of the call, which logs with service, method, callID, globalIndex and traceID,
+// return a logger logs nothing if ctx carries no logger
+func FromContext(ctx context.Context) IFieldLogger {
+	if ctx == nil {
+		return NopLogger()
+	}
+	if cl, ok := ctx.Value(ctxKey{}).(*ctxLogger); ok {
+		logger, _ := cl.get()
+		return logger
+	}
+	return NopLogger()
+}
+
+// FieldsFromContext get fields of the call, for handlers using their own logger
+func FieldsFromContext(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	if cl, ok := ctx.Value(ctxKey{}).(*ctxLogger); ok {
+		_, fields := cl.get()
+		return append([]Field(nil), fields...)
+	}
+	return nil
+}
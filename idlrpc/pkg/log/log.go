@@ -8,3 +8,40 @@ type ILogger interface {
 	Error(format string, args ...interface{})
 	Fatal(format string, args ...interface{})
 }
+
+// keys of fields attached by framework to every log line of a call
+const (
+	KeyService     = "service"
+	KeyMethod      = "method"
+	KeyCallID      = "callID"
+	KeyGlobalIndex = "globalIndex"
+	KeyTraceID     = "traceID"
+)
+
+type (
+	// Field key value pair of structured log
+	Field struct {
+		Key   string
+		Value interface{}
+	}
+
+	// IFieldLogger structured logger interface, message with key value fields
+	IFieldLogger interface {
+		Debug(msg string, fields ...Field)
+		Info(msg string, fields ...Field)
+		Warn(msg string, fields ...Field)
+		Error(msg string, fields ...Field)
+		// With return logger which attaches fields to every log line
+		With(fields ...Field) IFieldLogger
+	}
+
+	// IFatalLogger optional fatal level of IFieldLogger, Fatal of ToLogger forwards to it
+	IFatalLogger interface {
+		Fatal(msg string, fields ...Field)
+	}
+)
+
+// KV create log field
+func KV(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
@@ -0,0 +1,71 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogLogger adapt slog.Handler to IFieldLogger
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// LevelFatal slog level of Fatal, slog has no fatal level
+const LevelFatal = slog.LevelError + 4
+
+// FromSlogHandler adapt standard library slog handler to IFieldLogger.
+// only built with go 1.21+, rest of the framework still builds with go 1.13
+func FromSlogHandler(handler slog.Handler) IFieldLogger {
+	return &slogLogger{logger: slog.New(handler)}
+}
+
+func (s *slogLogger) Debug(msg string, fields ...Field) {
+	s.log(slog.LevelDebug, msg, fields)
+}
+
+func (s *slogLogger) Info(msg string, fields ...Field) {
+	s.log(slog.LevelInfo, msg, fields)
+}
+
+func (s *slogLogger) Warn(msg string, fields ...Field) {
+	s.log(slog.LevelWarn, msg, fields)
+}
+
+func (s *slogLogger) Error(msg string, fields ...Field) {
+	s.log(slog.LevelError, msg, fields)
+}
+
+func (s *slogLogger) Fatal(msg string, fields ...Field) {
+	s.log(LevelFatal, msg, fields)
+}
+
+func (s *slogLogger) With(fields ...Field) IFieldLogger {
+	return &slogLogger{logger: s.logger.With(toAttrs(fields)...)}
+}
+
+func (s *slogLogger) log(level slog.Level, msg string, fields []Field) {
+	ctx := context.Background()
+	if !s.logger.Enabled(ctx, level) {
+		return
+	}
+	s.logger.LogAttrs(ctx, level, msg, toAttrArgs(fields)...)
+}
+
+func toAttrArgs(fields []Field) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+	return attrs
+}
+
+func toAttrs(fields []Field) []interface{} {
+	args := make([]interface{}, 0, len(fields))
+	for _, f := range fields {
+		args = append(args, slog.Any(f.Key, f.Value))
+	}
+	return args
+}
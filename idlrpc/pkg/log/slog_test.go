@@ -0,0 +1,49 @@
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	fl := FromSlogHandler(handler)
+
+	fl.Debug("hidden")
+	if buf.Len() != 0 {
+		t.Fatalf("debug logged below level: %s", buf.String())
+	}
+
+	base := fl.With(KV("service", "Player"))
+	base.With(KV("method", "login")).Warn("failed", KV("code", 3))
+	base.With(KV("method", "logout")).Info("done")
+	ToLogger(base).Fatal("exit %d", 1)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expect := []string{
+		"level=WARN msg=failed service=Player method=login code=3",
+		"level=INFO msg=done service=Player method=logout",
+		`level=ERROR+4 msg="exit 1" service=Player`,
+	}
+	if len(lines) != len(expect) {
+		t.Fatalf("unexpected lines %q", lines)
+	}
+	for i := range expect {
+		if lines[i] != expect[i] {
+			t.Fatalf("line %d expect %q, got %q", i, expect[i], lines[i])
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package protocol
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// size classes of pooled buffers, power of 2 from 64B to 64KB, larger buffers are not pooled
+const (
+	minBufferShift = 6
+	maxBufferShift = 16
+)
+
+var (
+	bufferPools [maxBufferShift - minBufferShift + 1]sync.Pool
+	// holderPool slice headers put into buffer pools, avoid allocation while converting slice to interface
+	holderPool = sync.Pool{New: func() interface{} { return new([]byte) }}
+)
+
+// bufferClass index of the smallest size class holding size bytes, -1 if size is too large
+func bufferClass(size int) int {
+	if size <= 1<<minBufferShift {
+		return 0
+	}
+	class := bits.Len(uint(size-1)) - minBufferShift
+	if class >= len(bufferPools) {
+		return -1
+	}
+	return class
+}
+
+// GetBuffer get buffer of size bytes from pool, content is not zeroed
+func GetBuffer(size int) []byte {
+	class := bufferClass(size)
+	if class < 0 {
+		return make([]byte, size)
+	}
+	if h, ok := bufferPools[class].Get().(*[]byte); ok {
+		buf := *h
+		*h = nil
+		holderPool.Put(h)
+		return buf[:size]
+	}
+	return make([]byte, size, 1<<(class+minBufferShift))
+}
+
+// PutBuffer put buffer got by GetBuffer back to pool, buffer must not be used after that.
+// buffers not allocated by GetBuffer are ignored
+func PutBuffer(buf []byte) {
+	class := bufferClass(cap(buf))
+	if class < 0 || cap(buf) != 1<<(class+minBufferShift) {
+		return
+	}
+	h := holderPool.Get().(*[]byte)
+	*h = buf[:0]
+	bufferPools[class].Put(h)
+}
@@ -0,0 +1,53 @@
+package protocol
+
+import "testing"
+
+func TestBufferPool(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 65, 1000, 1 << 16} {
+		buf := GetBuffer(size)
+		if len(buf) != size || cap(buf)&(cap(buf)-1) != 0 || cap(buf) < 64 {
+			t.Fatalf("size %d got len %d cap %d", size, len(buf), cap(buf))
+		}
+		PutBuffer(buf)
+	}
+	// large buffer is not pooled
+	if buf := GetBuffer(1<<16 + 1); cap(buf) != 1<<16+1 {
+		t.Fatalf("large buffer cap %d", cap(buf))
+	}
+	// buffer not from pool is ignored
+	PutBuffer(make([]byte, 100))
+	if buf := GetBuffer(100); cap(buf) != 128 {
+		t.Fatalf("foreign buffer pooled, cap %d", cap(buf))
+	}
+}
+
+func BenchmarkPackRespMsg(b *testing.B) {
+	body := make([]byte, 200)
+	resp := &ResponsePackage{Buffer: body}
+	BuildRespHeader(resp, 0, 1, IDL_SUCCESS)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pkg, _ := PackRespMsg(resp)
+		PutBuffer(pkg)
+	}
+}
+
+func BenchmarkParseCallHeader(b *testing.B) {
+	pkg, _ := PackReqMsg(&RequestPackage{
+		Header: &RpcCallHeader{
+			RpcMsgHeader: RpcMsgHeader{Length: uint32(CallHeadSize), Type: RequestMsg},
+			ServiceUUID:  1,
+			CallID:       2,
+			MethodID:     3,
+		},
+	})
+
+	b.ReportAllocs()
+	var header RpcCallHeader
+	for i := 0; i < b.N; i++ {
+		if !ParseCallHeader(pkg, &header) {
+			b.Fatal("parse call header failed")
+		}
+	}
+}
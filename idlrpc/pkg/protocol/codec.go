@@ -0,0 +1,339 @@
+package protocol
+
+import "encoding/binary"
+
+// IHeader codec of protocol header, packed big endian layout same as c++ backend
+type IHeader interface {
+	HeaderSize() int        // encoded size of header
+	Encode(pkg []byte) bool // write header to the front of pkg, false if pkg is too short
+	Decode(pkg []byte) bool // read header from the front of pkg, false if pkg is too short
+}
+
+// encoded size of headers
+const (
+	msgHeaderSize       = 8
+	callHeaderSize      = msgHeaderSize + 20
+	callRetHeaderSize   = msgHeaderSize + 12
+	proxyCallHeaderSize = msgHeaderSize + 26
+	proxyRetHeaderSize  = msgHeaderSize + 16
+	subHeaderSize       = msgHeaderSize + 40
+	pubHeaderSize       = msgHeaderSize + 24
+	cancelHeaderSize    = msgHeaderSize + 16
+	pingHeaderSize      = msgHeaderSize + 8
+	pongHeaderSize      = msgHeaderSize + 8
+	timeoutHeaderSize   = msgHeaderSize + 4
+	loggedOutHeaderSize = msgHeaderSize + 4
+)
+
+func (h *RpcMsgHeader) HeaderSize() int {
+	return msgHeaderSize
+}
+
+func (h *RpcMsgHeader) Encode(pkg []byte) bool {
+	if len(pkg) < msgHeaderSize {
+		return false
+	}
+	binary.BigEndian.PutUint32(pkg[0:], h.Length)
+	binary.BigEndian.PutUint32(pkg[4:], h.Type)
+	return true
+}
+
+func (h *RpcMsgHeader) Decode(pkg []byte) bool {
+	if len(pkg) < msgHeaderSize {
+		return false
+	}
+	h.Length = binary.BigEndian.Uint32(pkg[0:])
+	h.Type = binary.BigEndian.Uint32(pkg[4:])
+	return true
+}
+
+func (h *RpcCallHeader) HeaderSize() int {
+	return callHeaderSize
+}
+
+func (h *RpcCallHeader) Encode(pkg []byte) bool {
+	if len(pkg) < callHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Encode(pkg)
+	binary.BigEndian.PutUint64(pkg[8:], h.ServiceUUID)
+	binary.BigEndian.PutUint32(pkg[16:], h.ServerID)
+	binary.BigEndian.PutUint32(pkg[20:], h.CallID)
+	binary.BigEndian.PutUint32(pkg[24:], h.MethodID)
+	return true
+}
+
+func (h *RpcCallHeader) Decode(pkg []byte) bool {
+	if len(pkg) < callHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Decode(pkg)
+	h.ServiceUUID = binary.BigEndian.Uint64(pkg[8:])
+	h.ServerID = binary.BigEndian.Uint32(pkg[16:])
+	h.CallID = binary.BigEndian.Uint32(pkg[20:])
+	h.MethodID = binary.BigEndian.Uint32(pkg[24:])
+	return true
+}
+
+func (h *RpcProxyCallHeader) HeaderSize() int {
+	return proxyCallHeaderSize
+}
+
+func (h *RpcProxyCallHeader) Encode(pkg []byte) bool {
+	if len(pkg) < proxyCallHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Encode(pkg)
+	binary.BigEndian.PutUint64(pkg[8:], h.ServiceUUID)
+	binary.BigEndian.PutUint32(pkg[16:], h.ServerID)
+	binary.BigEndian.PutUint32(pkg[20:], h.CallID)
+	binary.BigEndian.PutUint32(pkg[24:], h.MethodID)
+	binary.BigEndian.PutUint32(pkg[28:], uint32(h.GlobalIndex))
+	binary.BigEndian.PutUint16(pkg[32:], h.OneWay)
+	return true
+}
+
+func (h *RpcProxyCallHeader) Decode(pkg []byte) bool {
+	if len(pkg) < proxyCallHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Decode(pkg)
+	h.ServiceUUID = binary.BigEndian.Uint64(pkg[8:])
+	h.ServerID = binary.BigEndian.Uint32(pkg[16:])
+	h.CallID = binary.BigEndian.Uint32(pkg[20:])
+	h.MethodID = binary.BigEndian.Uint32(pkg[24:])
+	h.GlobalIndex = GlobalIndexType(binary.BigEndian.Uint32(pkg[28:]))
+	h.OneWay = binary.BigEndian.Uint16(pkg[32:])
+	return true
+}
+
+func (h *RpcCallRetHeader) HeaderSize() int {
+	return callRetHeaderSize
+}
+
+func (h *RpcCallRetHeader) Encode(pkg []byte) bool {
+	if len(pkg) < callRetHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Encode(pkg)
+	binary.BigEndian.PutUint32(pkg[8:], h.ServerID)
+	binary.BigEndian.PutUint32(pkg[12:], h.CallID)
+	binary.BigEndian.PutUint32(pkg[16:], h.ErrorCode)
+	return true
+}
+
+func (h *RpcCallRetHeader) Decode(pkg []byte) bool {
+	if len(pkg) < callRetHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Decode(pkg)
+	h.ServerID = binary.BigEndian.Uint32(pkg[8:])
+	h.CallID = binary.BigEndian.Uint32(pkg[12:])
+	h.ErrorCode = binary.BigEndian.Uint32(pkg[16:])
+	return true
+}
+
+func (h *RpcProxyCallRetHeader) HeaderSize() int {
+	return proxyRetHeaderSize
+}
+
+func (h *RpcProxyCallRetHeader) Encode(pkg []byte) bool {
+	if len(pkg) < proxyRetHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Encode(pkg)
+	binary.BigEndian.PutUint32(pkg[8:], h.ServerID)
+	binary.BigEndian.PutUint32(pkg[12:], h.CallID)
+	binary.BigEndian.PutUint32(pkg[16:], h.ErrorCode)
+	binary.BigEndian.PutUint32(pkg[20:], uint32(h.GlobalIndex))
+	return true
+}
+
+func (h *RpcProxyCallRetHeader) Decode(pkg []byte) bool {
+	if len(pkg) < proxyRetHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Decode(pkg)
+	h.ServerID = binary.BigEndian.Uint32(pkg[8:])
+	h.CallID = binary.BigEndian.Uint32(pkg[12:])
+	h.ErrorCode = binary.BigEndian.Uint32(pkg[16:])
+	h.GlobalIndex = GlobalIndexType(binary.BigEndian.Uint32(pkg[20:]))
+	return true
+}
+
+func (h *RpcSubHeader) HeaderSize() int {
+	return subHeaderSize
+}
+
+func (h *RpcSubHeader) Encode(pkg []byte) bool {
+	if len(pkg) < subHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Encode(pkg)
+	copy(pkg[8:24], h.SubId[:])
+	binary.BigEndian.PutUint32(pkg[24:], h.ProxyId)
+	binary.BigEndian.PutUint64(pkg[28:], h.ServiceUUID)
+	binary.BigEndian.PutUint32(pkg[36:], h.ServiceID)
+	binary.BigEndian.PutUint32(pkg[40:], h.NameLen)
+	binary.BigEndian.PutUint32(pkg[44:], h.DataLen)
+	return true
+}
+
+func (h *RpcSubHeader) Decode(pkg []byte) bool {
+	if len(pkg) < subHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Decode(pkg)
+	copy(h.SubId[:], pkg[8:24])
+	h.ProxyId = binary.BigEndian.Uint32(pkg[24:])
+	h.ServiceUUID = binary.BigEndian.Uint64(pkg[28:])
+	h.ServiceID = binary.BigEndian.Uint32(pkg[36:])
+	h.NameLen = binary.BigEndian.Uint32(pkg[40:])
+	h.DataLen = binary.BigEndian.Uint32(pkg[44:])
+	return true
+}
+
+func (h *RpcPubHeader) HeaderSize() int {
+	return pubHeaderSize
+}
+
+func (h *RpcPubHeader) Encode(pkg []byte) bool {
+	if len(pkg) < pubHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Encode(pkg)
+	copy(pkg[8:24], h.SubId[:])
+	binary.BigEndian.PutUint32(pkg[24:], h.ProxyId)
+	binary.BigEndian.PutUint32(pkg[28:], h.ValueLen)
+	return true
+}
+
+func (h *RpcPubHeader) Decode(pkg []byte) bool {
+	if len(pkg) < pubHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Decode(pkg)
+	copy(h.SubId[:], pkg[8:24])
+	h.ProxyId = binary.BigEndian.Uint32(pkg[24:])
+	h.ValueLen = binary.BigEndian.Uint32(pkg[28:])
+	return true
+}
+
+func (h *RpcCancelSubHeader) HeaderSize() int {
+	return cancelHeaderSize
+}
+
+func (h *RpcCancelSubHeader) Encode(pkg []byte) bool {
+	if len(pkg) < cancelHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Encode(pkg)
+	copy(pkg[8:24], h.SubId[:])
+	return true
+}
+
+func (h *RpcCancelSubHeader) Decode(pkg []byte) bool {
+	if len(pkg) < cancelHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Decode(pkg)
+	copy(h.SubId[:], pkg[8:24])
+	return true
+}
+
+func (h *RpcPingHeader) HeaderSize() int {
+	return pingHeaderSize
+}
+
+func (h *RpcPingHeader) Encode(pkg []byte) bool {
+	if len(pkg) < pingHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Encode(pkg)
+	binary.BigEndian.PutUint64(pkg[8:], h.PingId)
+	return true
+}
+
+func (h *RpcPingHeader) Decode(pkg []byte) bool {
+	if len(pkg) < pingHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Decode(pkg)
+	h.PingId = binary.BigEndian.Uint64(pkg[8:])
+	return true
+}
+
+func (h *RpcPongHeader) HeaderSize() int {
+	return pongHeaderSize
+}
+
+func (h *RpcPongHeader) Encode(pkg []byte) bool {
+	if len(pkg) < pongHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Encode(pkg)
+	binary.BigEndian.PutUint64(pkg[8:], h.PingId)
+	return true
+}
+
+func (h *RpcPongHeader) Decode(pkg []byte) bool {
+	if len(pkg) < pongHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Decode(pkg)
+	h.PingId = binary.BigEndian.Uint64(pkg[8:])
+	return true
+}
+
+func (h *RpcTimeoutHeader) HeaderSize() int {
+	return timeoutHeaderSize
+}
+
+func (h *RpcTimeoutHeader) Encode(pkg []byte) bool {
+	if len(pkg) < timeoutHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Encode(pkg)
+	binary.BigEndian.PutUint32(pkg[8:], uint32(h.GlobalIndexId))
+	return true
+}
+
+func (h *RpcTimeoutHeader) Decode(pkg []byte) bool {
+	if len(pkg) < timeoutHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Decode(pkg)
+	h.GlobalIndexId = GlobalIndexType(binary.BigEndian.Uint32(pkg[8:]))
+	return true
+}
+
+func (h *RpcLoggedOutHeader) HeaderSize() int {
+	return loggedOutHeaderSize
+}
+
+func (h *RpcLoggedOutHeader) Encode(pkg []byte) bool {
+	if len(pkg) < loggedOutHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Encode(pkg)
+	binary.BigEndian.PutUint32(pkg[8:], uint32(h.GlobalIndexId))
+	return true
+}
+
+func (h *RpcLoggedOutHeader) Decode(pkg []byte) bool {
+	if len(pkg) < loggedOutHeaderSize {
+		return false
+	}
+	h.RpcMsgHeader.Decode(pkg)
+	h.GlobalIndexId = GlobalIndexType(binary.BigEndian.Uint32(pkg[8:]))
+	return true
+}
+
+// packMsg encode header followed by body into pooled buffer
+func packMsg(header IHeader, body []byte) ([]byte, int) {
+	size := header.HeaderSize()
+	pkg := GetBuffer(size + len(body))
+	header.Encode(pkg)
+	copy(pkg[size:], body)
+	return pkg, len(pkg)
+}
@@ -0,0 +1,91 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// headerOfType header struct of every plato message type
+func headerOfType(msgType uint32) IHeader {
+	switch msgType {
+	case RequestMsg:
+		return &RpcCallHeader{}
+	case ResponseMsg:
+		return &RpcCallRetHeader{}
+	case ProxyRequestMsg:
+		return &RpcProxyCallHeader{}
+	case ProxyResponseMsg:
+		return &RpcProxyCallRetHeader{}
+	case RpcEventSub:
+		return &RpcSubHeader{}
+	case RpcEventPub:
+		return &RpcPubHeader{}
+	case RpcEventCancel:
+		return &RpcCancelSubHeader{}
+	case RpcPing:
+		return &RpcPingHeader{}
+	case RpcPong:
+		return &RpcPongHeader{}
+	case RpcTimeout:
+		return &RpcTimeoutHeader{}
+	case RpcLoggedOut:
+		return &RpcLoggedOutHeader{}
+	default:
+		// NotRpcMsg, RpcCallAlias
+		return &RpcMsgHeader{}
+	}
+}
+
+func TestHeaderCodec(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	proto := &binaryProtocol{}
+	for msgType := RequestMsg; msgType < RpcProtocolMax; msgType++ {
+		for i := 0; i < 100; i++ {
+			value, _ := quick.Value(reflect.TypeOf(headerOfType(msgType)).Elem(), rnd)
+			header := value.Addr().Interface().(IHeader)
+			body := make([]byte, rnd.Intn(64))
+			rnd.Read(body)
+
+			// layout must be same as packed c++ struct
+			ref := &bytes.Buffer{}
+			_ = binary.Write(ref, binary.BigEndian, header)
+			if ref.Len() != header.HeaderSize() {
+				t.Fatalf("type %d header size %d, expect %d", msgType, header.HeaderSize(), ref.Len())
+			}
+			ref.Write(body)
+
+			pkg, length := proto.PackPlatoMsg(header, body, 0)
+			if length != ref.Len() || !bytes.Equal(pkg[:length], ref.Bytes()) {
+				t.Fatalf("type %d encode %x, expect %x", msgType, pkg[:length], ref.Bytes())
+			}
+
+			decoded := headerOfType(msgType)
+			if !proto.ParsePlatoHeader(pkg, decoded) || !reflect.DeepEqual(decoded, header) {
+				t.Fatalf("type %d decode %+v, expect %+v", msgType, decoded, header)
+			}
+			if decoded.Decode(pkg[:header.HeaderSize()-1]) || header.Encode(make([]byte, header.HeaderSize()-1)) {
+				t.Fatalf("type %d accept short buffer", msgType)
+			}
+			PutBuffer(pkg)
+		}
+	}
+}
+
+func TestSubHeaderId(t *testing.T) {
+	header := &RpcSubHeader{ProxyId: 3, ServiceUUID: 4, ServiceID: 5}
+	copy(header.SubId[:], "0123456789abcdef")
+	pkg, _ := PackSubMsg(&RpcSubPackage{Header: header, Buffer: []byte("event")})
+	defer PutBuffer(pkg)
+
+	got := ReadSubHeader(pkg)
+	if got == nil || *got != *header {
+		t.Fatalf("sub header %+v, expect %+v", got, header)
+	}
+	if ReadSubHeader(pkg[:subHeaderSize-1]) != nil {
+		t.Fatal("short sub message parsed")
+	}
+}
@@ -0,0 +1,148 @@
+package protocol
+
+import (
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// keys of framework data carried in the Context message of method args
+const (
+	CtxTimeoutKey = "rpc_timeout" // remaining call timeout in millisecond
+	CtxTraceKey   = "rpc_trace"   // trace id of the call chain
+)
+
+// ctxFieldName name of Context field in generated method args, see golang_pb_layer.py
+const ctxFieldName = "ctx"
+
+// CtxField number of Context field in method args message, 0 while args has no Context field.
+// every language generator numbers Context after arguments and trace_info, so it is found by name
+func CtxField(args protoreflect.MessageDescriptor) protowire.Number {
+	if args == nil {
+		return 0
+	}
+	fd := args.Fields().ByName(ctxFieldName)
+	if fd == nil || fd.Kind() != protoreflect.MessageKind || fd.IsList() {
+		return 0
+	}
+	return fd.Number()
+}
+
+// ArgsDescriptor args message <service>_<method>_args generated by golang_pb_layer.py,
+// nil while the generated message is not linked into program
+func ArgsDescriptor(service, method string) protoreflect.MessageDescriptor {
+	name := protoreflect.Name(service + "_" + method + "_args")
+	var md protoreflect.MessageDescriptor
+	protoregistry.GlobalFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		md = fd.Messages().ByName(name)
+		return md == nil
+	})
+	return md
+}
+
+// AppendCtxInfo append key values as Context field num to serialized method args, caller's message is not touched.
+// the field is merged with Context already in body, later value of the same key wins. body is unchanged while num is 0
+func AppendCtxInfo(body []byte, num protowire.Number, info map[string]string) []byte {
+	if num == 0 || len(info) == 0 {
+		return body
+	}
+	keys := make([]string, 0, len(info))
+	for k := range info {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var ctx []byte
+	for _, k := range keys {
+		var kv []byte
+		kv = protowire.AppendTag(kv, 1, protowire.BytesType)
+		kv = protowire.AppendString(kv, k)
+		kv = protowire.AppendTag(kv, 2, protowire.BytesType)
+		kv = protowire.AppendString(kv, info[k])
+		ctx = protowire.AppendTag(ctx, 1, protowire.BytesType)
+		ctx = protowire.AppendBytes(ctx, kv)
+	}
+	body = protowire.AppendTag(body, num, protowire.BytesType)
+	return protowire.AppendBytes(body, ctx)
+}
+
+// ReadCtxInfo read all key values of Context field num from serialized method args, see CtxField.
+// malformed Context is ignored
+func ReadCtxInfo(body []byte, num protowire.Number) map[string]string {
+	if num == 0 {
+		return nil
+	}
+	var info map[string]string
+	for len(body) > 0 {
+		field, typ, n := protowire.ConsumeTag(body)
+		if n < 0 {
+			return info
+		}
+		body = body[n:]
+		if field != num || typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(field, typ, body)
+			if n < 0 {
+				return info
+			}
+			body = body[n:]
+			continue
+		}
+		ctx, n := protowire.ConsumeBytes(body)
+		if n < 0 {
+			return info
+		}
+		body = body[n:]
+		if info == nil {
+			info = make(map[string]string)
+		}
+		if !parseCtx(ctx, info) {
+			return nil
+		}
+	}
+	return info
+}
+
+// parseCtx decode Context message {repeated {string key = 1; string value = 2;} info = 1;} into info
+func parseCtx(field []byte, info map[string]string) bool {
+	for len(field) > 0 {
+		num, typ, n := protowire.ConsumeTag(field)
+		if n < 0 || num != 1 || typ != protowire.BytesType {
+			return false
+		}
+		field = field[n:]
+		kv, n := protowire.ConsumeBytes(field)
+		if n < 0 {
+			return false
+		}
+		field = field[n:]
+		key, value, ok := parseKeyValue(kv)
+		if !ok {
+			return false
+		}
+		info[key] = value
+	}
+	return true
+}
+
+func parseKeyValue(kv []byte) (key, value string, ok bool) {
+	for len(kv) > 0 {
+		num, typ, n := protowire.ConsumeTag(kv)
+		if n < 0 || typ != protowire.BytesType || (num != 1 && num != 2) {
+			return "", "", false
+		}
+		kv = kv[n:]
+		str, n := protowire.ConsumeString(kv)
+		if n < 0 {
+			return "", "", false
+		}
+		kv = kv[n:]
+		if num == 1 {
+			key = str
+		} else {
+			value = str
+		}
+	}
+	return key, value, true
+}
@@ -0,0 +1,99 @@
+package protocol
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestCtxInfo(t *testing.T) {
+	// args: string arg1 = 1; trace_info = 2 looking like Context; Context ctx = 3;
+	body := protowire.AppendTag(nil, 1, protowire.BytesType)
+	body = protowire.AppendString(body, "hello")
+	body = AppendCtxInfo(body, 2, map[string]string{"x": "y"})
+
+	body = AppendCtxInfo(body, 3, map[string]string{CtxTimeoutKey: "100", "user": "data"})
+	body = AppendCtxInfo(body, 3, map[string]string{CtxTimeoutKey: "200"})
+
+	info := ReadCtxInfo(body, 3)
+	if info[CtxTimeoutKey] != "200" || info["user"] != "data" || len(info) != 2 {
+		t.Fatalf("unexpected ctx info %v", info)
+	}
+	if got := AppendCtxInfo(body, 3, nil); len(got) != len(body) {
+		t.Fatal("empty info changes body")
+	}
+	if got := AppendCtxInfo(body, 0, info); len(got) != len(body) || ReadCtxInfo(body, 0) != nil {
+		t.Fatal("args without Context field carry context")
+	}
+}
+
+func TestCtxInfoAbsent(t *testing.T) {
+	body := protowire.AppendTag(nil, 1, protowire.BytesType)
+	body = protowire.AppendString(body, CtxTimeoutKey)
+	if info := ReadCtxInfo(body, 2); len(info) != 0 {
+		t.Fatalf("unexpected ctx info %v", info)
+	}
+	// malformed Context
+	bad := protowire.AppendTag(body, 2, protowire.BytesType)
+	bad = protowire.AppendBytes(bad, []byte{0x08, 0x01})
+	if info := ReadCtxInfo(bad, 2); len(info) != 0 {
+		t.Fatalf("unexpected ctx info %v", info)
+	}
+}
+
+func TestCtxField(t *testing.T) {
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	msg := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	opt := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	rep := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	// messages like golang_pb_layer.py generates, Context is numbered after arguments and trace_info
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("ctx_field_test.proto"),
+		Package: proto.String("ctxfieldtest"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("KeyValue"), Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("key"), Number: proto.Int32(1), Type: str, Label: opt},
+				{Name: proto.String("value"), Number: proto.Int32(2), Type: str, Label: opt},
+			}},
+			{Name: proto.String("Context"), Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("info"), Number: proto.Int32(1), Type: msg, Label: rep, TypeName: proto.String(".ctxfieldtest.KeyValue")},
+			}},
+			{Name: proto.String("Player_login_args"), Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("arg1"), Number: proto.Int32(1), Type: str, Label: opt},
+				{Name: proto.String("arg2"), Number: proto.Int32(2), Type: str, Label: opt},
+				{Name: proto.String("trace_info"), Number: proto.Int32(3), Type: msg, Label: opt, TypeName: proto.String(".ctxfieldtest.KeyValue")},
+				{Name: proto.String("ctx"), Number: proto.Int32(4), Type: msg, Label: opt, TypeName: proto.String(".ctxfieldtest.Context")},
+			}},
+			{Name: proto.String("Player_logout_args"), Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("arg1"), Number: proto.Int32(1), Type: str, Label: opt},
+			}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		method string
+		found  bool
+		num    protowire.Number
+	}{
+		{"login", true, 4},
+		{"logout", true, 0},
+		{"unknown", false, 0},
+	}
+	for _, c := range cases {
+		args := ArgsDescriptor("Player", c.method)
+		if (args != nil) != c.found || CtxField(args) != c.num {
+			t.Errorf("%s: args %v ctx field %d, expect found %v field %d", c.method, args, CtxField(args), c.found, c.num)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package protocol
+
+import "google.golang.org/protobuf/encoding/protowire"
+
+// field numbers of generated method ret message, see golang_pb_layer.py
+// message ExceptionInfo { string name = 1; string detail = 2; uint32 code = 3; }
+const (
+	retExecInfoField protowire.Number = 3
+	execNameField    protowire.Number = 1
+	execDetailField  protowire.Number = 2
+	execCodeField    protowire.Number = 3
+)
+
+// AppendExceptionInfo append exec_info field to serialized method ret message,
+// works for empty body of void method too
+func AppendExceptionInfo(body []byte, code uint32, name, detail string) []byte {
+	info := make([]byte, 0, len(name)+len(detail)+16)
+	if name != "" {
+		info = protowire.AppendTag(info, execNameField, protowire.BytesType)
+		info = protowire.AppendString(info, name)
+	}
+	if detail != "" {
+		info = protowire.AppendTag(info, execDetailField, protowire.BytesType)
+		info = protowire.AppendString(info, detail)
+	}
+	if code != 0 {
+		info = protowire.AppendTag(info, execCodeField, protowire.VarintType)
+		info = protowire.AppendVarint(info, uint64(code))
+	}
+	body = protowire.AppendTag(body, retExecInfoField, protowire.BytesType)
+	return protowire.AppendBytes(body, info)
+}
+
+// ReadExceptionInfo read exec_info field from serialized method ret message,
+// ok is false while remote not send exception info
+func ReadExceptionInfo(body []byte) (code uint32, name, detail string, ok bool) {
+	for len(body) > 0 {
+		num, typ, n := protowire.ConsumeTag(body)
+		if n < 0 {
+			return
+		}
+		body = body[n:]
+		if num != retExecInfoField || typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, body)
+			if n < 0 {
+				return
+			}
+			body = body[n:]
+			continue
+		}
+		info, n := protowire.ConsumeBytes(body)
+		if n < 0 {
+			return
+		}
+		body = body[n:]
+		// repeated message field merges, later value wins
+		if !mergeExceptionInfo(info, &code, &name, &detail) {
+			return 0, "", "", false
+		}
+		ok = true
+	}
+	return
+}
+
+func mergeExceptionInfo(info []byte, code *uint32, name, detail *string) bool {
+	for len(info) > 0 {
+		num, typ, n := protowire.ConsumeTag(info)
+		if n < 0 {
+			return false
+		}
+		info = info[n:]
+		switch {
+		case num == execNameField && typ == protowire.BytesType:
+			*name, n = protowire.ConsumeString(info)
+		case num == execDetailField && typ == protowire.BytesType:
+			*detail, n = protowire.ConsumeString(info)
+		case num == execCodeField && typ == protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(info)
+			*code = uint32(v)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, info)
+		}
+		if n < 0 {
+			return false
+		}
+		info = info[n:]
+	}
+	return true
+}
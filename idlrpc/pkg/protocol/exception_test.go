@@ -0,0 +1,25 @@
+package protocol
+
+import "testing"
+
+func TestExceptionInfo(t *testing.T) {
+	// void method ret without body, and ret with other fields before exec_info
+	for _, body := range [][]byte{nil, {0x0a, 0x02, 'o', 'k'}} {
+		pkg := AppendExceptionInfo(body, 1001, "NotEnoughGold", "need 100 gold")
+		code, name, detail, ok := ReadExceptionInfo(pkg)
+		if !ok || code != 1001 || name != "NotEnoughGold" || detail != "need 100 gold" {
+			t.Fatalf("unexpected exception info %d %q %q %v", code, name, detail, ok)
+		}
+	}
+
+	// later exec_info overrides former one
+	pkg := AppendExceptionInfo(nil, 1, "", "first")
+	pkg = AppendExceptionInfo(pkg, 2, "", "second")
+	if code, _, detail, ok := ReadExceptionInfo(pkg); !ok || code != 2 || detail != "second" {
+		t.Fatalf("unexpected merged exception info %d %q", code, detail)
+	}
+
+	if _, _, _, ok := ReadExceptionInfo([]byte{0x0a, 0x02, 'o', 'k'}); ok {
+		t.Fatal("read exception info from ret without exec_info")
+	}
+}
@@ -40,10 +40,17 @@ func init() {
 	})
 }
 
+// SetProtocol replace default protocol, which is used by package helpers and
+// rpc instances without their own protocol
 func SetProtocol(cusProto Protocol) {
 	curprotocol = cusProto
 }
 
+// Default return default protocol
+func Default() Protocol {
+	return curprotocol
+}
+
 func ReadHeader(pkg []byte) *RpcMsgHeader {
 	if curprotocol == nil {
 		return nil
@@ -81,6 +88,26 @@ func ReadProxyCallHeader(pkg []byte) *RpcProxyCallHeader {
 	return header
 }
 
+// ParseCallHeader decode request header in place, without allocation
+func ParseCallHeader(pkg []byte, header *RpcCallHeader) bool {
+	return curprotocol != nil && curprotocol.ParseReqMsg(pkg, header)
+}
+
+// ParseProxyCallHeader decode proxy request header in place, without allocation
+func ParseProxyCallHeader(pkg []byte, header *RpcProxyCallHeader) bool {
+	return curprotocol != nil && curprotocol.ParseProxyReqMsg(pkg, header)
+}
+
+// ParseRetHeader decode response header in place, without allocation
+func ParseRetHeader(pkg []byte, header *RpcCallRetHeader) bool {
+	return curprotocol != nil && curprotocol.ParseRespMsg(pkg, header)
+}
+
+// ParseProxyRetHeader decode proxy response header in place, without allocation
+func ParseProxyRetHeader(pkg []byte, header *RpcProxyCallRetHeader) bool {
+	return curprotocol != nil && curprotocol.ParseProxyRespMsg(pkg, header)
+}
+
 func ReadRetHeader(pkg []byte) *RpcCallRetHeader {
 	if curprotocol == nil {
 		return nil
@@ -187,3 +214,59 @@ func PackTimeMsg(resp *RpcTimeoutPackage) ([]byte, int) {
 func PackLoggedOutMsg(resp *RpcLoggedOutPackage) ([]byte, int) {
 	return curprotocol.PackPlatoMsg(resp.Header, nil, int(resp.Header.Length))
 }
+
+func ReadPongHeader(pkg []byte) *RpcPongHeader {
+	if curprotocol == nil {
+		return nil
+	}
+	header := &RpcPongHeader{}
+	if curprotocol.ParsePlatoHeader(pkg, header) == false {
+		return nil
+	}
+	return header
+}
+
+func ReadSubHeader(pkg []byte) *RpcSubHeader {
+	if curprotocol == nil {
+		return nil
+	}
+	header := &RpcSubHeader{}
+	if curprotocol.ParseSubMsg(pkg, header) == false {
+		return nil
+	}
+	return header
+}
+
+func ReadPubHeader(pkg []byte) *RpcPubHeader {
+	if curprotocol == nil {
+		return nil
+	}
+	header := &RpcPubHeader{}
+	if curprotocol.ParsePubMsg(pkg, header) == false {
+		return nil
+	}
+	return header
+}
+
+func ReadCancelHeader(pkg []byte) *RpcCancelSubHeader {
+	if curprotocol == nil {
+		return nil
+	}
+	header := &RpcCancelSubHeader{}
+	if curprotocol.ParseCancelMsg(pkg, header) == false {
+		return nil
+	}
+	return header
+}
+
+func PackSubMsg(msg *RpcSubPackage) ([]byte, int) {
+	return curprotocol.PackSubMsg(msg)
+}
+
+func PackPubMsg(msg *RpcPubPackage) ([]byte, int) {
+	return curprotocol.PackPubMsg(msg)
+}
+
+func PackCancelMsg(msg *RpcCancelPackage) ([]byte, int) {
+	return curprotocol.PackCancelMsg(msg)
+}
@@ -1,8 +1,6 @@
 package protocol
 
 import (
-	"encoding/binary"
-
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/common"
 )
 
@@ -34,18 +32,18 @@ const (
 )
 
 var (
-	RpcHeadSize         int
-	CallHeadSize        int
-	RespHeadSize        int
-	ProxyCallHeadSize   int
-	ProxyRetHeadSize    int
-	SubHeaderSize       int
-	PubHeaderSize       int
-	CancelHeaderSize    int
-	PingHeaderSize      int
-	PongHeaderSize      int
-	TimeoutHeaderSize   int
-	LoggedOutHeaderSize int
+	RpcHeadSize         = msgHeaderSize
+	CallHeadSize        = callHeaderSize
+	RespHeadSize        = callRetHeaderSize
+	ProxyCallHeadSize   = proxyCallHeaderSize
+	ProxyRetHeadSize    = proxyRetHeaderSize
+	SubHeaderSize       = subHeaderSize
+	PubHeaderSize       = pubHeaderSize
+	CancelHeaderSize    = cancelHeaderSize
+	PingHeaderSize      = pingHeaderSize
+	PongHeaderSize      = pongHeaderSize
+	TimeoutHeaderSize   = timeoutHeaderSize
+	LoggedOutHeaderSize = loggedOutHeaderSize
 )
 
 // RpcMsgHeader 协议包头 协议类型 协议长度
@@ -138,21 +136,6 @@ type (
 	}
 )
 
-func init() {
-	RpcHeadSize = binary.Size(RpcMsgHeader{})
-	CallHeadSize = binary.Size(RpcCallHeader{})
-	RespHeadSize = binary.Size(RpcCallRetHeader{})
-	ProxyCallHeadSize = binary.Size(RpcProxyCallHeader{})
-	ProxyRetHeadSize = binary.Size(RpcProxyCallRetHeader{})
-	SubHeaderSize = binary.Size(RpcSubHeader{})
-	PubHeaderSize = binary.Size(RpcPubHeader{})
-	CancelHeaderSize = binary.Size(RpcCancelSubHeader{})
-	PingHeaderSize = binary.Size(RpcPingHeader{})
-	PongHeaderSize = binary.Size(RpcPongHeader{})
-	TimeoutHeaderSize = binary.Size(RpcTimeoutHeader{})
-	LoggedOutHeaderSize = binary.Size(RpcLoggedOutHeader{})
-}
-
 func BuildRespHeader(resp *ResponsePackage, srvID uint32, callID uint32, errcode uint32) {
 	if resp == nil {
 		return
@@ -25,3 +25,21 @@ type ITransport interface {
 	GlobalIndex() protocol.GlobalIndexType     // outside global index id
 	Heartbeat() error                          // 触发一次心跳逻辑
 }
+
+// IFrameReader optional interface of ITransport, hands over a complete frame without copying.
+// frame is owned by framework after ReadFrame returns, transport must not reuse its memory
+type IFrameReader interface {
+	ReadFrame(length int) ([]byte, error) //移除并返回 length 长度的完整数据帧
+}
+
+// ICopySender optional interface of ITransport, transport which is done with pkg when Send returns.
+// framework puts pooled pkg back to protocol buffer pool after Send while CopyOnSend returns true
+type ICopySender interface {
+	CopyOnSend() bool
+}
+
+// IProtocolTransport optional interface of ITransport, transport speaks its own wire format.
+// protocol of rpc instance is used while Protocol returns nil
+type IProtocolTransport interface {
+	Protocol() protocol.Protocol
+}
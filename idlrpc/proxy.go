@@ -43,12 +43,13 @@ func (base *ProxyBase) GetID() ProxyId {
 	return base.id
 }
 
+// SetTargetID set by response while other goroutine may be calling
 func (base *ProxyBase) SetTargetID(id uint32) {
-	base.targetId = id
+	atomic.StoreUint32(&base.targetId, id)
 }
 
 func (base *ProxyBase) GetTargetID() uint32 {
-	return base.targetId
+	return atomic.LoadUint32(&base.targetId)
 }
 
 func (base *ProxyBase) GetTransport() transport.ITransport {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/logger"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/log"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/transport"
 	"sync"
@@ -32,6 +33,7 @@ type ProxyManager struct {
 	outsideProxyCache outSideTpCache  //outside transport cache
 	factory           proxyFactoryMap //proxy factory
 	mux               sync.RWMutex    //mutex
+	logger            log.ILogger     //logger of rpc instance
 }
 
 // newProxyManager create new proxy manager, init proxymap and mutex
@@ -43,24 +45,28 @@ func newProxyManager() *ProxyManager {
 		outsideProxyCache: make(outSideTpCache),
 		factory:           make(proxyFactoryMap),
 		mux:               sync.RWMutex{},
+		logger:            &logger.NullLogger{},
 	}
 }
 
+func (p *ProxyManager) Init(logger log.ILogger) {
+	p.logger = logger
+}
+
 func (p *ProxyManager) GeneProxyId() ProxyId {
 	return ProxyId(atomic.AddUint32((*uint32)(&p.pId), 1))
 }
 
 func (p *ProxyManager) Add(proxy IProxy) error {
-	if proxy == nil {
-		logger.Error("[ProxyManager] Invalid invalid proxy interface")
-		return errors.NewRpcError(errors.CommErr, "invalid proxy interface")
-	}
-
 	if p == nil {
-		logger.Error("[ProxyManager] proxy manager not init while add proxy: %d, %s", proxy.GetUUID(), proxy.GetSrvName())
 		return errors.NewRpcError(errors.CommErr, "invalid proxy manager")
 	}
 
+	if proxy == nil {
+		p.logger.Error("[ProxyManager] Invalid invalid proxy interface")
+		return errors.NewRpcError(errors.CommErr, "invalid proxy interface")
+	}
+
 	//generate proxy id
 	proxyId := p.GeneProxyId()
 	proxy.SetID(proxyId)
@@ -75,7 +81,7 @@ func (p *ProxyManager) Add(proxy IProxy) error {
 	defer p.mux.Unlock()
 
 	if _, ok := p.proxyMap[proxyId]; ok {
-		logger.Error("[IProxy] %s,%d,%d proxy has been exist", proxy.GetSrvName(), proxy.GetUUID(), proxy.GetID())
+		p.logger.Error("[IProxy] %s,%d,%d proxy has been exist", proxy.GetSrvName(), proxy.GetUUID(), proxy.GetID())
 		return errors.ErrProxyInvalid
 	}
 	//proxy id has been set while create thie struct
@@ -112,7 +118,6 @@ func (p *ProxyManager) Add(proxy IProxy) error {
 
 func (p *ProxyManager) Get(proxyId ProxyId) (IProxy, error) {
 	if p == nil {
-		logger.Error("[ProxyManager] proxy manager init error while get proxyId %d", proxyId)
 		return nil, errors.NewRpcError(errors.CommErr, "invalid proxy manager")
 	}
 
@@ -129,7 +134,6 @@ func (p *ProxyManager) Get(proxyId ProxyId) (IProxy, error) {
 
 func (p *ProxyManager) Destroy(proxyId ProxyId) error {
 	if p == nil {
-		logger.Error("[ProxyManager] %d,0,0 proxy manager is valid", proxyId)
 		return errors.NewRpcError(errors.CommErr, "invalid proxy manager")
 	}
 
@@ -208,7 +212,7 @@ func (p *ProxyManager) getOrCreateProxy(uuid uint64, globalIndex protocol.Global
 
 func (p *ProxyManager) closeOutsideProxy(outsideId protocol.GlobalIndexType) error {
 	if outsideId == InvalidGlobalIndex {
-		logger.Warn("Failed to clear expired connections because the parameter is invalid")
+		p.logger.Warn("Failed to clear expired connections because the parameter is invalid")
 		return fmt.Errorf("Failed to clear expired connections because the parameter is invalid! ")
 	}
 
@@ -0,0 +1,235 @@
+// rpcbench load test of running service over tcp, with dynamic idl client.
+//
+//	rpcbench -idl game.idl.go.json -proto game.desc -addr host:port [-c 8] [-rate 1000] [-d 30s] [-n 0] [-conns 1] [-csv out.csv] \
+//	    -call 'Service.method*3={"arg1": 1}' -call 'Service.other'
+//
+// -call is Service.method[*weight][=arguments], arguments are json like rpccall.
+// without -rate every worker calls one request after another, with -rate requests are issued
+// on schedule and -c limits calls in flight
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/idlclient"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/idljson"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/bench"
+	"google.golang.org/protobuf/proto"
+)
+
+const defaultTimeout = 5000 // millisecond, method without idl timeout
+
+type (
+	listFlags []string
+
+	// command parsed flags
+	command struct {
+		idls        listFlags
+		protos      listFlags
+		calls       listFlags
+		addr        string
+		concurrency int
+		rate        float64
+		duration    time.Duration
+		total       int
+		conns       int
+		timeout     uint
+		csv         string
+		schema      *idljson.Schema
+		msgs        idlclient.Messages
+	}
+
+	// target parsed -call
+	target struct {
+		srv    *idljson.Service
+		m      *idljson.Method
+		weight int
+		args   proto.Message
+	}
+)
+
+func (f *listFlags) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlags) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	cmd := &command{}
+	flags := flag.NewFlagSet("rpcbench", flag.ContinueOnError)
+	flags.Var(&cmd.idls, "idl", "idl json used by idl2go, can be repeated")
+	flags.Var(&cmd.protos, "proto", "protobuf descriptor set of idl, can be repeated")
+	flags.Var(&cmd.calls, "call", "request of mix, Service.method[*weight][=arguments], can be repeated")
+	flags.StringVar(&cmd.addr, "addr", "127.0.0.1:9090", "tcp address of service")
+	flags.IntVar(&cmd.concurrency, "c", 1, "workers, max calls in flight with -rate")
+	flags.Float64Var(&cmd.rate, "rate", 0, "requests per second, fixed concurrency while 0")
+	flags.DurationVar(&cmd.duration, "d", 10*time.Second, "test duration")
+	flags.IntVar(&cmd.total, "n", 0, "stop after n requests, 0 no limit")
+	flags.IntVar(&cmd.conns, "conns", 1, "tcp connections, calls are spread over them")
+	flags.UintVar(&cmd.timeout, "timeout", 0, "call timeout in millisecond, default idl timeout or 5000")
+	flags.StringVar(&cmd.csv, "csv", "", "write result csv to file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := cmd.load(); err != nil {
+		return err
+	}
+	targets, err := cmd.targets()
+	if err != nil {
+		return err
+	}
+	if cmd.conns <= 0 {
+		return fmt.Errorf("invalid connection number %d", cmd.conns)
+	}
+
+	services := make([]*idljson.Service, 0, len(cmd.schema.Services))
+	for _, srv := range cmd.schema.Services {
+		services = append(services, srv)
+	}
+	clients := make([]*idlclient.Client, 0, cmd.conns)
+	defer func() {
+		for _, c := range clients {
+			c.Close()
+		}
+	}()
+	for i := 0; i < cmd.conns; i++ {
+		c, err := idlclient.Dial(cmd.addr, time.Duration(cmd.callTimeout(nil))*time.Millisecond, services, idlrpc.WithCallTimeout(defaultTimeout, 0))
+		if err != nil {
+			return err
+		}
+		clients = append(clients, c)
+	}
+
+	var next uint32
+	mix := make([]*bench.Request, 0, len(targets))
+	for _, tg := range targets {
+		tg := tg
+		timeout := cmd.callTimeout(tg.m)
+		mix = append(mix, &bench.Request{
+			Name:   tg.srv.Name + "." + tg.m.Name,
+			Weight: tg.weight,
+			Call: func() error {
+				c := clients[int(atomic.AddUint32(&next, 1))%len(clients)]
+				_, err := c.Call(tg.srv, tg.m, timeout, 0, tg.args)
+				return err
+			},
+		})
+	}
+
+	opts := []bench.Option{bench.WithConcurrency(cmd.concurrency), bench.WithDuration(cmd.duration), bench.WithTotal(cmd.total)}
+	if cmd.rate > 0 {
+		opts = append(opts, bench.WithRate(cmd.rate))
+	}
+	res, err := bench.Run(mix, opts...)
+	if err != nil {
+		return err
+	}
+	_ = res.WriteSummary(out)
+	if cmd.csv == "" {
+		return nil
+	}
+	f, err := os.Create(cmd.csv)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return res.WriteCSV(f)
+}
+
+func (cmd *command) load() error {
+	if len(cmd.idls) == 0 {
+		return fmt.Errorf("no idl json, set -idl")
+	}
+	cmd.schema = idljson.NewSchema()
+	for _, path := range cmd.idls {
+		if err := cmd.schema.Load(path); err != nil {
+			return err
+		}
+	}
+	cmd.msgs = make(idlclient.Messages)
+	for _, path := range cmd.protos {
+		if err := cmd.msgs.Load(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callTimeout timeout flag, or idl timeout of method
+func (cmd *command) callTimeout(m *idljson.Method) uint32 {
+	switch {
+	case cmd.timeout > 0:
+		return uint32(cmd.timeout)
+	case m != nil && m.TimeOut > 0:
+		return m.TimeOut
+	}
+	return defaultTimeout
+}
+
+// targets parse -call flags
+func (cmd *command) targets() ([]*target, error) {
+	if len(cmd.calls) == 0 {
+		return nil, fmt.Errorf("no request, set -call")
+	}
+	targets := make([]*target, 0, len(cmd.calls))
+	for _, call := range cmd.calls {
+		tg, err := cmd.parseCall(call)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, tg)
+	}
+	return targets, nil
+}
+
+// parseCall Service.method[*weight][=arguments]
+func (cmd *command) parseCall(call string) (*target, error) {
+	name, input := call, ""
+	if eq := strings.IndexByte(call, '='); eq >= 0 {
+		name, input = call[:eq], call[eq+1:]
+	}
+	tg := &target{weight: 1}
+	if star := strings.IndexByte(name, '*'); star >= 0 {
+		weight, err := strconv.Atoi(name[star+1:])
+		if err != nil || weight <= 0 {
+			return nil, fmt.Errorf("invalid weight of %s", call)
+		}
+		name, tg.weight = name[:star], weight
+	}
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 {
+		return nil, fmt.Errorf("invalid request %s, want Service.method", call)
+	}
+	if tg.srv = cmd.schema.Service(name[:dot]); tg.srv == nil {
+		return nil, fmt.Errorf("service %s not in idl", name[:dot])
+	}
+	if tg.m = tg.srv.MethodByName(name[dot+1:]); tg.m == nil {
+		return nil, fmt.Errorf("method %s not in service %s", name[dot+1:], tg.srv.Name)
+	}
+	md, err := cmd.msgs.Args(tg.srv, tg.m)
+	if err != nil {
+		return nil, err
+	}
+	if tg.args, err = idlclient.BuildArgs(md, input); err != nil {
+		return nil, err
+	}
+	return tg, nil
+}
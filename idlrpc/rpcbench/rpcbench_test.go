@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/example"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/example/pbdata"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/idlclient"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const callerIdl = `{"services": [{"name": "TestCaller", "uuid": "8590810067174448481", "methods": [
+	{"name": "SetInfo", "index": 1, "arguments": [{"IdlType": "string", "index": 1, "type": "string", "decl_name": "info"}], "retType": {"IdlType": "void", "type": "void"}},
+	{"name": "GetInfo", "index": 2, "arguments": [], "retType": {"IdlType": "string", "type": "string"}}
+]}]}`
+
+func TestRpcBench(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "rpcbench")
+	defer os.RemoveAll(dir)
+	idlPath, descPath, csvPath := filepath.Join(dir, "caller.idl.go.json"), filepath.Join(dir, "caller.desc"), filepath.Join(dir, "out.csv")
+	_ = ioutil.WriteFile(idlPath, []byte(callerIdl), 0644)
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(pbdata.File_box_example_service_proto)}}
+	data, _ := proto.Marshal(set)
+	_ = ioutil.WriteFile(descPath, data, 0644)
+
+	// SetInfo always fails with exception
+	service := example.NewMockTestCaller()
+	service.SetInfoFunc = func(context.Context, string) error {
+		return errors.NewException(1001, "Locked", "info locked")
+	}
+	service.GetInfoFunc = func(context.Context) (string, error) {
+		return "info", nil
+	}
+	rpc := idlrpc.CreateRpcFramework()
+	_ = rpc.Init()
+	_ = (&example.TestCallerSDK{}).Register(rpc)
+	_ = rpc.Start()
+	defer rpc.ShutDown()
+	_ = rpc.RegisterService(service)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen error %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for id := uint32(1); ; id++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go idlclient.NewTcpTransport(conn, id).Serve(rpc)
+		}
+	}()
+
+	out := &bytes.Buffer{}
+	err = run([]string{"-idl", idlPath, "-proto", descPath, "-addr", ln.Addr().String(), "-c", "4", "-conns", "2", "-n", "200", "-csv", csvPath,
+		"-call", "TestCaller.GetInfo*3", "-call", `TestCaller.SetInfo=["x"]`}, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv, _ := ioutil.ReadFile(csvPath)
+	if !strings.Contains(out.String(), "200 calls") || !strings.Contains(string(csv), "1001 Locked:") {
+		t.Fatalf("summary\n%s\ncsv\n%s", out.String(), csv)
+	}
+
+	if err = run([]string{"-idl", idlPath, "-call", "TestCaller.GetInfo*0"}, out); err == nil {
+		t.Fatal("invalid weight accepted")
+	}
+}
@@ -0,0 +1,256 @@
+// rpccall call method of running service from command line, like curl.
+//
+//	rpccall list     -idl game.idl.go.json
+//	rpccall describe -idl game.idl.go.json [-proto game.desc] Service[.method]
+//	rpccall call     -idl game.idl.go.json -proto game.desc -addr host:port [-timeout ms] [-retry n] Service.method '{"arg1": 1}'
+//
+// descriptors are written by protoc --include_imports --descriptor_set_out, arguments are
+// json object of args message, or json array of arguments in order
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/idlclient"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/idljson"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/logger"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	defaultTimeout = 5000 // millisecond, method without idl timeout
+	usage          = "usage: rpccall list|describe|call [flags] [Service[.method]] [arguments]"
+)
+
+type (
+	idlFlags []string
+
+	// command parsed flags of sub command
+	command struct {
+		flags   *flag.FlagSet
+		idls    idlFlags
+		protos  idlFlags
+		addr    string
+		timeout uint
+		retry   int
+		verbose bool
+		schema  *idljson.Schema
+		msgs    idlclient.Messages
+	}
+)
+
+func (f *idlFlags) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *idlFlags) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	cmd := &command{flags: flag.NewFlagSet(args[0], flag.ContinueOnError)}
+	cmd.flags.Var(&cmd.idls, "idl", "idl json used by idl2go, can be repeated")
+	cmd.flags.Var(&cmd.protos, "proto", "protobuf descriptor set of idl, can be repeated")
+	cmd.flags.StringVar(&cmd.addr, "addr", "127.0.0.1:9090", "tcp address of service")
+	cmd.flags.UintVar(&cmd.timeout, "timeout", 0, "call timeout in millisecond, default idl timeout or 5000")
+	cmd.flags.IntVar(&cmd.retry, "retry", -1, "retry time after timeout, default idl retry")
+	cmd.flags.BoolVar(&cmd.verbose, "v", false, "print framework log")
+	if err := cmd.flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if err := cmd.load(); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		return cmd.list(out)
+	case "describe":
+		return cmd.describe(out)
+	case "call":
+		return cmd.call(out)
+	}
+	return fmt.Errorf("unknown command %s\n%s", args[0], usage)
+}
+
+func (cmd *command) load() error {
+	if len(cmd.idls) == 0 {
+		return fmt.Errorf("no idl json, set -idl")
+	}
+	cmd.schema = idljson.NewSchema()
+	for _, path := range cmd.idls {
+		if err := cmd.schema.Load(path); err != nil {
+			return err
+		}
+	}
+	cmd.msgs = make(idlclient.Messages)
+	for _, path := range cmd.protos {
+		if err := cmd.msgs.Load(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// target service and method of Service.method, method is nil if not given
+func (cmd *command) target() (*idljson.Service, *idljson.Method, error) {
+	if cmd.flags.NArg() == 0 {
+		return nil, nil, fmt.Errorf("service not given")
+	}
+	name := cmd.flags.Arg(0)
+	var method string
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name, method = name[:dot], name[dot+1:]
+	}
+	srv := cmd.schema.Service(name)
+	if srv == nil {
+		return nil, nil, fmt.Errorf("service %s not in idl", name)
+	}
+	if method == "" {
+		return srv, nil, nil
+	}
+	m := srv.MethodByName(method)
+	if m == nil {
+		return nil, nil, fmt.Errorf("method %s not in service %s", method, srv.Name)
+	}
+	return srv, m, nil
+}
+
+func (cmd *command) services() []*idljson.Service {
+	services := make([]*idljson.Service, 0, len(cmd.schema.Services))
+	for _, srv := range cmd.schema.Services {
+		services = append(services, srv)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services
+}
+
+// list services and their methods
+func (cmd *command) list(out io.Writer) error {
+	for _, srv := range cmd.services() {
+		fmt.Fprintf(out, "%s %d\n", srv.Name, srv.ID)
+		for _, m := range srv.Methods {
+			fmt.Fprintf(out, "  %d %s\n", m.Index, m.Name)
+		}
+	}
+	return nil
+}
+
+// describe service or method, with fields of proto messages if descriptors are loaded
+func (cmd *command) describe(out io.Writer) error {
+	srv, m, err := cmd.target()
+	if err != nil {
+		return err
+	}
+	methods := srv.Methods
+	if m != nil {
+		methods = []*idljson.Method{m}
+	} else {
+		fmt.Fprintf(out, "service %s uuid %d\n", srv.Name, srv.ID)
+	}
+	for _, m := range methods {
+		fmt.Fprintf(out, "%d: %s", m.Index, m.Signature())
+		if m.TimeOut > 0 {
+			fmt.Fprintf(out, " timeout %dms", m.TimeOut)
+		}
+		if m.Retry > 0 {
+			fmt.Fprintf(out, " retry %d", m.Retry)
+		}
+		fmt.Fprintln(out)
+		for _, find := range []func(*idljson.Service, *idljson.Method) (protoreflect.MessageDescriptor, error){cmd.msgs.Args, cmd.msgs.Ret} {
+			md, err := find(srv, m)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(out, "  message %s {\n", md.FullName())
+			fields := md.Fields()
+			for i := 0; i < fields.Len(); i++ {
+				fd := fields.Get(i)
+				fmt.Fprintf(out, "    %s %s = %d;\n", idlclient.FieldType(fd), fd.Name(), fd.Number())
+			}
+			fmt.Fprintln(out, "  }")
+		}
+	}
+	return nil
+}
+
+// call method by connecting to service, print return message or error
+func (cmd *command) call(out io.Writer) error {
+	srv, m, err := cmd.target()
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return fmt.Errorf("method not given")
+	}
+	argsDesc, err := cmd.msgs.Args(srv, m)
+	if err != nil {
+		return err
+	}
+	args, err := idlclient.BuildArgs(argsDesc, cmd.flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	timeout, retry := uint32(cmd.timeout), int32(cmd.retry)
+	if timeout == 0 {
+		timeout = m.TimeOut
+	}
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	if retry < 0 {
+		retry = int32(m.Retry)
+	}
+
+	opts := []idlrpc.Option{idlrpc.WithCallTimeout(timeout, retry)}
+	if cmd.verbose {
+		opts = append(opts, idlrpc.WithLogger(&logger.DefaultLogger{}))
+	}
+	client, err := idlclient.Dial(cmd.addr, time.Duration(timeout)*time.Millisecond, []*idljson.Service{srv}, opts...)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	resp, err := client.Call(srv, m, timeout, retry, args)
+	if err != nil {
+		var ex errors.IException
+		if errors.As(err, &ex) {
+			return fmt.Errorf("call %s.%s error code %d: %v", srv.Name, m.Name, ex.ExceptionCode(), err)
+		}
+		return fmt.Errorf("call %s.%s error %v", srv.Name, m.Name, err)
+	}
+	if m.IsOneway {
+		return nil
+	}
+	retDesc, err := cmd.msgs.Ret(srv, m)
+	if err != nil {
+		return err
+	}
+	ret, err := idlclient.FormatRet(retDesc, resp)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, ret)
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/example"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/example/pbdata"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/idlclient"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const callerIdl = `{"services": [{"name": "TestCaller", "uuid": "8590810067174448481", "methods": [
+	{"name": "SetInfo", "index": 1, "arguments": [{"IdlType": "string", "index": 1, "type": "string", "decl_name": "info"}], "retType": {"IdlType": "void", "type": "void"}},
+	{"name": "GetInfo", "index": 2, "arguments": [], "retType": {"IdlType": "string", "type": "string"}, "timeout": 2000}
+]}]}`
+
+// serveTcp host TestCaller on tcp listener
+func serveTcp(t *testing.T, service idlrpc.IService) (string, func()) {
+	rpc := idlrpc.CreateRpcFramework()
+	_ = rpc.Init()
+	_ = (&example.TestCallerSDK{}).Register(rpc)
+	_ = rpc.Start()
+	if err := rpc.RegisterService(service); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen error %v", err)
+	}
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Millisecond):
+				_ = rpc.Tick()
+			}
+		}
+	}()
+	go func() {
+		for id := uint32(1); ; id++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go idlclient.NewTcpTransport(conn, id).Serve(rpc)
+		}
+	}()
+	return ln.Addr().String(), func() {
+		close(stop)
+		_ = ln.Close()
+		_ = rpc.ShutDown()
+	}
+}
+
+// compact remove spaces, protojson output is not stable
+func compact(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+func TestRpcCall(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "rpccall")
+	defer os.RemoveAll(dir)
+	idlPath, descPath := filepath.Join(dir, "caller.idl.go.json"), filepath.Join(dir, "caller.desc")
+	_ = ioutil.WriteFile(idlPath, []byte(callerIdl), 0644)
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(pbdata.File_box_example_service_proto)}}
+	data, _ := proto.Marshal(set)
+	_ = ioutil.WriteFile(descPath, data, 0644)
+
+	service := example.NewMockTestCaller()
+	var info string
+	service.SetInfoFunc = func(_ context.Context, v string) error {
+		info = v
+		return nil
+	}
+	service.GetInfoFunc = func(context.Context) (string, error) {
+		return info, nil
+	}
+	addr, stop := serveTcp(t, service)
+	defer stop()
+
+	out := &bytes.Buffer{}
+	call := func(args ...string) error {
+		out.Reset()
+		return run(append([]string{args[0], "-idl", idlPath, "-proto", descPath, "-addr", addr}, args[1:]...), out)
+	}
+
+	if err := call("list"); err != nil || out.String() != "TestCaller 8590810067174448481\n  1 SetInfo\n  2 GetInfo\n" {
+		t.Fatalf("list %q error %v", out.String(), err)
+	}
+	if err := call("describe", "testcaller.getinfo"); err != nil ||
+		!strings.Contains(out.String(), "2: string GetInfo() timeout 2000ms") ||
+		!strings.Contains(out.String(), "string ret1 = 1;") {
+		t.Fatalf("describe %q error %v", out.String(), err)
+	}
+
+	// unpopulated return field still printed
+	if err := call("call", "TestCaller.GetInfo"); err != nil || !strings.Contains(compact(out.String()), `"ret1":""`) {
+		t.Fatalf("get info %q error %v", out.String(), err)
+	}
+	if err := call("call", "TestCaller.SetInfo", `["hello"]`); err != nil {
+		t.Fatal(err)
+	}
+	if err := call("call", "TestCaller.GetInfo", "{}"); err != nil || !strings.Contains(compact(out.String()), `"ret1":"hello"`) {
+		t.Fatalf("get info %q error %v", out.String(), err)
+	}
+
+	if err := call("call", "TestCaller.Unknown"); err == nil {
+		t.Fatal("unknown method called")
+	}
+	if err := call("call", "TestCaller.SetInfo", `{"arg9": 1}`); err == nil {
+		t.Fatal("invalid arguments accepted")
+	}
+}
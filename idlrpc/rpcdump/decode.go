@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/idljson"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+//@title protobuf body 解码
+//@desc 按 idl 类型把 protobuf 二进制解成 json 对象, 字段编号规则和 golang_pb_layer.py 一致:
+// args 参数从 1 开始顺序编号, 之后是 trace_info 和 ctx; ret 固定 ret1 = 1, trace_info = 2, exec_info = 3, ctx = 4
+
+// schema idl types used to decode body
+type schema struct {
+	*idljson.Schema
+}
+
+func newSchema() *schema {
+	return &schema{idljson.NewSchema()}
+}
+
+// namedField field of message known by idl
+type namedField struct {
+	name string
+	typ  *idljson.Type
+	ctx  bool // Context message of framework keys
+}
+
+// decodeArgs render method args message
+func (s *schema) decodeArgs(m *idljson.Method, body []byte) (map[string]interface{}, error) {
+	fields := make(map[protowire.Number]namedField)
+	n := protowire.Number(1)
+	for _, arg := range m.Arguments {
+		if arg.IdlType == "void" {
+			continue
+		}
+		name := arg.DeclName
+		if name == "" {
+			name = fmt.Sprintf("arg%d", n)
+		}
+		fields[n] = namedField{name: name, typ: arg}
+		n++
+	}
+	fields[n] = namedField{name: "trace_info"}
+	fields[n+1] = namedField{name: "ctx", ctx: true}
+	return s.decodeMessage(fields, body)
+}
+
+// decodeRet render method return message
+func (s *schema) decodeRet(m *idljson.Method, body []byte) (map[string]interface{}, error) {
+	fields := map[protowire.Number]namedField{
+		2: {name: "trace_info"},
+		3: {name: "exec_info"},
+		4: {name: "ctx", ctx: true},
+	}
+	if m.RetType != nil && m.RetType.IdlType != "void" {
+		fields[1] = namedField{name: "ret1", typ: m.RetType}
+	}
+	return s.decodeMessage(fields, body)
+}
+
+// decodeMessage fields without idl type are rendered by wire type, unknown fields are named by number
+func (s *schema) decodeMessage(fields map[protowire.Number]namedField, body []byte) (map[string]interface{}, error) {
+	obj := make(map[string]interface{})
+	for len(body) > 0 {
+		num, wtyp, n := protowire.ConsumeTag(body)
+		if n < 0 {
+			return obj, protowire.ParseError(n)
+		}
+		body = body[n:]
+		n = protowire.ConsumeFieldValue(num, wtyp, body)
+		if n < 0 {
+			return obj, protowire.ParseError(n)
+		}
+		raw := body[:n]
+		body = body[n:]
+
+		field, ok := fields[num]
+		if !ok {
+			field.name = fmt.Sprintf("%d", num)
+		}
+		if field.ctx && wtyp == protowire.BytesType {
+			if info, ok := decodeCtx(raw); ok {
+				obj[field.name] = info
+				continue
+			}
+		}
+		if field.typ == nil {
+			obj[field.name] = decodeRaw(wtyp, raw)
+			continue
+		}
+		if err := s.decodeField(obj, field, wtyp, raw); err != nil {
+			return obj, fmt.Errorf("field %s: %v", field.name, err)
+		}
+	}
+	return obj, nil
+}
+
+// decodeField set value of field to obj, repeated values are appended
+func (s *schema) decodeField(obj map[string]interface{}, field namedField, wtyp protowire.Type, raw []byte) error {
+	switch field.typ.IdlType {
+	case "seq", "set":
+		elem := field.typ.Key
+		if elem == nil {
+			return fmt.Errorf("element type missing")
+		}
+		list, _ := obj[field.name].([]interface{})
+		if wtyp == protowire.BytesType && isScalar(elem) {
+			// packed scalars
+			packed, n := protowire.ConsumeBytes(raw)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			for len(packed) > 0 {
+				v, n, err := s.decodeScalar(elem, scalarWireType(elem), packed)
+				if err != nil {
+					return err
+				}
+				list = append(list, v)
+				packed = packed[n:]
+			}
+		} else {
+			v, _, err := s.decodeValue(elem, wtyp, raw)
+			if err != nil {
+				return err
+			}
+			list = append(list, v)
+		}
+		obj[field.name] = list
+	case "dict":
+		if field.typ.Key == nil || field.typ.Value == nil {
+			return fmt.Errorf("key or value type missing")
+		}
+		entry, n := protowire.ConsumeBytes(raw)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		kv, err := s.decodeMessage(map[protowire.Number]namedField{
+			1: {name: "key", typ: field.typ.Key},
+			2: {name: "value", typ: field.typ.Value},
+		}, entry)
+		if err != nil {
+			return err
+		}
+		dict, _ := obj[field.name].(map[string]interface{})
+		if dict == nil {
+			dict = make(map[string]interface{})
+		}
+		dict[fmt.Sprint(kv["key"])] = kv["value"]
+		obj[field.name] = dict
+	default:
+		v, _, err := s.decodeValue(field.typ, wtyp, raw)
+		if err != nil {
+			return err
+		}
+		obj[field.name] = v
+	}
+	return nil
+}
+
+// decodeValue decode single value of type
+func (s *schema) decodeValue(typ *idljson.Type, wtyp protowire.Type, raw []byte) (interface{}, int, error) {
+	if typ.IsStruct {
+		msg, n := protowire.ConsumeBytes(raw)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		st, ok := s.Structs[typ.GoType]
+		if !ok {
+			return decodeRaw(wtyp, raw), n, nil
+		}
+		fields := make(map[protowire.Number]namedField, len(st.Fields))
+		for i, f := range st.Fields {
+			fields[protowire.Number(i+1)] = namedField{name: f.Name, typ: f}
+		}
+		v, err := s.decodeMessage(fields, msg)
+		return v, n, err
+	}
+	return s.decodeScalar(typ, wtyp, raw)
+}
+
+func (s *schema) decodeScalar(typ *idljson.Type, wtyp protowire.Type, raw []byte) (interface{}, int, error) {
+	if typ.IsEnum {
+		v, n := protowire.ConsumeVarint(raw)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		if name, ok := s.Enums[typ.GoType][int32(v)]; ok {
+			return name, n, nil
+		}
+		return int32(v), n, nil
+	}
+	if wtyp != scalarWireType(typ) {
+		return nil, 0, fmt.Errorf("%s value has wire type %d", typ.IdlType, wtyp)
+	}
+	switch typ.IdlType {
+	case "float":
+		v, n := protowire.ConsumeFixed32(raw)
+		return math.Float32frombits(v), n, checkParse(n)
+	case "double":
+		v, n := protowire.ConsumeFixed64(raw)
+		return math.Float64frombits(v), n, checkParse(n)
+	case "string":
+		v, n := protowire.ConsumeBytes(raw)
+		return string(v), n, checkParse(n)
+	}
+	v, n := protowire.ConsumeVarint(raw)
+	if err := checkParse(n); err != nil {
+		return nil, 0, err
+	}
+	switch typ.IdlType {
+	case "bool":
+		return v != 0, n, nil
+	case "i8", "i16", "i32":
+		return int32(v), n, nil
+	case "i64":
+		return int64(v), n, nil
+	case "ui8", "ui16", "ui32":
+		return uint32(v), n, nil
+	}
+	return v, n, nil
+}
+
+func checkParse(n int) error {
+	if n < 0 {
+		return protowire.ParseError(n)
+	}
+	return nil
+}
+
+func isScalar(typ *idljson.Type) bool {
+	return !typ.IsStruct && typ.IdlType != "string"
+}
+
+func scalarWireType(typ *idljson.Type) protowire.Type {
+	switch {
+	case typ.IsEnum:
+		return protowire.VarintType
+	case typ.IdlType == "float":
+		return protowire.Fixed32Type
+	case typ.IdlType == "double":
+		return protowire.Fixed64Type
+	case typ.IdlType == "string" || typ.IsStruct:
+		return protowire.BytesType
+	}
+	return protowire.VarintType
+}
+
+// decodeRaw render value without type, bytes are tried as printable string, then message, then base64
+func decodeRaw(wtyp protowire.Type, raw []byte) interface{} {
+	switch wtyp {
+	case protowire.VarintType:
+		v, _ := protowire.ConsumeVarint(raw)
+		return v
+	case protowire.Fixed32Type:
+		v, _ := protowire.ConsumeFixed32(raw)
+		return v
+	case protowire.Fixed64Type:
+		v, _ := protowire.ConsumeFixed64(raw)
+		return v
+	case protowire.BytesType:
+		v, _ := protowire.ConsumeBytes(raw)
+		if isPrintable(v) {
+			return string(v)
+		}
+		if msg, err := (&schema{}).decodeMessage(nil, v); err == nil {
+			return msg
+		}
+		return base64.StdEncoding.EncodeToString(v)
+	}
+	return nil
+}
+
+// decodeCtx render Context {repeated {string key = 1; string value = 2;} info = 1;} as key values
+func decodeCtx(raw []byte) (map[string]string, bool) {
+	msg, n := protowire.ConsumeBytes(raw)
+	if n < 0 {
+		return nil, false
+	}
+	info := make(map[string]string)
+	for len(msg) > 0 {
+		num, wtyp, n := protowire.ConsumeTag(msg)
+		if n < 0 || num != 1 || wtyp != protowire.BytesType {
+			return nil, false
+		}
+		msg = msg[n:]
+		kv, n := protowire.ConsumeBytes(msg)
+		if n < 0 {
+			return nil, false
+		}
+		msg = msg[n:]
+		var key, value string
+		for len(kv) > 0 {
+			num, wtyp, n := protowire.ConsumeTag(kv)
+			if n < 0 || wtyp != protowire.BytesType {
+				return nil, false
+			}
+			kv = kv[n:]
+			str, n := protowire.ConsumeString(kv)
+			if n < 0 {
+				return nil, false
+			}
+			kv = kv[n:]
+			switch num {
+			case 1:
+				key = str
+			case 2:
+				value = str
+			}
+		}
+		info[key] = value
+	}
+	return info, true
+}
+
+func isPrintable(v []byte) bool {
+	if !utf8.Valid(v) {
+		return false
+	}
+	for _, r := range string(v) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/capture"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const playerIdl = `{
+	"services": [{"name": "Player", "uuid": "1001", "methods": [
+		{"name": "login", "index": 1, "arguments": [
+			{"IdlType": "string", "index": 1, "type": "string", "decl_name": "account"},
+			{"IdlType": "seq", "index": 2, "type": "[]", "decl_name": "items", "key": {"IdlType": "i32", "type": "int32"}},
+			{"IdlType": "dict", "index": 3, "type": "map", "decl_name": "infos", "key": {"IdlType": "string", "type": "string"}, "value": {"IdlType": "Info", "isStruct": true, "type": "Info"}}
+		], "retType": {"IdlType": "Info", "isStruct": true, "type": "Info"}}
+	]}],
+	"structs": [{"name": "Info", "fields": [
+		{"IdlType": "string", "name": "name", "type": "string"},
+		{"IdlType": "Level", "name": "level", "isEnum": true, "type": "Level"}
+	]}],
+	"enums": [{"name": "Level", "fields": [{"name": "high", "value": 2}]}]
+}`
+
+func info(name string, level uint64) []byte {
+	b := protowire.AppendTag(nil, 1, protowire.BytesType)
+	b = protowire.AppendString(b, name)
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	return protowire.AppendVarint(b, level)
+}
+
+func TestDump(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "rpcdump")
+	defer os.RemoveAll(dir)
+	idlPath, capPath := filepath.Join(dir, "game.json"), filepath.Join(dir, "rpc.cap")
+	_ = ioutil.WriteFile(idlPath, []byte(playerIdl), 0644)
+
+	// login("bob", [1, 2], {"pet": {"cat", high}}) with trace key in ctx
+	args := protowire.AppendTag(nil, 1, protowire.BytesType)
+	args = protowire.AppendString(args, "bob")
+	args = protowire.AppendTag(args, 2, protowire.BytesType)
+	args = protowire.AppendBytes(args, []byte{1, 2})
+	entry := protowire.AppendTag(nil, 1, protowire.BytesType)
+	entry = protowire.AppendString(entry, "pet")
+	entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+	entry = protowire.AppendBytes(entry, info("cat", 2))
+	args = protowire.AppendTag(args, 3, protowire.BytesType)
+	args = protowire.AppendBytes(args, entry)
+	kv := protowire.AppendTag(nil, 1, protowire.BytesType)
+	kv = protowire.AppendString(kv, protocol.CtxTraceKey)
+	kv = protowire.AppendTag(kv, 2, protowire.BytesType)
+	kv = protowire.AppendString(kv, "t1")
+	ctx := protowire.AppendTag(nil, 1, protowire.BytesType)
+	ctx = protowire.AppendBytes(ctx, kv)
+	args = protowire.AppendTag(args, 5, protowire.BytesType)
+	args = protowire.AppendBytes(args, ctx)
+	req, _ := protocol.PackReqMsg(&protocol.RequestPackage{
+		Header: &protocol.RpcCallHeader{
+			RpcMsgHeader: protocol.RpcMsgHeader{Length: uint32(protocol.CallHeadSize + len(args)), Type: protocol.RequestMsg},
+			ServiceUUID:  1001,
+			CallID:       7,
+			MethodID:     1,
+		},
+		Buffer: args,
+	})
+	ret := protowire.AppendTag(nil, 1, protowire.BytesType)
+	ret = protowire.AppendBytes(ret, info("bob", 3))
+	resp, _ := protocol.PackRespMsg(&protocol.ResponsePackage{
+		Header: &protocol.RpcCallRetHeader{
+			RpcMsgHeader: protocol.RpcMsgHeader{Length: uint32(protocol.RespHeadSize + len(ret)), Type: protocol.ResponseMsg},
+			CallID:       7,
+			ErrorCode:    protocol.IDL_SUCCESS,
+		},
+		Buffer: ret,
+	})
+
+	w, _ := capture.Create(capPath)
+	now := time.Now()
+	_ = w.WritePacket(&capture.Packet{Time: now, Dir: capture.Out, Conn: 1, Data: req})
+	_ = w.WritePacket(&capture.Packet{Time: now, Dir: capture.In, Conn: 1, Data: resp})
+	_ = w.WritePacket(&capture.Packet{Time: now, Dir: capture.In, Conn: 1, Data: resp[:protocol.RespHeadSize]})
+	_ = w.Close()
+
+	d := newDecoder(newSchema(), false)
+	if err := d.schema.Load(idlPath); err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	if err := d.dumpFile(capPath, out, true); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected output %s", out.String())
+	}
+	expects := []string{
+		`"dir":"OUT","conn":1,"type":"Request","length":` +
+			`[0-9]+,"service":"Player","method":"login","call_id":7,"body":{"account":"bob","ctx":{"rpc_trace":"t1"},"infos":{"pet":{"level":"high","name":"cat"}},"items":\[1,2\]}}`,
+		`"type":"Response",.*"service":"Player","method":"login","call_id":7,"error_code":"SUCCESS","body":{"ret1":{"level":3,"name":"bob"}}}`,
+		`"type":"Response","length":[0-9]+,"decode_error":"header length`,
+	}
+	for i, line := range lines {
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("line %d is not json: %s", i, line)
+		}
+		if ok, _ := regexp.MatchString(expects[i], line); !ok {
+			t.Errorf("line %d\n%s\nexpect %s", i, line, expects[i])
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// rpcdump decode rpc capture files written by pkg/capture.
+//
+//	rpcdump -idl game.json [-idl other.json] [-json] [-hex] capture.cap ...
+//
+// prints message type, service, method, call id, error code and protobuf body as json of every frame
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/idljson"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/capture"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+)
+
+type (
+	// record decoded frame
+	record struct {
+		Time        string      `json:"time"`
+		Dir         string      `json:"dir"`
+		Conn        uint32      `json:"conn"`
+		Type        string      `json:"type"`
+		Length      int         `json:"length"`
+		Service     string      `json:"service,omitempty"`
+		Method      string      `json:"method,omitempty"`
+		CallID      uint32      `json:"call_id,omitempty"`
+		ErrorCode   string      `json:"error_code,omitempty"`
+		GlobalIndex uint32      `json:"global_index,omitempty"`
+		Body        interface{} `json:"body,omitempty"`
+		Hex         string      `json:"hex,omitempty"`
+		Error       string      `json:"decode_error,omitempty"`
+	}
+
+	// callKey call of connection, response is matched to request by it
+	callKey struct {
+		conn   uint32
+		callId uint32
+	}
+
+	pendingCall struct {
+		service *idljson.Service
+		method  *idljson.Method
+		uuid    uint64
+		methId  uint32
+	}
+
+	decoder struct {
+		schema  *schema
+		wire    protocol.Protocol
+		pending map[callKey]pendingCall
+		withHex bool
+	}
+
+	idlFlags []string
+)
+
+var typeNames = map[uint32]string{
+	protocol.RequestMsg:       "Request",
+	protocol.ResponseMsg:      "Response",
+	protocol.ProxyRequestMsg:  "ProxyRequest",
+	protocol.ProxyResponseMsg: "ProxyResponse",
+	protocol.NotRpcMsg:        "NotRpc",
+	protocol.RpcEventSub:      "EventSub",
+	protocol.RpcEventPub:      "EventPub",
+	protocol.RpcEventCancel:   "EventCancel",
+	protocol.RpcCallAlias:     "CallAlias",
+	protocol.RpcPing:          "Ping",
+	protocol.RpcPong:          "Pong",
+	protocol.RpcTimeout:       "Timeout",
+	protocol.RpcLoggedOut:     "LoggedOut",
+}
+
+var errorNames = map[uint32]string{
+	protocol.IDL_SUCCESS:           "SUCCESS",
+	protocol.IDL_SERVICE_NOT_FOUND: "SERVICE_NOT_FOUND",
+	protocol.IDL_SERVICE_ERROR:     "SERVICE_ERROR",
+	protocol.IDL_RPC_TIME_OUT:      "RPC_TIME_OUT",
+	protocol.IDL_RPC_LIMIT:         "RPC_LIMIT",
+}
+
+func (f *idlFlags) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *idlFlags) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+func main() {
+	var idls idlFlags
+	flag.Var(&idls, "idl", "idl json used by idl2go, can be repeated")
+	asJson := flag.Bool("json", false, "print one json object per frame")
+	withHex := flag.Bool("hex", false, "print hex of whole frame")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: rpcdump [-idl file.json] [-json] [-hex] capture ...")
+		os.Exit(2)
+	}
+
+	d := newDecoder(newSchema(), *withHex)
+	for _, path := range idls {
+		if err := d.schema.Load(path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	for _, path := range flag.Args() {
+		if err := d.dumpFile(path, os.Stdout, *asJson); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func newDecoder(s *schema, withHex bool) *decoder {
+	return &decoder{
+		schema:  s,
+		wire:    protocol.Default(),
+		pending: make(map[callKey]pendingCall),
+		withHex: withHex,
+	}
+}
+
+func (d *decoder) dumpFile(path string, out io.Writer, asJson bool) error {
+	r, err := capture.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for {
+		p, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rec := d.decode(p)
+		if asJson {
+			data, _ := json.Marshal(rec)
+			fmt.Fprintln(out, string(data))
+		} else {
+			fmt.Fprintln(out, rec.String())
+		}
+	}
+}
+
+// decode decode header and body of packet, requests are remembered to decode their responses
+func (d *decoder) decode(p *capture.Packet) *record {
+	rec := &record{
+		Time:   p.Time.Format("2006-01-02 15:04:05.000000"),
+		Dir:    p.Dir.String(),
+		Conn:   p.Conn,
+		Length: len(p.Data),
+	}
+	if d.withHex {
+		rec.Hex = hex.EncodeToString(p.Data)
+	}
+	header := &protocol.RpcMsgHeader{}
+	if !d.wire.ReadHeader(p.Data, header) {
+		rec.Type, rec.Error = "Invalid", "short frame"
+		return rec
+	}
+	rec.Type = typeNames[header.Type]
+	if rec.Type == "" {
+		rec.Type = fmt.Sprintf("Unknown(%d)", header.Type)
+	}
+	if int(header.Length) != len(p.Data) {
+		rec.Error = fmt.Sprintf("header length %d, frame length %d", header.Length, len(p.Data))
+		return rec
+	}
+
+	var ok bool
+	switch header.Type {
+	case protocol.RequestMsg:
+		h := &protocol.RpcCallHeader{}
+		if ok = d.wire.ParseReqMsg(p.Data, h); ok {
+			d.request(rec, p.Conn, h.ServiceUUID, h.MethodID, h.CallID, p.Data[protocol.CallHeadSize:])
+		}
+	case protocol.ProxyRequestMsg:
+		h := &protocol.RpcProxyCallHeader{}
+		if ok = d.wire.ParseProxyReqMsg(p.Data, h); ok {
+			rec.GlobalIndex = uint32(h.GlobalIndex)
+			d.request(rec, p.Conn, h.ServiceUUID, h.MethodID, h.CallID, p.Data[protocol.ProxyCallHeadSize:])
+		}
+	case protocol.ResponseMsg:
+		h := &protocol.RpcCallRetHeader{}
+		if ok = d.wire.ParseRespMsg(p.Data, h); ok {
+			d.response(rec, p.Conn, h.CallID, h.ErrorCode, p.Data[protocol.RespHeadSize:])
+		}
+	case protocol.ProxyResponseMsg:
+		h := &protocol.RpcProxyCallRetHeader{}
+		if ok = d.wire.ParseProxyRespMsg(p.Data, h); ok {
+			rec.GlobalIndex = uint32(h.GlobalIndex)
+			d.response(rec, p.Conn, h.CallID, h.ErrorCode, p.Data[protocol.ProxyRetHeadSize:])
+		}
+	case protocol.RpcTimeout:
+		h := &protocol.RpcTimeoutHeader{}
+		if ok = d.wire.ParsePlatoHeader(p.Data, h); ok {
+			rec.GlobalIndex = uint32(h.GlobalIndexId)
+		}
+	case protocol.RpcLoggedOut:
+		h := &protocol.RpcLoggedOutHeader{}
+		if ok = d.wire.ParsePlatoHeader(p.Data, h); ok {
+			rec.GlobalIndex = uint32(h.GlobalIndexId)
+		}
+	default:
+		ok = true
+	}
+	if !ok {
+		rec.Error = "broken header"
+	}
+	return rec
+}
+
+func (d *decoder) request(rec *record, conn uint32, uuid uint64, methId, callId uint32, body []byte) {
+	rec.CallID = callId
+	srv, m := d.schema.Method(uuid, methId)
+	d.pending[callKey{conn, callId}] = pendingCall{service: srv, method: m, uuid: uuid, methId: methId}
+	rec.Service, rec.Method = names(srv, m, uuid, methId)
+	if m == nil {
+		return
+	}
+	var err error
+	if rec.Body, err = d.schema.decodeArgs(m, body); err != nil {
+		rec.Error = err.Error()
+	}
+}
+
+func (d *decoder) response(rec *record, conn uint32, callId, errCode uint32, body []byte) {
+	rec.CallID = callId
+	rec.ErrorCode = errorNames[errCode]
+	if rec.ErrorCode == "" {
+		rec.ErrorCode = fmt.Sprintf("%d", errCode)
+	}
+	call, ok := d.pending[callKey{conn, callId}]
+	if !ok {
+		return
+	}
+	delete(d.pending, callKey{conn, callId})
+	rec.Service, rec.Method = names(call.service, call.method, call.uuid, call.methId)
+	if call.method == nil || errCode != protocol.IDL_SUCCESS {
+		return
+	}
+	var err error
+	if rec.Body, err = d.schema.decodeRet(call.method, body); err != nil {
+		rec.Error = err.Error()
+	}
+}
+
+// names service name and method signature, uuid and method id if not in idl
+func names(srv *idljson.Service, m *idljson.Method, uuid uint64, methId uint32) (string, string) {
+	service, method := fmt.Sprintf("%d", uuid), fmt.Sprintf("%d", methId)
+	if srv != nil {
+		service = srv.Name
+	}
+	if m != nil {
+		method = m.Name
+	}
+	return service, method
+}
+
+func (rec *record) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-3s conn=%d %s len=%d", rec.Time, rec.Dir, rec.Conn, rec.Type, rec.Length)
+	if rec.Service != "" {
+		fmt.Fprintf(&b, " %s.%s", rec.Service, rec.Method)
+	}
+	if rec.CallID != 0 {
+		fmt.Fprintf(&b, " call=%d", rec.CallID)
+	}
+	if rec.ErrorCode != "" {
+		fmt.Fprintf(&b, " error=%s", rec.ErrorCode)
+	}
+	if rec.GlobalIndex != 0 {
+		fmt.Fprintf(&b, " global=%d", rec.GlobalIndex)
+	}
+	if rec.Body != nil {
+		data, _ := json.Marshal(rec.Body)
+		fmt.Fprintf(&b, " body=%s", data)
+	}
+	if rec.Error != "" {
+		fmt.Fprintf(&b, " decode_error=%q", rec.Error)
+	}
+	if rec.Hex != "" {
+		fmt.Fprintf(&b, " hex=%s", rec.Hex)
+	}
+	return b.String()
+}
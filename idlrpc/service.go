@@ -23,3 +23,10 @@ type IService interface {
 
 //ServiceCreator service creator
 type ServiceCreator func(interface{}) IService
+
+// ServiceStats runtime statistics of registered service
+type ServiceStats struct {
+	Expired   uint64 // calls dropped without executing, caller's deadline has passed
+	Workers   int    // worker goroutines, 0 in tick mode
+	QueueSize int    // capacity of call queue, of each queue in keyed mode
+}
@@ -1,6 +1,9 @@
 package idlrpc
 
-import "context"
+import (
+	"context"
+	"hash/fnv"
+)
 
 type ServiceStatus uint32
 
@@ -9,6 +12,20 @@ const (
 	SERVICE_UPDATING                          //stop receive message, wait for update
 )
 
+// DispatchMode how stub calls are handed to the service worker goroutines
+type DispatchMode uint32
+
+const (
+	DispatchDefault DispatchMode = iota // decided by stub, keyed if stub implements IKeyedStub
+	DispatchShared                      // all workers pull from one shared queue, no ordering
+	DispatchKeyed                       // calls with the same key always run on the same worker, in order
+	// DispatchTick no worker, calls run inline in rpc Tick, on the same goroutine as OnTick.
+	// Tick waits for handlers, so a handler must not wait for a sync proxy call whose response
+	// is fed to the rpc by the goroutine calling Tick (idlrpctest nodes do), nor for a call
+	// to a tick mode service of the same rpc. make such calls on another goroutine and answer by ReplyLater
+	DispatchTick
+)
+
 type (
 	//IStub rpc stub of service's side
 	IStub interface {
@@ -39,7 +56,20 @@ type (
 		//SetStatus set service status
 		SetStatus(status ServiceStatus)
 	}
+
+	// IKeyedStub optional stub interface, generated for services with dispatch key annotation
+	IKeyedStub interface {
+		// GetDispatchKey return ordering key of the call, false will fall back to global index
+		GetDispatchKey(methodId uint32, req []byte) (uint64, bool)
+	}
 )
 
 //StubCreator stub factory
 type StubCreator func(v interface{}) IStub
+
+// StringDispatchKey hash string argument to dispatch key, used by generated stub
+func StringDispatchKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum64()
+}
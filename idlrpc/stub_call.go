@@ -1,13 +1,30 @@
 package idlrpc
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/log"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/transport"
+	"google.golang.org/protobuf/encoding/protowire"
 )
 
 // CallUuid  stub call uuid generate by manager
 type CallUuid uint32
 
+// reply state of stub call, response is sent once at most
+const (
+	replyPending  int32 = iota // handler is running
+	replyDeferred              // handler called ReplyLater, response is sent by DeferredReply
+	replyDone                  // response has been taken by one sender
+)
+
 // StubCall remote call data
 type StubCall struct {
 	uuid      CallUuid                 //uuid, generate by manager
@@ -19,11 +36,24 @@ type StubCall struct {
 	oneWay    uint16                   // is one way method
 	buffer    []byte                   // serialize body
 	trans     transport.ITransport     //remote client socket channel
+	wire      protocol.Protocol        // wire format of trans, response is packed by it
+	recvTime  time.Time                // time request arrived
+	deadline  time.Time                // caller's deadline, zero if caller not send timeout
+	traceID   string                   // trace id sent by caller, generated if caller not send
+	meta      map[string]string        // metadata sent by caller, without framework keys
+	service   string                   // service name, set before execution
+	baseLog   log.IFieldLogger         // logger of service, set before execution
+	logOnce   sync.Once                // logFields and logger are built when call logs first time
+	logFields []log.Field              // fields attached to logs of this call
+	logger    log.IFieldLogger         // logger with fields of this call
+	frame     []byte                   // pooled request frame which buffer refers to, put back after execution
+	signature string                   // method name, set before execution
+	replied   int32                    // reply state, see replyPending
 }
 
 // newStubCall create stub call by manager
-func newStubCall(trans transport.ITransport, req *protocol.RequestPackage, uuid CallUuid) *StubCall {
-	return &StubCall{
+func newStubCall(trans transport.ITransport, wire protocol.Protocol, req *protocol.RequestPackage, uuid CallUuid) *StubCall {
+	sc := &StubCall{
 		uuid:      uuid,
 		srvUuid:   req.Header.ServiceUUID,
 		srvInstID: req.Header.ServerID,
@@ -31,11 +61,14 @@ func newStubCall(trans transport.ITransport, req *protocol.RequestPackage, uuid
 		methodID:  req.Header.MethodID,
 		buffer:    req.Buffer,
 		trans:     trans,
+		wire:      wire,
+		recvTime:  time.Now(),
 	}
+	return sc
 }
 
-func newStubCallWithProxy(trans transport.ITransport, req *protocol.ProxyRequestPackage, uuid CallUuid) *StubCall {
-	return &StubCall{
+func newStubCallWithProxy(trans transport.ITransport, wire protocol.Protocol, req *protocol.ProxyRequestPackage, uuid CallUuid) *StubCall {
+	sc := &StubCall{
 		uuid:      uuid,
 		srvUuid:   req.Header.ServiceUUID,
 		srvInstID: req.Header.ServerID,
@@ -45,7 +78,92 @@ func newStubCallWithProxy(trans transport.ITransport, req *protocol.ProxyRequest
 		oneWay:    req.Header.OneWay,
 		buffer:    req.Buffer,
 		trans:     trans,
+		wire:      wire,
+		recvTime:  time.Now(),
+	}
+	return sc
+}
+
+// initCtxInfo record trace id and caller's deadline by the remaining timeout sent with request,
+// ctxField is number of Context field in args of method
+func (sc *StubCall) initCtxInfo(ctxField protowire.Number) {
+	info := protocol.ReadCtxInfo(sc.buffer, ctxField)
+	sc.traceID = info[protocol.CtxTraceKey]
+	if sc.traceID == "" {
+		sc.traceID = newTraceID()
+	}
+	for k, v := range info {
+		if isFrameworkKey(k) {
+			continue
+		}
+		if sc.meta == nil {
+			sc.meta = make(map[string]string)
+		}
+		sc.meta[k] = v
+	}
+	timeout, ok := info[protocol.CtxTimeoutKey]
+	if !ok {
+		return
 	}
+	ms, err := strconv.ParseUint(timeout, 10, 32)
+	if err != nil || ms == 0 {
+		return
+	}
+	sc.deadline = sc.recvTime.Add(time.Duration(ms) * time.Millisecond)
+}
+
+var (
+	tracePrefix = newTracePrefix() // random per process, ids generated by different processes not collide
+	traceSeq    uint64
+)
+
+func newTracePrefix() string {
+	var id [4]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// newTraceID trace id for call without caller's trace id, process prefix and sequence number in hex
+func newTraceID() string {
+	var buf [24]byte
+	id := append(buf[:0], tracePrefix...)
+	id = strconv.AppendUint(id, atomic.AddUint64(&traceSeq, 1), 16)
+	return string(id)
+}
+
+// callLogger logger with fields of this call, fields are built only when the call logs
+func (sc *StubCall) callLogger() log.IFieldLogger {
+	sc.logOnce.Do(func() {
+		sc.logFields = []log.Field{
+			log.KV(log.KeyService, sc.service),
+			log.KV(log.KeyMethod, sc.signature),
+			log.KV(log.KeyCallID, sc.callID),
+			log.KV(log.KeyGlobalIndex, sc.globalID),
+			log.KV(log.KeyTraceID, sc.traceID),
+		}
+		sc.logger = sc.baseLog.With(sc.logFields...)
+	})
+	return sc.logger
+}
+
+// logContext logger and fields of this call for handler ctx
+func (sc *StubCall) logContext() (log.IFieldLogger, []log.Field) {
+	return sc.callLogger(), sc.logFields
+}
+
+// TraceID trace id of the call chain
+func (sc *StubCall) TraceID() string {
+	return sc.traceID
+}
+
+// Deadline caller's deadline, ok is false while caller not send timeout
+func (sc *StubCall) Deadline() (deadline time.Time, ok bool) {
+	return sc.deadline, !sc.deadline.IsZero()
+}
+
+// isExpired caller has given up waiting for this call
+func (sc *StubCall) isExpired(now time.Time) bool {
+	return !sc.deadline.IsZero() && now.After(sc.deadline)
 }
 
 // MethodID rpc method call id
@@ -75,6 +193,52 @@ func (sc *StubCall) GetBody() []byte {
 	return sc.buffer
 }
 
+// release put pooled request frame back, buffer must not be used after that
+func (sc *StubCall) release() {
+	if sc.frame != nil {
+		protocol.PutBuffer(sc.frame)
+		sc.frame = nil
+		sc.buffer = nil
+	}
+}
+
+// deferReply response will be sent by DeferredReply, false while response has been taken
+func (sc *StubCall) deferReply() bool {
+	return atomic.CompareAndSwapInt32(&sc.replied, replyPending, replyDeferred) ||
+		atomic.LoadInt32(&sc.replied) == replyDeferred
+}
+
+// takeReply take the only chance of sending response while state is from
+func (sc *StubCall) takeReply(from int32) bool {
+	return atomic.CompareAndSwapInt32(&sc.replied, from, replyDone)
+}
+
+// respond pack body and send response, exception info is appended while callErr is not nil
+func (sc *StubCall) respond(buffer []byte, callErr error) error {
+	execCode := protocol.IDL_SUCCESS
+	if callErr != nil {
+		execCode = protocol.IDL_SERVICE_ERROR
+		code, name, detail := exceptionInfo(callErr)
+		buffer = protocol.AppendExceptionInfo(buffer, code, name, detail)
+	}
+	var respData []byte
+	var pkgLen int
+	if sc.globalID == InvalidGlobalIndex {
+		resp := &protocol.ResponsePackage{Buffer: buffer}
+		protocol.BuildRespHeader(resp, 0, sc.callID, execCode)
+		respData, pkgLen = sc.wire.PackRespMsg(resp)
+	} else {
+		resp := &protocol.ProxyRespPackage{Buffer: buffer}
+		protocol.BuildProxyRespHeader(resp, 0, sc.callID, execCode, sc.globalID)
+		respData, pkgLen = sc.wire.PackProxyRespMsg(resp)
+	}
+	if respData == nil || pkgLen == 0 {
+		return errors.ErrIllegalProto
+	}
+	return sc.doRet(respData)
+}
+
+// doRet send response, msg is put back to buffer pool while transport copies it on send
 func (sc *StubCall) doRet(msg []byte) error {
 	if sc.trans.IsClose() {
 		//TODO add common error
@@ -85,5 +249,8 @@ func (sc *StubCall) doRet(msg []byte) error {
 	if err != nil {
 		return err
 	}
+	if cs, ok := sc.trans.(transport.ICopySender); ok && cs.CopyOnSend() {
+		protocol.PutBuffer(msg)
+	}
 	return nil
 }
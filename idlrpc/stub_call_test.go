@@ -0,0 +1,64 @@
+package idlrpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/log"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+)
+
+func TestNewTraceID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := newTraceID()
+		if !strings.HasPrefix(id, tracePrefix) || seen[id] {
+			t.Fatalf("trace id %q duplicated or without process prefix %q", id, tracePrefix)
+		}
+		seen[id] = true
+	}
+}
+
+func TestCallLoggerLazy(t *testing.T) {
+	var handlerFields []log.Field
+	stub := &testStub{handler: func(ctx context.Context, _ uint32, req []byte) ([]byte, error) {
+		if string(req) == "log" {
+			handlerFields = log.FieldsFromContext(ctx)
+			return nil, errors.NewRpcError(errors.CommErr, "failed")
+		}
+		return nil, nil
+	}}
+	sw := newTestWrapper(stub, serviceOptions{})
+	trans := newTestTrans()
+
+	quiet := newTestCall(trans, 1)
+	if err := sw.doCallMethod(quiet); err != nil {
+		t.Fatal(err)
+	}
+	trans.response(t)
+	if quiet.logger != nil || quiet.logFields != nil {
+		t.Fatal("call logger built without logging")
+	}
+
+	loud := newTestCall(trans, 2)
+	loud.buffer, loud.traceID = []byte("log"), "trace-2"
+	_ = sw.doCallMethod(loud)
+	trans.response(t)
+	expect := []log.Field{
+		log.KV(log.KeyService, "Test"),
+		log.KV(log.KeyMethod, "method"),
+		log.KV(log.KeyCallID, uint32(2)),
+		log.KV(log.KeyGlobalIndex, protocol.GlobalIndexType(InvalidGlobalIndex)),
+		log.KV(log.KeyTraceID, "trace-2"),
+	}
+	if loud.logger == nil || len(handlerFields) != len(expect) {
+		t.Fatalf("call fields %v", handlerFields)
+	}
+	for i := range expect {
+		if handlerFields[i] != expect[i] {
+			t.Fatalf("field %d expect %v, got %v", i, expect[i], handlerFields[i])
+		}
+	}
+}
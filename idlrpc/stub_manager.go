@@ -17,10 +17,12 @@ type ServiceCache map[SvcUuid]*stubWrapper
 
 // StubManager stub manager, manager registered service
 type StubManager struct {
-	stubCallId CallUuid     //stub call uuid
-	svcMaps    ServiceCache //service
-	rwlock     sync.RWMutex //read write lock
-	logger     log.ILogger  //logger
+	stubCallId CallUuid         //stub call uuid
+	svcMaps    ServiceCache     //service
+	rwlock     sync.RWMutex     //read write lock
+	logger     log.ILogger      //logger
+	flog       log.IFieldLogger //structured logger
+	opt        *Options         //rpc options
 }
 
 func newStubManager() *StubManager {
@@ -29,11 +31,15 @@ func newStubManager() *StubManager {
 		make(ServiceCache, common.DefaultServiceCache),
 		sync.RWMutex{},
 		nil,
+		nil,
+		nil,
 	}
 }
 
-func (m *StubManager) Init(logger log.ILogger) {
+func (m *StubManager) Init(logger log.ILogger, flog log.IFieldLogger, opt *Options) {
 	m.logger = logger
+	m.flog = flog
+	m.opt = opt
 }
 
 func (m *StubManager) GeneUuid() CallUuid {
@@ -54,13 +60,18 @@ func (m *StubManager) Add(ctx context.Context, impl IStub) (err error) {
 	//check repeated add
 	_, ok := m.svcMaps[impl.GetUUID()]
 	if ok {
-		err = errors.NewRpcError(errors.ServiceHasExist, "service %s has exits in this programe", impl.GetUUID())
+		err = errors.NewRpcError(errors.ServiceHasExist, "service %d has exits in this programe", impl.GetUUID())
 		m.logger.Error("[Service] %s,%d,0 service has been added to this programe", impl.GetServiceName(), impl.GetUUID())
 		return
 	}
 
 	//create stub instance
-	sb := newStubWrapper(impl, m.logger)
+	opt := m.opt
+	if opt == nil {
+		opt = defaultOptions()
+	}
+	setting := opt.serviceSetting(uint64(impl.GetUUID()), impl.GetServiceName())
+	sb := newStubWrapper(impl, m.logger, m.flog, setting)
 	if sb == nil {
 		err = errors.NewRpcError(errors.CommErr, "service %s create instance error", impl.GetServiceName())
 		m.logger.Error("[Service] %s,%d,0 create service instance error!", impl.GetServiceName(), impl.GetUUID())
@@ -80,13 +91,17 @@ func (m *StubManager) Add(ctx context.Context, impl IStub) (err error) {
 	return
 }
 
+// Tick tick services out of lock, OnTick and calls of tick mode services may register
+// or look up services
 func (m *StubManager) Tick() {
-	// read lock
 	m.rwlock.RLock()
-	// defer unlock
-	defer m.rwlock.RUnlock()
-
+	stubs := make([]*stubWrapper, 0, len(m.svcMaps))
 	for _, v := range m.svcMaps {
+		stubs = append(stubs, v)
+	}
+	m.rwlock.RUnlock()
+
+	for _, v := range stubs {
 		if v.isValid() {
 			v.tick()
 		}
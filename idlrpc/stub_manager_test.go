@@ -0,0 +1,53 @@
+package idlrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/logger"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/log"
+)
+
+// otherStub test stub with another uuid
+type otherStub struct {
+	testStub
+}
+
+func (ts *otherStub) GetUUID() SvcUuid { return 2 }
+
+func TestTickHandlerRegistersService(t *testing.T) {
+	nl := &logger.NullLogger{}
+	opt := defaultOptions()
+	WithDispatchMode(1, DispatchTick)(opt)
+	m := newStubManager()
+	m.Init(nl, log.FromLogger(nl), opt)
+
+	registered := make(chan error, 1)
+	stub := &testStub{oneWay: true, handler: func(ctx context.Context, _ uint32, _ []byte) ([]byte, error) {
+		registered <- m.Add(ctx, &otherStub{})
+		return nil, nil
+	}}
+	if err := m.Add(context.Background(), stub); err != nil {
+		t.Fatal(err)
+	}
+	trans := newTestTrans()
+	if err := m.Get(1).doCallService(trans, newTestCall(trans, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		m.Tick()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("tick handler registering service blocks tick")
+	}
+	if err := <-registered; err != nil || m.Get(2) == nil {
+		t.Fatalf("service registered by tick handler %v", err)
+	}
+	m.UnInit()
+}
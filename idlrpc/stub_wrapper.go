@@ -2,15 +2,18 @@ package idlrpc
 
 import (
 	"context"
+	"fmt"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
-	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/common"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/log"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
 	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/transport"
+	"google.golang.org/protobuf/encoding/protowire"
 )
 
 // StubCallQueue service remote call queue
@@ -19,32 +22,69 @@ type stopSign chan struct{}
 
 // stubWrapper user stub wrapper
 type stubWrapper struct {
-	isClose   int32           //is this service not service again, 0 not, 1 closed
-	srvImp    IStub           //stub interface user implemenet
-	wg        sync.WaitGroup  //worker goroutine waiter
-	callQueue stubCallQueue   //rpc remote call queue
-	stopCh    stopSign        //stop signal channel, maybe be replaced with context cancel function
-	logger    log.ILogger     //logger instance
-	ctx       context.Context //graceful close single
+	isClose  int32            //is this service not service again, 0 not, 1 closed
+	srvImp   IStub            //stub interface user implemenet
+	wg       sync.WaitGroup   //worker goroutine waiter
+	queues   []stubCallQueue  //rpc remote call queue, one shared queue or one queue per worker in keyed mode
+	dispatch DispatchMode     //how calls are handed to workers
+	setting  serviceOptions   //service execution settings
+	expired  uint64           //count of calls dropped for caller's deadline passed
+	ctxField sync.Map         //method id -> number of Context field in method args
+	stopCh   stopSign         //stop signal channel, maybe be replaced with context cancel function
+	logger   log.ILogger      //logger instance
+	flog     log.IFieldLogger //structured logger, base of call loggers
+	ctx      context.Context  //graceful close single
 }
 
 // newStubWrapper create stubbase while service register
-func newStubWrapper(impl IStub, logger log.ILogger) *stubWrapper {
+func newStubWrapper(impl IStub, logger log.ILogger, flog log.IFieldLogger, setting serviceOptions) *stubWrapper {
 	if impl == nil {
 		panic("[IStub] register invalid service ")
-		return nil
+	}
+
+	dispatch := setting.dispatch
+	// keyed mode declared by idl annotation
+	if dispatch == DispatchDefault {
+		dispatch = DispatchShared
+		if _, ok := impl.(IKeyedStub); ok {
+			dispatch = DispatchKeyed
+		}
+	}
+
+	queueNum := uint32(1)
+	if dispatch == DispatchKeyed {
+		queueNum = workerNum(impl, setting)
+	}
+	queues := make([]stubCallQueue, queueNum)
+	for i := range queues {
+		queues[i] = make(stubCallQueue, setting.queueSize)
 	}
 
 	return &stubWrapper{
-		isClose:   0,
-		srvImp:    impl,
-		wg:        sync.WaitGroup{},
-		callQueue: make(stubCallQueue, common.DefaultCallCache),
-		stopCh:    make(stopSign),
-		logger:    logger,
+		isClose:  0,
+		srvImp:   impl,
+		wg:       sync.WaitGroup{},
+		queues:   queues,
+		dispatch: dispatch,
+		setting:  setting,
+		stopCh:   make(stopSign),
+		logger:   logger,
+		flog:     flog,
 	}
 }
 
+// workerNum service worker goroutine number, declared by idl or set by config
+func workerNum(impl IStub, setting serviceOptions) uint32 {
+	if setting.fixedWorkers {
+		return setting.workers
+	}
+	num := impl.GetMutipleNum()
+	if num == 0 {
+		num = setting.workers
+	}
+	return num
+}
+
 // Init service, after register
 func (s *stubWrapper) init(ctx context.Context) (err error) {
 	// add recover function to avoid throwing panic in OnTick function
@@ -58,7 +98,7 @@ func (s *stubWrapper) init(ctx context.Context) (err error) {
 			}
 			err = errors.NewRpcError(errors.CommErr, "service %s throw panic while executing the initialization function", s.srvImp.GetServiceName())
 			s.logger.Warn("[Service] %s,%d,0 service throw exception %v on init ", s.srvImp.GetServiceName(), s.srvImp.GetUUID(), r)
-			if stackTrace {
+			if s.setting.trace {
 				s.logger.Error("trace back: %s", string(debug.Stack()))
 			}
 		}
@@ -77,25 +117,31 @@ func (s *stubWrapper) start() {
 		panic("invalid service")
 	}
 
-	num := s.srvImp.GetMutipleNum()
-	if num == 0 {
-		num = common.DefaultServiceWorker
+	//tick mode service has no worker, calls are drained by tick
+	if s.dispatch == DispatchTick {
+		return
 	}
 
-	//start worker
+	num := workerNum(s.srvImp, s.setting)
+
+	//start worker, keyed worker owns its queue
 	for i := uint32(0); i < num; i++ {
 		s.wg.Add(1)
-		go s.loop()
+		if s.dispatch == DispatchKeyed {
+			go s.loop(s.queues[i])
+		} else {
+			go s.loop(s.queues[0])
+		}
 	}
 }
 
 // loop rpc remote call worker, read stub call from message queue
-func (s *stubWrapper) loop() {
+func (s *stubWrapper) loop(callQueue stubCallQueue) {
 	defer func() {
 		//maybe panic
 		if r := recover(); r != nil {
 			s.logger.Error("[Service] %s,%d,0 service method call panic!!", s.srvImp.GetServiceName(), s.srvImp.GetUUID())
-			if stackTrace {
+			if s.setting.trace {
 				s.logger.Error("trace back: %s", string(debug.Stack()))
 			}
 		}
@@ -120,46 +166,66 @@ func (s *stubWrapper) loop() {
 		select {
 		case <-s.stopCh:
 			return
-		case call := <-s.callQueue:
+		case call := <-callQueue:
 			// stub call has been set to nil while shutdown rpc framework
 			if call == nil {
 				return
 			}
 
-			err := s.doCallMethod(call)
-			//TODO: destroy stub call
-			if err != nil {
-				//TODO: add record of error code
-				return
+			// failed call must not stop the worker, keyed queue is owned by this worker only
+			if err := s.doCallMethod(call); err != nil {
+				call.callLogger().Warn("[Service] service method call error", log.KV("error", err))
 			}
 		}
 	}
 }
 
+// bindCall set method and logger of service to stub call
+func (s *stubWrapper) bindCall(stubCall *StubCall) {
+	stubCall.signature = s.srvImp.GetSignature(stubCall.MethodID())
+	if s.srvImp.IsOneWay(stubCall.MethodID()) {
+		stubCall.oneWay = 1
+	}
+	stubCall.service = s.srvImp.GetServiceName()
+	stubCall.baseLog = s.flog
+}
+
 func (s *stubWrapper) doCallMethod(stubCall *StubCall) (err error) {
 	if stubCall == nil {
 		s.logger.Error("[Service] %s,%d,0 stub call pointer is invalid", s.srvImp.GetServiceName(), s.srvImp.GetUUID())
 		return errors.ErrStubCallInvalid
 	}
+	s.bindCall(stubCall)
+	defer stubCall.release()
+	//caller has timeout, skip execution, response will be discarded anyway
+	if stubCall.isExpired(time.Now()) {
+		atomic.AddUint64(&s.expired, 1)
+		stubCall.callLogger().Debug("[Service] method dropped, caller deadline passed")
+		return nil
+	}
 	//recover function, not break loop
 	defer func() {
 		//recover panic
 		if r := recover(); r != nil {
-			if !s.srvImp.IsOneWay(stubCall.MethodID()) {
+			//panic response replaces deferred one
+			if !s.srvImp.IsOneWay(stubCall.MethodID()) && (stubCall.takeReply(replyPending) || stubCall.takeReply(replyDeferred)) {
 				//build rpc response, notify client exception
 				var pkg []byte
+				panicInfo := r
 				if info, ok := r.(errors.RpcPanicInfo); ok {
 					pkg = info.Pkg
+					panicInfo = info.Info
 				}
+				pkg = protocol.AppendExceptionInfo(pkg, uint32(errors.ServicePanic), "", fmt.Sprint(panicInfo))
 				resp := protocol.BuildException(stubCall.CallID(), pkg)
-				respData, pkgLen := protocol.PackRespMsg(resp)
+				respData, pkgLen := stubCall.wire.PackRespMsg(resp)
 				if respData == nil || pkgLen == 0 {
-					s.logger.Error("[Service] %s,%d,0 serialize response bytes error !", s.srvImp.GetServiceName(), s.srvImp.GetUUID())
+					stubCall.callLogger().Error("[Service] serialize response bytes error !")
 					return
 				} else {
-					s.logger.Error("[Service] %s,%d,0 runtime error: %v ", s.srvImp.GetServiceName(), stubCall.MethodID(), r)
-					if stackTrace {
-						s.logger.Error("trace back: %s", string(debug.Stack()))
+					stubCall.callLogger().Error("[Service] method runtime error", log.KV("panic", r))
+					if s.setting.trace {
+						stubCall.callLogger().Error("trace back", log.KV("stack", string(debug.Stack())))
 					}
 				}
 				err = stubCall.doRet(respData)
@@ -177,19 +243,51 @@ func (s *stubWrapper) doCallMethod(stubCall *StubCall) (err error) {
 	}
 }
 
+// exceptionInfo code, name and message of handler error which will be sent back to caller
+func exceptionInfo(err error) (code uint32, name, detail string) {
+	code = uint32(errors.MethodException)
+	detail = err.Error()
+	var ex errors.IException
+	if errors.As(err, &ex) {
+		code, name = ex.ExceptionCode(), ex.ExceptionName()
+		detail = strings.TrimPrefix(detail, name+": ")
+	}
+	return
+}
+
+// callContext handler context, carry stub call, call logger and caller's deadline
+func (s *stubWrapper) callContext(stubCall *StubCall) (context.Context, context.CancelFunc) {
+	ctx := context.WithValue(context.Background(), callkey{}, stubCall)
+	ctx = log.NewLazyContext(ctx, stubCall.logContext)
+	if deadline, ok := stubCall.Deadline(); ok {
+		return context.WithDeadline(ctx, deadline)
+	}
+	return context.WithCancel(ctx)
+}
+
 func (s *stubWrapper) rpcCall(stubCall *StubCall) (err error) {
-	ctx := context.Background()
-	ctx = context.WithValue(ctx, callkey{}, stubCall)
+	ctx, cancel := s.callContext(stubCall)
+	defer cancel()
 	//not check transport first
 	buffer, err := s.srvImp.Call(ctx, stubCall.MethodID(), stubCall.buffer)
+	oneWay := s.srvImp.IsOneWay(stubCall.MethodID())
+	if !oneWay && !stubCall.takeReply(replyPending) {
+		// handler called ReplyLater, response is sent by DeferredReply and return value is discarded
+		if err != nil {
+			stubCall.callLogger().Warn("[Service] deferred method returns error, discarded", log.KV("error", err))
+		}
+		return nil
+	}
 	// not one-way function, send response
-	if !s.srvImp.IsOneWay(stubCall.MethodID()) {
+	if !oneWay {
 		execCode := protocol.IDL_SUCCESS
 		if err != nil {
 			//log error
-			s.logger.Warn("[Service] %s,%d,%d method %s call error %v", s.srvImp.GetServiceName(), s.srvImp.GetUUID(), stubCall.CallID(), s.srvImp.GetSignature(stubCall.MethodID()), err)
-			//set error
+			stubCall.callLogger().Warn("[Service] method call error", log.KV("error", err))
+			//set error, tell caller the code and message
 			execCode = protocol.IDL_SERVICE_ERROR
+			code, name, detail := exceptionInfo(err)
+			buffer = protocol.AppendExceptionInfo(buffer, code, name, detail)
 		}
 		//Build response package
 		resp := &protocol.ResponsePackage{
@@ -197,15 +295,15 @@ func (s *stubWrapper) rpcCall(stubCall *StubCall) (err error) {
 		}
 		//srvID compatible with cpp implement, must be zero in golang
 		protocol.BuildRespHeader(resp, 0, stubCall.CallID(), execCode)
-		respData, pkgLen := protocol.PackRespMsg(resp)
+		respData, pkgLen := stubCall.wire.PackRespMsg(resp)
 		if respData == nil || pkgLen == 0 {
-			s.logger.Error("[Service] %s,%d,0 serialize response bytes error !", s.srvImp.GetServiceName(), s.srvImp.GetUUID())
+			stubCall.callLogger().Error("[Service] serialize response bytes error !")
 			err = errors.NewMethodExecError(s.srvImp.GetServiceName(), s.srvImp.GetSignature(stubCall.MethodID()))
 			return
 		}
 		err = stubCall.doRet(respData)
 		if err != nil {
-			s.logger.Warn("[Service] %s,%d,%d method %s send data error!", s.srvImp.GetServiceName(), s.srvImp.GetUUID(), stubCall.CallID(), s.srvImp.GetSignature(stubCall.MethodID()), err)
+			stubCall.callLogger().Warn("[Service] method send data error!", log.KV("error", err))
 			return
 		}
 	}
@@ -213,18 +311,28 @@ func (s *stubWrapper) rpcCall(stubCall *StubCall) (err error) {
 }
 
 func (s *stubWrapper) rpcProxyCall(stubCall *StubCall) (err error) {
-	ctx := context.Background()
-	ctx = context.WithValue(ctx, callkey{}, stubCall)
+	ctx, cancel := s.callContext(stubCall)
+	defer cancel()
 	//not check transport first
 	buffer, err := s.srvImp.Call(ctx, stubCall.MethodID(), stubCall.buffer)
+	oneWay := s.srvImp.IsOneWay(stubCall.MethodID())
+	if !oneWay && !stubCall.takeReply(replyPending) {
+		// handler called ReplyLater, response is sent by DeferredReply and return value is discarded
+		if err != nil {
+			stubCall.callLogger().Warn("[Service] deferred method returns error, discarded", log.KV("error", err))
+		}
+		return nil
+	}
 	// not one-way function, send response
-	if !s.srvImp.IsOneWay(stubCall.MethodID()) {
+	if !oneWay {
 		execCode := protocol.IDL_SUCCESS
 		if err != nil {
 			//log error
-			s.logger.Warn("[Service] %s,%d,%d method %s exec error %v", s.srvImp.GetServiceName(), s.srvImp.GetUUID(), stubCall.CallID(), s.srvImp.GetSignature(stubCall.MethodID()), err)
-			//set error
+			stubCall.callLogger().Warn("[Service] method exec error", log.KV("error", err))
+			//set error, tell caller the code and message
 			execCode = protocol.IDL_SERVICE_ERROR
+			code, name, detail := exceptionInfo(err)
+			buffer = protocol.AppendExceptionInfo(buffer, code, name, detail)
 		}
 		//Build response package
 		resp := &protocol.ProxyRespPackage{
@@ -232,15 +340,15 @@ func (s *stubWrapper) rpcProxyCall(stubCall *StubCall) (err error) {
 		}
 		//srvID compatible with cpp implement, must be zero in golang
 		protocol.BuildProxyRespHeader(resp, 0, stubCall.CallID(), execCode, stubCall.globalID)
-		respData, pkgLen := protocol.PackProxyRespMsg(resp)
+		respData, pkgLen := stubCall.wire.PackProxyRespMsg(resp)
 		if respData == nil || pkgLen == 0 {
-			s.logger.Error("[Service] %s,%d,0 serialize response bytes error !", s.srvImp.GetServiceName(), s.srvImp.GetUUID())
+			stubCall.callLogger().Error("[Service] serialize response bytes error !")
 			err = errors.NewMethodExecError(s.srvImp.GetServiceName(), s.srvImp.GetSignature(stubCall.MethodID()))
 			return
 		}
 		err = stubCall.doRet(respData)
 		if err != nil {
-			s.logger.Warn("[Service] %s,%d,%d method %s send data error!", s.srvImp.GetServiceName(), s.srvImp.GetUUID(), stubCall.CallID(), s.srvImp.GetSignature(stubCall.MethodID()), err)
+			stubCall.callLogger().Warn("[Service] method send data error!", log.KV("error", err))
 			return
 		}
 	}
@@ -260,9 +368,34 @@ func (s *stubWrapper) tick() {
 		}
 	}()
 	//TODO check impl status
+	if s.dispatch == DispatchTick {
+		s.drain()
+	}
 	s.srvImp.OnTick()
 }
 
+// drain execute queued calls of tick mode service in tick goroutine, until queue empty or budget used up
+func (s *stubWrapper) drain() {
+	start := time.Now()
+	for n := uint32(0); s.setting.tickCalls == 0 || n < s.setting.tickCalls; n++ {
+		select {
+		case call := <-s.queues[0]:
+			// queue has been closed while shutdown
+			if call == nil {
+				return
+			}
+			if err := s.doCallMethod(call); err != nil {
+				call.callLogger().Warn("[Service] service method call error", log.KV("error", err))
+			}
+		default:
+			return
+		}
+		if s.setting.tickTime > 0 && time.Since(start) >= s.setting.tickTime {
+			return
+		}
+	}
+}
+
 // close close service
 func (s *stubWrapper) close() {
 	//atomic check close status
@@ -277,7 +410,9 @@ func (s *stubWrapper) close() {
 		s.logger.Info("[Service] %s,%d,0 service has been closed by other goroutine!")
 		return
 	}
-	close(s.callQueue)
+	for _, queue := range s.queues {
+		close(queue)
+	}
 	//close stop channel
 	close(s.stopCh)
 	s.wg.Wait()
@@ -288,31 +423,96 @@ func (s *stubWrapper) close() {
 func (s *stubWrapper) addCall(call *StubCall) error {
 	//check status
 	if atomic.LoadInt32(&s.isClose) != 0 {
-		s.logger.Warn("[Service] %s,%d,0 service has been shutdown while stub call %d", s.srvImp.GetServiceName(), s.srvImp.GetUUID(), call.CallID())
+		call.callLogger().Warn("[Service] service has been shutdown")
 		return errors.NewRpcError(errors.ServiceShutdown, "service %s has shutdown ", s.srvImp.GetServiceName())
 	}
 
 	//check channel
-	if s.callQueue == nil {
+	if len(s.queues) == 0 {
 		return errors.NewRpcError(errors.ServiceShutdown, "service %s has shutdown ", s.srvImp.GetServiceName())
 	}
+	//tick mode, OnMessage may run on the tick goroutine, do not block it
+	if s.dispatch == DispatchTick {
+		select {
+		case s.queues[0] <- call:
+			return nil
+		default:
+			call.callLogger().Warn("[Service] service call queue is full")
+			return errors.NewRpcError(errors.ServiceBusy, "service %s call queue is full ", s.srvImp.GetServiceName())
+		}
+	}
 	//add to callQueue
-	s.callQueue <- call
+	s.queues[s.queueIndex(call)] <- call
 	return nil
 }
 
+// queueIndex select the queue of stub call, calls with same key go to the same worker
+func (s *stubWrapper) queueIndex(call *StubCall) int {
+	if len(s.queues) == 1 {
+		return 0
+	}
+	return int(s.dispatchKey(call) % uint64(len(s.queues)))
+}
+
+// dispatchKey ordering key of stub call, idl annotation first, then global index, then transport
+func (s *stubWrapper) dispatchKey(call *StubCall) uint64 {
+	if ks, ok := s.srvImp.(IKeyedStub); ok {
+		if key, ok := ks.GetDispatchKey(call.MethodID(), call.buffer); ok {
+			return key
+		}
+	}
+	if call.globalID != InvalidGlobalIndex {
+		return uint64(call.globalID)
+	}
+	if call.trans != nil {
+		return uint64(call.trans.GetID())
+	}
+	return 0
+}
+
+// stats service runtime statistics
+func (s *stubWrapper) stats() ServiceStats {
+	workers := 0
+	if s.dispatch != DispatchTick {
+		workers = int(workerNum(s.srvImp, s.setting))
+	}
+	return ServiceStats{
+		Expired:   atomic.LoadUint64(&s.expired),
+		Workers:   workers,
+		QueueSize: cap(s.queues[0]),
+	}
+}
+
 func (s *stubWrapper) isValid() bool {
 	return atomic.LoadInt32(&s.isClose) == 0
 }
 
+// ctxFieldOf number of Context field in args of method, looked up in generated messages once per method
+func (s *stubWrapper) ctxFieldOf(methodId uint32) protowire.Number {
+	if num, ok := s.ctxField.Load(methodId); ok {
+		return num.(protowire.Number)
+	}
+	num := protocol.CtxField(protocol.ArgsDescriptor(s.srvImp.GetServiceName(), s.srvImp.GetSignature(methodId)))
+	s.ctxField.Store(methodId, num)
+	return num
+}
+
 func (s *stubWrapper) doCallService(trans transport.ITransport, stubCall *StubCall) error {
 	//check valid
 	if !s.isValid() {
 		return errors.ErrTransClose
 	}
+	stubCall.initCtxInfo(s.ctxFieldOf(stubCall.MethodID()))
+	s.bindCall(stubCall)
 	// add stub call to service
 	err := s.addCall(stubCall)
 	if err != nil {
+		// call is not executed, tell caller instead of letting it wait for timeout
+		if !s.srvImp.IsOneWay(stubCall.MethodID()) && stubCall.takeReply(replyPending) {
+			if rerr := stubCall.respond(nil, err); rerr != nil {
+				stubCall.callLogger().Warn("[Service] send reject response error", log.KV("error", rerr))
+			}
+		}
 		return err
 	}
 	return nil
@@ -0,0 +1,190 @@
+package idlrpc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/internal/logger"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/errors"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/log"
+	"github.com/CloudGuan/rpc-backend-go/idlrpc/pkg/protocol"
+)
+
+type (
+	// testStub stub running handler, all calls have the same dispatch key
+	testStub struct {
+		oneWay  bool
+		handler func(ctx context.Context, methodId uint32, req []byte) ([]byte, error)
+	}
+
+	// testTrans transport recording sent packages
+	testTrans struct {
+		ch chan []byte
+	}
+)
+
+func (ts *testStub) GetUUID() SvcUuid                    { return 1 }
+func (ts *testStub) GetServiceName() string              { return "Test" }
+func (ts *testStub) GetSignature(methodId uint32) string { return "method" }
+func (ts *testStub) GetMutipleNum() uint32               { return 0 }
+func (ts *testStub) IsOneWay(methodId uint32) bool       { return ts.oneWay }
+func (ts *testStub) OnAfterFork(context.Context) bool    { return true }
+func (ts *testStub) OnBeforeDestroy() bool               { return true }
+func (ts *testStub) OnTick() bool                        { return true }
+func (ts *testStub) GetStatus() ServiceStatus            { return SERVICE_RESOLVED }
+func (ts *testStub) SetStatus(ServiceStatus)             {}
+func (ts *testStub) GetDispatchKey(uint32, []byte) (uint64, bool) {
+	return 7, true
+}
+func (ts *testStub) Call(ctx context.Context, methodId uint32, req []byte) ([]byte, error) {
+	return ts.handler(ctx, methodId, req)
+}
+
+func newTestTrans() *testTrans {
+	return &testTrans{ch: make(chan []byte, 64)}
+}
+
+func (tt *testTrans) Write(pkg []byte, length int) (int, error) { return length, nil }
+func (tt *testTrans) Read(pkg []byte, length int) (int, error)  { return 0, nil }
+func (tt *testTrans) Peek(length int) ([]byte, int, error)      { return nil, 0, nil }
+func (tt *testTrans) Send(pkg []byte) error {
+	tt.ch <- append([]byte(nil), pkg...)
+	return nil
+}
+func (tt *testTrans) Close()                                {}
+func (tt *testTrans) Size() uint32                          { return 0 }
+func (tt *testTrans) IsClose() bool                         { return false }
+func (tt *testTrans) GetID() uint32                         { return 1 }
+func (tt *testTrans) SetID(uint32)                          {}
+func (tt *testTrans) LocalAddr() string                     { return "local" }
+func (tt *testTrans) RemoteAddr() string                    { return "remote" }
+func (tt *testTrans) GlobalIndex() protocol.GlobalIndexType { return InvalidGlobalIndex }
+func (tt *testTrans) Heartbeat() error                      { return nil }
+
+// response wait for response sent back to caller, return header and body
+func (tt *testTrans) response(t *testing.T) (*protocol.RpcCallRetHeader, []byte) {
+	select {
+	case pkg := <-tt.ch:
+		header := protocol.ReadRetHeader(pkg)
+		if header == nil {
+			t.Fatal("invalid response")
+		}
+		return header, pkg[protocol.RespHeadSize:]
+	case <-time.After(time.Second):
+		t.Fatal("no response")
+	}
+	return nil, nil
+}
+
+func newTestWrapper(stub IStub, setting serviceOptions) *stubWrapper {
+	nl := &logger.NullLogger{}
+	return newStubWrapper(stub, nl, log.FromLogger(nl), setting)
+}
+
+func newTestCall(trans *testTrans, callID uint32) *StubCall {
+	return &StubCall{callID: callID, methodID: 1, globalID: InvalidGlobalIndex, trans: trans, wire: protocol.Default()}
+}
+
+func TestKeyedWorkerSurvivesError(t *testing.T) {
+	var calls int32
+	stub := &testStub{oneWay: true, handler: func(context.Context, uint32, []byte) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.NewRpcError(errors.CommErr, "handler failed")
+	}}
+	sw := newTestWrapper(stub, serviceOptions{dispatch: DispatchKeyed, workers: 4, queueSize: 1, fixedWorkers: true})
+	sw.start()
+	defer sw.close()
+
+	// every call goes to the same worker, queue of 1 blocks adding while worker is gone
+	done := make(chan struct{})
+	go func() {
+		for i := uint32(1); i <= 20; i++ {
+			_ = sw.addCall(newTestCall(newTestTrans(), i))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("keyed queue blocked after %d failed calls", atomic.LoadInt32(&calls))
+	}
+	for start := time.Now(); atomic.LoadInt32(&calls) != 20; time.Sleep(time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("executed %d of 20 calls", atomic.LoadInt32(&calls))
+		}
+	}
+}
+
+func TestTickQueueFullResponds(t *testing.T) {
+	stub := &testStub{handler: func(context.Context, uint32, []byte) ([]byte, error) {
+		return nil, nil
+	}}
+	sw := newTestWrapper(stub, serviceOptions{dispatch: DispatchTick, queueSize: 1})
+	sw.start()
+	defer sw.close()
+
+	trans := newTestTrans()
+	if err := sw.doCallService(trans, newTestCall(trans, 1)); err != nil {
+		t.Fatal(err)
+	}
+	err := sw.doCallService(trans, newTestCall(trans, 2))
+	var rpcErr *errors.RpcError
+	if !errors.As(err, &rpcErr) || rpcErr.Code() != errors.ServiceBusy {
+		t.Fatalf("full queue returns %v", err)
+	}
+	// rejected call is answered at once with busy exception
+	header, body := trans.response(t)
+	code, _, _, ok := protocol.ReadExceptionInfo(body)
+	if header.CallID != 2 || header.ErrorCode != protocol.IDL_SERVICE_ERROR || !ok || code != uint32(errors.ServiceBusy) {
+		t.Fatalf("reject response %+v code %d", header, code)
+	}
+
+	sw.tick()
+	if header, _ = trans.response(t); header.CallID != 1 || header.ErrorCode != protocol.IDL_SUCCESS {
+		t.Fatalf("queued call response %+v", header)
+	}
+}
+
+func TestDeferredReplyHandlerError(t *testing.T) {
+	replies := make(chan *DeferredReply, 2)
+	stub := &testStub{handler: func(ctx context.Context, _ uint32, _ []byte) ([]byte, error) {
+		reply, err := ReplyLater(ctx)
+		if err != nil {
+			return nil, err
+		}
+		replies <- reply
+		return nil, errors.NewRpcError(errors.CommErr, "returned after deferring")
+	}}
+	sw := newTestWrapper(stub, serviceOptions{})
+
+	for _, globalID := range []protocol.GlobalIndexType{InvalidGlobalIndex, 9} {
+		trans := newTestTrans()
+		call := newTestCall(trans, 1)
+		call.globalID = globalID
+		if err := sw.doCallMethod(call); err != nil {
+			t.Fatalf("global index %d: deferred call returns %v", globalID, err)
+		}
+		select {
+		case pkg := <-trans.ch:
+			t.Fatalf("global index %d: handler error answered before deferred reply %v", globalID, pkg)
+		default:
+		}
+		if err := (<-replies).Reply(nil); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case pkg := <-trans.ch:
+			if globalID == InvalidGlobalIndex {
+				if header := protocol.ReadRetHeader(pkg); header == nil || header.ErrorCode != protocol.IDL_SUCCESS {
+					t.Fatalf("deferred response %+v", header)
+				}
+			} else if header := protocol.ReadProxyRetHeader(pkg); header == nil || header.ErrorCode != protocol.IDL_SUCCESS || header.GlobalIndex != globalID {
+				t.Fatalf("deferred proxy response %+v", header)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no deferred response")
+		}
+	}
+}